@@ -0,0 +1,16 @@
+package context
+
+import (
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// AfAnaSubscription is an AnalyticsExposure subscription of an AF together
+// with the Nnwdaf_EventsSubscription subscription created for it.
+type AfAnaSubscription struct {
+	SubID        string                             `json:"subId"`
+	AnaSub       *nef_models.AnalyticsExposureSubsc `json:"anaSub,omitempty"`
+	NotifCorreID string                             `json:"notifCorreId"`
+	NwdafSubID   string                             `json:"nwdafSubId,omitempty"`
+	Log          *logrus.Entry                      `json:"-"`
+}
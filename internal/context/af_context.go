@@ -1,27 +1,32 @@
 package context
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"sync"
 
 	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
 	"github.com/free5gc/openapi/models"
 	"github.com/sirupsen/logrus"
 )
 
 type AfData struct {
-	AfID       string
-	NumSubscID uint64
-	NumTransID uint64
-	Subs       map[string]*AfSubscription
-	PfdTrans   map[string]*AfPfdTransaction
-<<<<<<< Updated upstream
-=======
-	QosSubs    map[string]*AfQosSubscription
->>>>>>> Stashed changes
-	Mu         sync.RWMutex
-	Log        *logrus.Entry
+	AfID       string                           `json:"afId"`
+	NumSubscID uint64                           `json:"numSubscId"`
+	NumTransID uint64                           `json:"numTransId"`
+	Subs       map[string]*AfSubscription       `json:"subs"`
+	PfdTrans   map[string]*AfPfdTransaction     `json:"pfdTrans"`
+	QosSubs    map[string]*AfQosSubscription    `json:"qosSubs"`
+	MonSubs    map[string]*AfMonSubscription    `json:"monSubs"`
+	AnaSubs    map[string]*AfAnaSubscription    `json:"anaSubs"`
+	DevTrigs   map[string]*AfDevTrigTransaction `json:"devTrigs"`
+	NiddConfs  map[string]*AfNiddConfiguration  `json:"niddConfs"`
+	Mu         sync.RWMutex                     `json:"-"`
+	Log        *logrus.Entry                    `json:"-"`
+
+	nefCtx *NefContext
 }
 
 func (a *AfData) NewSub(numCorreID uint64, tiSub *models.NefTrafficInfluSub) *AfSubscription {
@@ -33,9 +38,100 @@ func (a *AfData) NewSub(numCorreID uint64, tiSub *models.NefTrafficInfluSub) *Af
 		Log:          a.Log.WithField(logger.FieldSubID, fmt.Sprintf("SUB:%d", a.NumSubscID)),
 	}
 	sub.Log.Infoln("New subscription")
+	a.Persist()
 	return &sub
 }
 
+func (a *AfData) DeleteSub(subID string) {
+	delete(a.Subs, subID)
+	a.Persist()
+}
+
+func (a *AfData) NewMonSub(numCorreID uint64, monSub *nef_models.MonitoringEventSubscription) *AfMonSubscription {
+	a.NumSubscID++
+	sub := AfMonSubscription{
+		NotifCorreID: strconv.FormatUint(numCorreID, 10),
+		SubID:        strconv.FormatUint(a.NumSubscID, 10),
+		MonSub:       monSub,
+		Log:          a.Log.WithField(logger.FieldSubID, fmt.Sprintf("MON:%d", a.NumSubscID)),
+	}
+	sub.Log.Infoln("New monitoring event subscription")
+	a.Persist()
+	return &sub
+}
+
+func (a *AfData) DeleteMonSub(subID string) {
+	delete(a.MonSubs, subID)
+	a.Persist()
+}
+
+func (a *AfData) NewAnaSub(numCorreID uint64, anaSub *nef_models.AnalyticsExposureSubsc) *AfAnaSubscription {
+	a.NumSubscID++
+	sub := AfAnaSubscription{
+		NotifCorreID: strconv.FormatUint(numCorreID, 10),
+		SubID:        strconv.FormatUint(a.NumSubscID, 10),
+		AnaSub:       anaSub,
+		Log:          a.Log.WithField(logger.FieldSubID, fmt.Sprintf("ANA:%d", a.NumSubscID)),
+	}
+	sub.Log.Infoln("New analytics exposure subscription")
+	a.Persist()
+	return &sub
+}
+
+func (a *AfData) DeleteAnaSub(subID string) {
+	delete(a.AnaSubs, subID)
+	a.Persist()
+}
+
+func (a *AfData) NewDevTrig(
+	numCorreID uint64, devTrig *nef_models.DeviceTriggering,
+) *AfDevTrigTransaction {
+	a.NumTransID++
+	devTr := AfDevTrigTransaction{
+		NotifCorreID: strconv.FormatUint(numCorreID, 10),
+		TransID:      strconv.FormatUint(a.NumTransID, 10),
+		DevTrig:      devTrig,
+		Log:          a.Log.WithField(logger.FieldSubID, fmt.Sprintf("DT:%d", a.NumTransID)),
+	}
+	devTr.Log.Infoln("New device triggering transaction")
+	a.Persist()
+	return &devTr
+}
+
+func (a *AfData) DeleteDevTrig(transID string) {
+	delete(a.DevTrigs, transID)
+	a.Persist()
+}
+
+func (a *AfData) NewNiddConf(niddConf *nef_models.NiddConfiguration, gpsi string) *AfNiddConfiguration {
+	a.NumSubscID++
+	conf := AfNiddConfiguration{
+		ConfigID:    strconv.FormatUint(a.NumSubscID, 10),
+		NiddConf:    niddConf,
+		Gpsi:        gpsi,
+		DlTransfers: make(map[string]*AfNiddDlTransfer),
+		Log:         a.Log.WithField(logger.FieldSubID, fmt.Sprintf("NIDD:%d", a.NumSubscID)),
+	}
+	conf.Log.Infoln("New NIDD configuration")
+	a.Persist()
+	return &conf
+}
+
+func (a *AfData) DeleteNiddConf(configID string) {
+	delete(a.NiddConfs, configID)
+	a.Persist()
+}
+
+// FindNiddConf returns the NIDD configuration of the UE.
+func (a *AfData) FindNiddConf(gpsi string) *AfNiddConfiguration {
+	for _, conf := range a.NiddConfs {
+		if conf.Gpsi == gpsi {
+			return conf
+		}
+	}
+	return nil
+}
+
 func (a *AfData) NewPfdTrans() *AfPfdTransaction {
 	a.NumTransID++
 	pfdTr := AfPfdTransaction{
@@ -44,9 +140,15 @@ func (a *AfData) NewPfdTrans() *AfPfdTransaction {
 		Log:       a.Log.WithField(logger.FieldPfdTransID, fmt.Sprintf("PFDT:%d", a.NumTransID)),
 	}
 	pfdTr.Log.Infoln("New pfd transcation")
+	a.Persist()
 	return &pfdTr
 }
 
+func (a *AfData) DeletePfdTrans(transID string) {
+	delete(a.PfdTrans, transID)
+	a.Persist()
+}
+
 func (a *AfData) IsAppIDExisted(appID string) (string, bool) {
 	for _, pfdTrans := range a.PfdTrans {
 		if _, ok := pfdTrans.ExtAppIDs[appID]; ok {
@@ -55,14 +157,13 @@ func (a *AfData) IsAppIDExisted(appID string) (string, bool) {
 	}
 	return "", false
 }
-<<<<<<< Updated upstream
-=======
 
 func (a *AfData) AddQosSubscription(sub *AfQosSubscription) {
 	if a.QosSubs == nil {
 		a.QosSubs = make(map[string]*AfQosSubscription)
 	}
 	a.QosSubs[sub.SubscriptionID] = sub
+	a.Persist()
 }
 
 func (a *AfData) GetQosSubscription(subID string) (*AfQosSubscription, bool) {
@@ -72,5 +173,77 @@ func (a *AfData) GetQosSubscription(subID string) (*AfQosSubscription, bool) {
 
 func (a *AfData) DeleteQosSubscription(subID string) {
 	delete(a.QosSubs, subID)
+	a.Persist()
+}
+
+// Persist writes the current state of the AF through to the state store.
+// AFs which are not added to the NefContext yet are skipped, AddAf stores them.
+// The caller must hold a.Mu, it is not acquired here.
+func (a *AfData) Persist() {
+	if a.nefCtx == nil || a.nefCtx.GetAf(a.AfID) != a {
+		return
+	}
+	a.persist()
+}
+
+func (a *AfData) persist() {
+	data, err := json.Marshal(a)
+	if err != nil {
+		a.Log.Errorf("Marshal AF for store failed: %+v", err)
+		return
+	}
+	if err = a.nefCtx.store.Put(bucketAfs, a.AfID, data); err != nil {
+		a.Log.Errorf("Persist AF failed: %+v", err)
+	}
+}
+
+// restoreRuntimeState rebuilds the maps and log entries which are not part of
+// the stored state.
+func (a *AfData) restoreRuntimeState() {
+	a.Log = logger.CtxLog.WithField(logger.FieldAFID, fmt.Sprintf("AF:%s", a.AfID))
+	if a.Subs == nil {
+		a.Subs = make(map[string]*AfSubscription)
+	}
+	if a.PfdTrans == nil {
+		a.PfdTrans = make(map[string]*AfPfdTransaction)
+	}
+	if a.QosSubs == nil {
+		a.QosSubs = make(map[string]*AfQosSubscription)
+	}
+	if a.MonSubs == nil {
+		a.MonSubs = make(map[string]*AfMonSubscription)
+	}
+	if a.AnaSubs == nil {
+		a.AnaSubs = make(map[string]*AfAnaSubscription)
+	}
+	if a.DevTrigs == nil {
+		a.DevTrigs = make(map[string]*AfDevTrigTransaction)
+	}
+	if a.NiddConfs == nil {
+		a.NiddConfs = make(map[string]*AfNiddConfiguration)
+	}
+	for _, sub := range a.Subs {
+		sub.Log = a.Log.WithField(logger.FieldSubID, fmt.Sprintf("SUB:%s", sub.SubID))
+	}
+	for _, pfdTr := range a.PfdTrans {
+		if pfdTr.ExtAppIDs == nil {
+			pfdTr.ExtAppIDs = make(map[string]struct{})
+		}
+		pfdTr.Log = a.Log.WithField(logger.FieldPfdTransID, fmt.Sprintf("PFDT:%s", pfdTr.TransID))
+	}
+	for _, qosSub := range a.QosSubs {
+		qosSub.Log = a.Log.WithField(logger.FieldSubID, qosSub.SubscriptionID)
+	}
+	for _, monSub := range a.MonSubs {
+		monSub.Log = a.Log.WithField(logger.FieldSubID, fmt.Sprintf("MON:%s", monSub.SubID))
+	}
+	for _, anaSub := range a.AnaSubs {
+		anaSub.Log = a.Log.WithField(logger.FieldSubID, fmt.Sprintf("ANA:%s", anaSub.SubID))
+	}
+	for _, devTr := range a.DevTrigs {
+		devTr.Log = a.Log.WithField(logger.FieldSubID, fmt.Sprintf("DT:%s", devTr.TransID))
+	}
+	for _, conf := range a.NiddConfs {
+		conf.restoreRuntimeState(a.Log)
+	}
 }
->>>>>>> Stashed changes
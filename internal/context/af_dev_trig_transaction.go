@@ -0,0 +1,16 @@
+package context
+
+import (
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// AfDevTrigTransaction is a device triggering transaction of an AF together
+// with the device trigger submitted to the SMS-SF for it.
+type AfDevTrigTransaction struct {
+	TransID      string                       `json:"transId"`
+	DevTrig      *nef_models.DeviceTriggering `json:"devTrig,omitempty"`
+	NotifCorreID string                       `json:"notifCorreId"`
+	SmsSfTrigUri string                       `json:"smsSfTrigUri,omitempty"`
+	Log          *logrus.Entry                `json:"-"`
+}
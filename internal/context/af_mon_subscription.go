@@ -0,0 +1,19 @@
+package context
+
+import (
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// AfMonSubscription is a MonitoringEvent subscription of an AF together with
+// the Nudm_EE or Namf_EventExposure subscription created for it.
+type AfMonSubscription struct {
+	SubID        string                                  `json:"subId"`
+	MonSub       *nef_models.MonitoringEventSubscription `json:"monSub,omitempty"`
+	NotifCorreID string                                  `json:"notifCorreId"`
+	UeIdentity   string                                  `json:"ueIdentity,omitempty"` // UDM EE ueIdentity
+	UdmEeSubID   string                                  `json:"udmEeSubId,omitempty"` // use in UDM case
+	AmfSubID     string                                  `json:"amfSubId,omitempty"`   // use in AMF case
+	NumReports   int32                                   `json:"numReports,omitempty"` // reports relayed to the AF
+	Log          *logrus.Entry                           `json:"-"`
+}
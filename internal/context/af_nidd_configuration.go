@@ -0,0 +1,69 @@
+package context
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// AfNiddConfiguration is a NIDD configuration of an AF together with the
+// downlink data buffered for its UE.
+type AfNiddConfiguration struct {
+	ConfigID    string                        `json:"configId"`
+	NiddConf    *nef_models.NiddConfiguration `json:"niddConf,omitempty"`
+	Gpsi        string                        `json:"gpsi"`
+	NumDlID     uint64                        `json:"numDlId"`
+	DlTransfers map[string]*AfNiddDlTransfer  `json:"dlTransfers"`
+	Log         *logrus.Entry                 `json:"-"`
+}
+
+// AfNiddDlTransfer is a downlink data delivery buffered by the NEF until the
+// UE can be reached.
+type AfNiddDlTransfer struct {
+	DlID       string                               `json:"dlId"`
+	DlTransfer *nef_models.NiddDownlinkDataTransfer `json:"dlTransfer,omitempty"`
+	Expiry     *time.Time                           `json:"expiry,omitempty"` // from maximumLatency
+	Delivering bool                                 `json:"-"`                // being delivered to the UE
+}
+
+func (a *AfNiddConfiguration) NewDlTransfer(dlTransfer *nef_models.NiddDownlinkDataTransfer) *AfNiddDlTransfer {
+	a.NumDlID++
+	dl := &AfNiddDlTransfer{
+		DlID:       strconv.FormatUint(a.NumDlID, 10),
+		DlTransfer: dlTransfer,
+	}
+	if dlTransfer.MaximumLatency > 0 {
+		expiry := time.Now().Add(time.Duration(dlTransfer.MaximumLatency) * time.Second)
+		dl.Expiry = &expiry
+	}
+	a.Log.Infof("New downlink data delivery[%s]", dl.DlID)
+	return dl
+}
+
+// BufferedDlTransfers returns the buffered downlink data in arrival order.
+func (a *AfNiddConfiguration) BufferedDlTransfers() []*AfNiddDlTransfer {
+	dls := make([]*AfNiddDlTransfer, 0, len(a.DlTransfers))
+	for _, dl := range a.DlTransfers {
+		dls = append(dls, dl)
+	}
+	// The IDs are allocated in sequence
+	slices.SortFunc(dls, func(x, y *AfNiddDlTransfer) int {
+		xID, _ := strconv.ParseUint(x.DlID, 10, 64)
+		yID, _ := strconv.ParseUint(y.DlID, 10, 64)
+		return cmp.Compare(xID, yID)
+	})
+	return dls
+}
+
+func (a *AfNiddConfiguration) restoreRuntimeState(afLog *logrus.Entry) {
+	a.Log = afLog.WithField(logger.FieldSubID, fmt.Sprintf("NIDD:%s", a.ConfigID))
+	if a.DlTransfers == nil {
+		a.DlTransfers = make(map[string]*AfNiddDlTransfer)
+	}
+}
@@ -5,9 +5,12 @@ import (
 )
 
 type AfPfdTransaction struct {
-	TransID   string
-	ExtAppIDs map[string]struct{}
-	Log       *logrus.Entry
+	TransID   string              `json:"transId"`
+	ExtAppIDs map[string]struct{} `json:"extAppIds"`
+	SuppFeat  string              `json:"suppFeat,omitempty"` // negotiated with the AF
+	// Where the PFDs failed to be deployed are reported to
+	NotificationDestination string        `json:"notificationDestination,omitempty"`
+	Log                     *logrus.Entry `json:"-"`
 }
 
 func (a *AfPfdTransaction) GetExtAppIDs() []string {
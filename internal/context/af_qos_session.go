@@ -1,16 +1,89 @@
 package context
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
 	"github.com/free5gc/openapi/models"
 	"github.com/sirupsen/logrus"
 )
 
 // AfQosSubscription represents a QoS exposure subscription tracked by NEF.
 type AfQosSubscription struct {
-	SubscriptionID string
-	AppSessID      string
-	NotifCorrID    string
-	Payload        *models.AppSessionContext
-	LastUpdate     *models.AppSessionContextUpdateData
-	Log            *logrus.Entry
+	SubscriptionID string                               `json:"subscriptionId"`
+	AppSessID      string                               `json:"appSessId"`
+	PcfUri         string                               `json:"pcfUri,omitempty"` // PCF owning the app session
+	NotifCorrID    string                               `json:"notifCorrId"`
+	QosSub         *models.AsSessionWithQoSSubscription `json:"qosSub,omitempty"`
+	Log            *logrus.Entry                        `json:"-"`
+}
+
+// PatchQosSubData applies the JSON merge patch (IETF RFC 7396) of the
+// AsSessionWithQoSSubscriptionPatch to the subscription: null removes an
+// attribute and a false boolean is kept. The attributes outside of the patch
+// schema are not modifiable and are ignored.
+func (s *AfQosSubscription) PatchQosSubData(qosSubPatch []byte) error {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(qosSubPatch, &patch); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+	for attr := range patch {
+		if !qosSubPatchAttrs[attr] {
+			delete(patch, attr)
+		}
+	}
+
+	orig, err := json.Marshal(s.QosSub)
+	if err != nil {
+		return err
+	}
+	var target interface{}
+	if err = json.Unmarshal(orig, &target); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err
+	}
+
+	qosSub := &models.AsSessionWithQoSSubscription{}
+	if err = json.Unmarshal(merged, qosSub); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+	s.QosSub = qosSub
+	return nil
+}
+
+// qosSubPatchAttrs holds the JSON names of the AsSessionWithQoSSubscriptionPatch attributes
+var qosSubPatchAttrs = func() map[string]bool {
+	attrs := make(map[string]bool)
+	t := reflect.TypeOf(models.AsSessionWithQoSSubscriptionPatch{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		attrs[name] = true
+	}
+	return attrs
+}()
+
+// mergePatch applies the merge patch to the decoded JSON document target as
+// defined in IETF RFC 7396 section 2
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
 }
@@ -6,12 +6,15 @@ import (
 )
 
 type AfSubscription struct {
-	SubID        string
-	TiSub        *models.NefTrafficInfluSub
-	AppSessID    string // use in single UE case
-	InfluID      string // use in multiple UE case
-	NotifCorreID string
-	Log          *logrus.Entry
+	SubID        string                     `json:"subId"`
+	TiSub        *models.NefTrafficInfluSub `json:"tiSub,omitempty"`
+	AppSessID    string                     `json:"appSessId,omitempty"` // use in single UE case
+	PcfUri       string                     `json:"pcfUri,omitempty"`    // PCF owning AppSessID
+	InfluID      string                     `json:"influId,omitempty"`   // use in multiple UE case
+	NotifCorreID string                     `json:"notifCorreId"`
+	// ackUri of the last SMF notification still waiting for the AF's AfAckInfo
+	PendingAckUri string        `json:"pendingAckUri,omitempty"`
+	Log           *logrus.Entry `json:"-"`
 }
 
 func (s *AfSubscription) PatchTiSubData(tiSubPatch *models.NefTrafficInfluSubPatch) {
@@ -0,0 +1,49 @@
+package context
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+	"github.com/sirupsen/logrus"
+)
+
+// EeSubscription is a Nnef_EventExposure subscription of a consumer NF together
+// with the Naf_EventExposure subscriptions created for it at the AFs.
+type EeSubscription struct {
+	SubID  string                        `json:"subId"`
+	EeSub  *models.NefEventExposureSubsc `json:"eeSub,omitempty"`
+	AfSubs map[string]string             `json:"afSubs,omitempty"` // Naf_EventExposure subscription URI by AF ID
+	Mu     sync.RWMutex                  `json:"-"`
+	Log    *logrus.Entry                 `json:"-"`
+
+	nefCtx *NefContext
+}
+
+// Persist writes the current state of the subscription through to the state
+// store. The caller must hold s.Mu, it is not acquired here.
+func (s *EeSubscription) Persist() {
+	if s.nefCtx == nil || s.nefCtx.GetEeSub(s.SubID) != s {
+		return
+	}
+	s.persist()
+}
+
+func (s *EeSubscription) persist() {
+	data, err := json.Marshal(s)
+	if err != nil {
+		s.Log.Errorf("Marshal event exposure subscription for store failed: %+v", err)
+		return
+	}
+	if err = s.nefCtx.store.Put(bucketEeSubs, s.SubID, data); err != nil {
+		s.Log.Errorf("Persist event exposure subscription failed: %+v", err)
+	}
+}
+
+func (s *EeSubscription) restoreRuntimeState() {
+	s.Log = logger.CtxLog.WithField(logger.FieldSubID, "EE:"+s.SubID)
+	if s.AfSubs == nil {
+		s.AfSubs = make(map[string]string)
+	}
+}
@@ -2,16 +2,29 @@ package context
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/store"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/openapi/oauth"
 	"github.com/google/uuid"
 )
 
+const (
+	bucketAfs    = "afs"
+	bucketEeSubs = "eeSubs"
+	bucketSmCtxs = "smContexts"
+	bucketMeta   = "meta"
+
+	keyNumCorreID = "numCorreID"
+)
+
 type nef interface {
 	Config() *factory.Config
 }
@@ -22,9 +35,20 @@ type NefContext struct {
 	nfInstID       string // NF Instance ID
 	pcfPaUri       string
 	udrDrUri       string
+	udmSdmUri      string
+	bsfMgmtUri     string
+	udmEeUri       string
+	amfEvtsUri     string
+	amfMtUri       string
+	nwdafEvtsUri   string
+	nwdafAnaUri    string
 	numCorreID     uint64
 	OAuth2Required bool
 	afs            map[string]*AfData
+	eeSubs         map[string]*EeSubscription // Nnef_EventExposure subscriptions
+	smContexts     map[string]*SmContext      // Nnef_SMContext SM contexts
+	capifApiIds    map[string]string          // serviceApiId published to CAPIF by API name
+	store          store.Store
 	mu             sync.RWMutex
 }
 
@@ -34,10 +58,98 @@ func NewContext(nef nef) (*NefContext, error) {
 		nfInstID: uuid.New().String(),
 	}
 	c.afs = make(map[string]*AfData)
+	c.eeSubs = make(map[string]*EeSubscription)
+	c.smContexts = make(map[string]*SmContext)
+	c.capifApiIds = make(map[string]string)
+
+	var err error
+	cfg := nef.Config()
+	if c.store, err = store.New(cfg.StoreBackend(), cfg.StorePath()); err != nil {
+		return nil, err
+	}
 	logger.CtxLog.Infof("New nfInstID: [%s]", c.nfInstID)
 	return c, nil
 }
 
+func (c *NefContext) Store() store.Store {
+	return c.store
+}
+
+// LoadFromStore restores the AFs and the correlation ID counter kept in the
+// state store, so that subscriptions created before a restart keep resolving.
+func (c *NefContext) LoadFromStore() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := c.store.Get(bucketMeta, keyNumCorreID)
+	switch {
+	case err == nil:
+		if c.numCorreID, err = strconv.ParseUint(string(data), 10, 64); err != nil {
+			return fmt.Errorf("invalid stored correlation ID [%s]: %w", data, err)
+		}
+	case !errors.Is(err, store.ErrNotFound):
+		return err
+	}
+
+	records, err := c.store.List(bucketAfs)
+	if err != nil {
+		return err
+	}
+	for afID, record := range records {
+		af := c.NewAf(afID)
+		if err = json.Unmarshal(record, af); err != nil {
+			return fmt.Errorf("invalid stored AF [%s]: %w", afID, err)
+		}
+		af.restoreRuntimeState()
+		c.afs[af.AfID] = af
+		af.Log.Infof("AF is restored with %d subscriptions, %d PFD transactions, %d QoS subscriptions"+
+			" and %d monitoring event subscriptions", len(af.Subs), len(af.PfdTrans), len(af.QosSubs), len(af.MonSubs))
+	}
+
+	eeRecords, err := c.store.List(bucketEeSubs)
+	if err != nil {
+		return err
+	}
+	for subID, record := range eeRecords {
+		sub := c.NewEeSub()
+		if err = json.Unmarshal(record, sub); err != nil {
+			return fmt.Errorf("invalid stored event exposure subscription [%s]: %w", subID, err)
+		}
+		sub.restoreRuntimeState()
+		c.eeSubs[sub.SubID] = sub
+	}
+
+	smCtxRecords, err := c.store.List(bucketSmCtxs)
+	if err != nil {
+		return err
+	}
+	for smContextID, record := range smCtxRecords {
+		smCtx := c.NewSmContext()
+		if err = json.Unmarshal(record, smCtx); err != nil {
+			return fmt.Errorf("invalid stored SM context [%s]: %w", smContextID, err)
+		}
+		smCtx.restoreRuntimeState()
+		c.smContexts[smCtx.SmContextID] = smCtx
+	}
+	logger.CtxLog.Infof("Restored %d AFs, %d event exposure subscriptions, %d SM contexts, numCorreID[%d]",
+		len(records), len(eeRecords), len(smCtxRecords), c.numCorreID)
+	return nil
+}
+
+func (c *NefContext) CloseStore() {
+	if err := c.store.Close(); err != nil {
+		logger.CtxLog.Errorf("Close store failed: %+v", err)
+	}
+}
+
+// persistCorreID must be called with c.mu held.
+func (c *NefContext) persistCorreID() {
+	err := c.store.Put(bucketMeta, keyNumCorreID, []byte(strconv.FormatUint(c.numCorreID, 10)))
+	if err != nil {
+		logger.CtxLog.Errorf("Persist numCorreID failed: %+v", err)
+	}
+}
+
 func (c *NefContext) NfInstID() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -77,16 +189,138 @@ func (c *NefContext) SetUdrDrUri(uri string) {
 	logger.CtxLog.Infof("Set udrDrUri: [%s]", c.udrDrUri)
 }
 
+func (c *NefContext) UdmSdmUri() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.udmSdmUri
+}
+
+func (c *NefContext) SetUdmSdmUri(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.udmSdmUri = uri
+	logger.CtxLog.Infof("Set udmSdmUri: [%s]", c.udmSdmUri)
+}
+
+func (c *NefContext) BsfMgmtUri() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.bsfMgmtUri
+}
+
+func (c *NefContext) SetBsfMgmtUri(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bsfMgmtUri = uri
+	logger.CtxLog.Infof("Set bsfMgmtUri: [%s]", c.bsfMgmtUri)
+}
+
+func (c *NefContext) UdmEeUri() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.udmEeUri
+}
+
+func (c *NefContext) SetUdmEeUri(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.udmEeUri = uri
+	logger.CtxLog.Infof("Set udmEeUri: [%s]", c.udmEeUri)
+}
+
+func (c *NefContext) AmfEvtsUri() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.amfEvtsUri
+}
+
+func (c *NefContext) SetAmfEvtsUri(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.amfEvtsUri = uri
+	logger.CtxLog.Infof("Set amfEvtsUri: [%s]", c.amfEvtsUri)
+}
+
+func (c *NefContext) AmfMtUri() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.amfMtUri
+}
+
+func (c *NefContext) SetAmfMtUri(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.amfMtUri = uri
+	logger.CtxLog.Infof("Set amfMtUri: [%s]", c.amfMtUri)
+}
+
+func (c *NefContext) NwdafEvtsUri() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nwdafEvtsUri
+}
+
+func (c *NefContext) SetNwdafEvtsUri(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nwdafEvtsUri = uri
+	logger.CtxLog.Infof("Set nwdafEvtsUri: [%s]", c.nwdafEvtsUri)
+}
+
+func (c *NefContext) NwdafAnaUri() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nwdafAnaUri
+}
+
+func (c *NefContext) SetNwdafAnaUri(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nwdafAnaUri = uri
+	logger.CtxLog.Infof("Set nwdafAnaUri: [%s]", c.nwdafAnaUri)
+}
+
+func (c *NefContext) CapifApiId(apiName string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.capifApiIds[apiName]
+}
+
+func (c *NefContext) CapifApiIds() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	apiIds := make(map[string]string, len(c.capifApiIds))
+	for apiName, apiId := range c.capifApiIds {
+		apiIds[apiName] = apiId
+	}
+	return apiIds
+}
+
+func (c *NefContext) SetCapifApiId(apiName, apiId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capifApiIds[apiName] = apiId
+	logger.CtxLog.Infof("Set CAPIF serviceApiId of %s: [%s]", apiName, apiId)
+}
+
+func (c *NefContext) DeleteCapifApiId(apiName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.capifApiIds, apiName)
+}
+
 func (c *NefContext) NewAf(afID string) *AfData {
 	af := &AfData{
-		AfID:     afID,
-		Subs:     make(map[string]*AfSubscription),
-		PfdTrans: make(map[string]*AfPfdTransaction),
-<<<<<<< Updated upstream
-=======
-		QosSubs:  make(map[string]*AfQosSubscription),
->>>>>>> Stashed changes
-		Log:      logger.CtxLog.WithField(logger.FieldAFID, fmt.Sprintf("AF:%s", afID)),
+		AfID:      afID,
+		Subs:      make(map[string]*AfSubscription),
+		PfdTrans:  make(map[string]*AfPfdTransaction),
+		QosSubs:   make(map[string]*AfQosSubscription),
+		MonSubs:   make(map[string]*AfMonSubscription),
+		AnaSubs:   make(map[string]*AfAnaSubscription),
+		DevTrigs:  make(map[string]*AfDevTrigTransaction),
+		NiddConfs: make(map[string]*AfNiddConfiguration),
+		Log:       logger.CtxLog.WithField(logger.FieldAFID, fmt.Sprintf("AF:%s", afID)),
+		nefCtx:    c,
 	}
 	return af
 }
@@ -95,6 +329,7 @@ func (c *NefContext) AddAf(af *AfData) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.afs[af.AfID] = af
+	af.persist()
 	af.Log.Infoln("AF is added")
 }
 
@@ -104,18 +339,120 @@ func (c *NefContext) GetAf(afID string) *AfData {
 	return c.afs[afID]
 }
 
+// Afs returns a snapshot of the AFs.
+func (c *NefContext) Afs() []*AfData {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	afs := make([]*AfData, 0, len(c.afs))
+	for _, af := range c.afs {
+		afs = append(afs, af)
+	}
+	return afs
+}
+
 func (c *NefContext) DeleteAf(afID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.afs, afID)
+	if err := c.store.Delete(bucketAfs, afID); err != nil {
+		logger.CtxLog.Errorf("Delete stored AF[%s] failed: %+v", afID, err)
+	}
 	logger.CtxLog.Infof("AF[%s] is deleted", afID)
 }
 
+// NewEeSub allocates a Nnef_EventExposure subscription with a globally unique
+// ID, AddEeSub makes it visible once the AFs are subscribed.
+func (c *NefContext) NewEeSub() *EeSubscription {
+	sub := &EeSubscription{
+		SubID:  uuid.New().String(),
+		AfSubs: make(map[string]string),
+		nefCtx: c,
+	}
+	sub.Log = logger.CtxLog.WithField(logger.FieldSubID, "EE:"+sub.SubID)
+	return sub
+}
+
+func (c *NefContext) AddEeSub(sub *EeSubscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eeSubs[sub.SubID] = sub
+	sub.persist()
+	sub.Log.Infoln("Event exposure subscription is added")
+}
+
+func (c *NefContext) GetEeSub(subID string) *EeSubscription {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.eeSubs[subID]
+}
+
+func (c *NefContext) DeleteEeSub(subID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.eeSubs, subID)
+	if err := c.store.Delete(bucketEeSubs, subID); err != nil {
+		logger.CtxLog.Errorf("Delete stored event exposure subscription[%s] failed: %+v", subID, err)
+	}
+	logger.CtxLog.Infof("Event exposure subscription[%s] is deleted", subID)
+}
+
+// NewSmContext allocates an SM context with a globally unique ID, AddSmContext
+// makes it visible.
+func (c *NefContext) NewSmContext() *SmContext {
+	smCtx := &SmContext{
+		SmContextID: uuid.New().String(),
+		nefCtx:      c,
+	}
+	smCtx.Log = logger.CtxLog.WithField(logger.FieldSubID, "SMCTX:"+smCtx.SmContextID)
+	return smCtx
+}
+
+func (c *NefContext) AddSmContext(smCtx *SmContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.smContexts[smCtx.SmContextID] = smCtx
+	smCtx.persist()
+	smCtx.Log.Infoln("SM context is added")
+}
+
+func (c *NefContext) GetSmContext(smContextID string) *SmContext {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.smContexts[smContextID]
+}
+
+// FindSmContext returns the SM context of the NIDD PDU session of the UE
+// towards the AF.
+func (c *NefContext) FindSmContext(afID, gpsi string) *SmContext {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, smCtx := range c.smContexts {
+		smCtx.Mu.RLock()
+		found := smCtx.AfID() == afID && smCtx.Gpsi() == gpsi
+		smCtx.Mu.RUnlock()
+		if found {
+			return smCtx
+		}
+	}
+	return nil
+}
+
+func (c *NefContext) DeleteSmContext(smContextID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.smContexts, smContextID)
+	if err := c.store.Delete(bucketSmCtxs, smContextID); err != nil {
+		logger.CtxLog.Errorf("Delete stored SM context[%s] failed: %+v", smContextID, err)
+	}
+	logger.CtxLog.Infof("SM context[%s] is deleted", smContextID)
+}
+
 func (c *NefContext) NewCorreID() uint64 {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.numCorreID++
+	c.persistCorreID()
 	return c.numCorreID
 }
 
@@ -123,6 +460,7 @@ func (c *NefContext) ResetCorreID() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.numCorreID = 0
+	c.persistCorreID()
 }
 
 func (c *NefContext) IsAppIDExisted(appID string) (string, string, bool) {
@@ -155,8 +493,54 @@ func (c *NefContext) FindAfSub(CorrID string) (*AfData, *AfSubscription) {
 	return nil, nil
 }
 
-<<<<<<< Updated upstream
-=======
+func (c *NefContext) FindAfMonSub(corrID string) (*AfData, *AfMonSubscription) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, af := range c.afs {
+		af.Mu.RLock()
+		for _, sub := range af.MonSubs {
+			if sub.NotifCorreID == corrID {
+				defer af.Mu.RUnlock()
+				return af, sub
+			}
+		}
+		af.Mu.RUnlock()
+	}
+	return nil, nil
+}
+
+func (c *NefContext) FindAfAnaSub(corrID string) (*AfData, *AfAnaSubscription) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, af := range c.afs {
+		af.Mu.RLock()
+		for _, sub := range af.AnaSubs {
+			if sub.NotifCorreID == corrID {
+				defer af.Mu.RUnlock()
+				return af, sub
+			}
+		}
+		af.Mu.RUnlock()
+	}
+	return nil, nil
+}
+
+func (c *NefContext) FindAfDevTrig(corrID string) (*AfData, *AfDevTrigTransaction) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, af := range c.afs {
+		af.Mu.RLock()
+		for _, devTr := range af.DevTrigs {
+			if devTr.NotifCorreID == corrID {
+				defer af.Mu.RUnlock()
+				return af, devTr
+			}
+		}
+		af.Mu.RUnlock()
+	}
+	return nil, nil
+}
+
 func (c *NefContext) FindAfQosSubscriptionByCorrID(corrID string) (*AfData, *AfQosSubscription) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -173,7 +557,6 @@ func (c *NefContext) FindAfQosSubscriptionByCorrID(corrID string) (*AfData, *AfQ
 	return nil, nil
 }
 
->>>>>>> Stashed changes
 func (c *NefContext) GetTokenCtx(serviceName models.ServiceName, targetNF models.NrfNfManagementNfType) (
 	context.Context, *models.ProblemDetails, error,
 ) {
@@ -0,0 +1,117 @@
+package context
+
+import (
+	"testing"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+)
+
+type nefTestApp struct {
+	cfg *factory.Config
+}
+
+func (a *nefTestApp) Config() *factory.Config {
+	return a.cfg
+}
+
+func TestLoadFromStore(t *testing.T) {
+	nef := &nefTestApp{
+		cfg: &factory.Config{
+			Configuration: &factory.Configuration{
+				Store: &factory.Store{
+					Backend: "file",
+					Path:    t.TempDir(),
+				},
+			},
+		},
+	}
+
+	nefCtx, err := NewContext(nef)
+	require.NoError(t, err)
+
+	af := nefCtx.NewAf("af1")
+	nefCtx.AddAf(af)
+
+	af.Mu.Lock()
+	sub := af.NewSub(nefCtx.NewCorreID(), &models.NefTrafficInfluSub{AfAppId: "app1"})
+	sub.AppSessID = "12345"
+	af.Subs[sub.SubID] = sub
+	pfdTr := af.NewPfdTrans()
+	pfdTr.AddExtAppID("app1")
+	af.PfdTrans[pfdTr.TransID] = pfdTr
+	anaSub := af.NewAnaSub(nefCtx.NewCorreID(), &nef_models.AnalyticsExposureSubsc{NotifId: "ana1"})
+	anaSub.NwdafSubID = "nwdaf1"
+	af.AnaSubs[anaSub.SubID] = anaSub
+	af.AddQosSubscription(&AfQosSubscription{
+		SubscriptionID: "qos1",
+		AppSessID:      "67890",
+		NotifCorrID:    "corr1",
+	})
+	af.Mu.Unlock()
+	eeSub := nefCtx.NewEeSub()
+	eeSub.EeSub = &models.NefEventExposureSubsc{NotifId: "notif1"}
+	eeSub.AfSubs["af1"] = "http://af1/naf-eventexposure/v1/subscriptions/1"
+	nefCtx.AddEeSub(eeSub)
+	nefCtx.CloseStore()
+
+	// A new context on the same store simulates the NEF restart
+	restoredCtx, err := NewContext(nef)
+	require.NoError(t, err)
+	require.NoError(t, restoredCtx.LoadFromStore())
+
+	restoredAf, restoredSub := restoredCtx.FindAfSub(sub.NotifCorreID)
+	require.NotNil(t, restoredSub)
+	require.Equal(t, "af1", restoredAf.AfID)
+	require.Equal(t, "12345", restoredSub.AppSessID)
+	require.Equal(t, "app1", restoredSub.TiSub.AfAppId)
+
+	afID, transID, ok := restoredCtx.IsAppIDExisted("app1")
+	require.True(t, ok)
+	require.Equal(t, "af1", afID)
+	require.Equal(t, pfdTr.TransID, transID)
+
+	_, qosSub := restoredCtx.FindAfQosSubscriptionByCorrID("corr1")
+	require.NotNil(t, qosSub)
+	require.Equal(t, "67890", qosSub.AppSessID)
+
+	_, restoredAnaSub := restoredCtx.FindAfAnaSub(anaSub.NotifCorreID)
+	require.NotNil(t, restoredAnaSub)
+	require.Equal(t, "nwdaf1", restoredAnaSub.NwdafSubID)
+	require.Equal(t, "ana1", restoredAnaSub.AnaSub.NotifId)
+
+	restoredEeSub := restoredCtx.GetEeSub(eeSub.SubID)
+	require.NotNil(t, restoredEeSub)
+	require.Equal(t, "notif1", restoredEeSub.EeSub.NotifId)
+	require.Equal(t, eeSub.AfSubs, restoredEeSub.AfSubs)
+
+	// Counters continue instead of restarting from 1
+	require.Equal(t, uint64(3), restoredCtx.NewCorreID())
+	restoredAf.Mu.Lock()
+	require.Equal(t, "3", restoredAf.NewSub(3, &models.NefTrafficInfluSub{}).SubID)
+	restoredAf.Mu.Unlock()
+
+	restoredCtx.DeleteAf("af1")
+	emptyCtx, err := NewContext(nef)
+	require.NoError(t, err)
+	require.NoError(t, emptyCtx.LoadFromStore())
+	require.Nil(t, emptyCtx.GetAf("af1"))
+}
+
+func TestBufferedDlTransfers(t *testing.T) {
+	conf := &AfNiddConfiguration{
+		DlTransfers: map[string]*AfNiddDlTransfer{
+			"10": {DlID: "10"},
+			"2":  {DlID: "2"},
+			"9":  {DlID: "9"},
+		},
+	}
+
+	var dlIDs []string
+	for _, dl := range conf.BufferedDlTransfers() {
+		dlIDs = append(dlIDs, dl.DlID)
+	}
+	require.Equal(t, []string{"2", "9", "10"}, dlIDs)
+}
@@ -0,0 +1,61 @@
+package context
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+	"github.com/sirupsen/logrus"
+)
+
+// SmContext is an Nnef_SMContext individual SM context created by an SMF for
+// the NIDD PDU session of a UE.
+type SmContext struct {
+	SmContextID string                                  `json:"smContextId"`
+	CreateData  *models.NefSmContextSmContextCreateData `json:"createData,omitempty"`
+	Mu          sync.RWMutex                            `json:"-"`
+	Log         *logrus.Entry                           `json:"-"`
+
+	nefCtx *NefContext
+}
+
+// AfID returns the AF the NIDD PDU session is established towards.
+func (s *SmContext) AfID() string {
+	if s.CreateData == nil || s.CreateData.NiddInfo == nil {
+		return ""
+	}
+	return s.CreateData.NiddInfo.AfId
+}
+
+// Gpsi returns the GPSI of the UE of the NIDD PDU session.
+func (s *SmContext) Gpsi() string {
+	if s.CreateData == nil || s.CreateData.NiddInfo == nil {
+		return ""
+	}
+	return s.CreateData.NiddInfo.Gpsi
+}
+
+// Persist writes the current state of the SM context through to the state
+// store. The caller must hold s.Mu, it is not acquired here.
+func (s *SmContext) Persist() {
+	if s.nefCtx == nil || s.nefCtx.GetSmContext(s.SmContextID) != s {
+		return
+	}
+	s.persist()
+}
+
+func (s *SmContext) persist() {
+	data, err := json.Marshal(s)
+	if err != nil {
+		s.Log.Errorf("Marshal SM context for store failed: %+v", err)
+		return
+	}
+	if err = s.nefCtx.store.Put(bucketSmCtxs, s.SmContextID, data); err != nil {
+		s.Log.Errorf("Persist SM context failed: %+v", err)
+	}
+}
+
+func (s *SmContext) restoreRuntimeState() {
+	s.Log = logger.CtxLog.WithField(logger.FieldSubID, "SMCTX:"+s.SmContextID)
+}
@@ -21,6 +21,12 @@ var (
 	PFDManageLog *logrus.Entry
 	PFDFLog      *logrus.Entry
 	OamLog       *logrus.Entry
+	MonEvtLog    *logrus.Entry
+	EvtExpoLog   *logrus.Entry
+	AnaExpoLog   *logrus.Entry
+	DevTrigLog   *logrus.Entry
+	NiddLog      *logrus.Entry
+	CapifLog     *logrus.Entry
 )
 
 const (
@@ -52,4 +58,10 @@ func init() {
 	PFDManageLog = NfLog.WithField(logger_util.FieldCategory, "PFDMng")
 	PFDFLog = NfLog.WithField(logger_util.FieldCategory, "PFDF")
 	OamLog = NfLog.WithField(logger_util.FieldCategory, "OAM")
+	MonEvtLog = NfLog.WithField(logger_util.FieldCategory, "MonEvt")
+	EvtExpoLog = NfLog.WithField(logger_util.FieldCategory, "EvtExpo")
+	AnaExpoLog = NfLog.WithField(logger_util.FieldCategory, "AnaExpo")
+	DevTrigLog = NfLog.WithField(logger_util.FieldCategory, "DevTrig")
+	NiddLog = NfLog.WithField(logger_util.FieldCategory, "NIDD")
+	CapifLog = NfLog.WithField(logger_util.FieldCategory, "CAPIF")
 }
@@ -0,0 +1,61 @@
+package business
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/free5gc/util/metrics/utils"
+)
+
+const (
+	SUBSYSTEM_NAME = "business"
+
+	NOTIFICATION_COUNTER_NAME = "notification_total"
+	NOTIFICATION_COUNTER_DESC = "Counter of notifications delivered by the NEF, by type and result"
+
+	NOTIFICATION_TYPE_LABEL   = "notification_type"
+	NOTIFICATION_RESULT_LABEL = "result"
+)
+
+// Notification types
+const (
+	NotifTypeUpPathChange = "up_path_change"
+	NotifTypeSmfAck       = "smf_ack"
+	NotifTypeMonEvt       = "monitoring_event"
+	NotifTypeAsSessionQos = "as_session_qos"
+	NotifTypePfdChange    = "pfd_change"
+	NotifTypePfdReport    = "pfd_report"
+	NotifTypeEvtExpo      = "event_exposure"
+	NotifTypeAnaExpo      = "analytics_exposure"
+	NotifTypeDevTrig      = "device_triggering"
+	NotifTypeNiddUplink   = "nidd_uplink_data"
+	NotifTypeNiddDlStatus = "nidd_downlink_status"
+)
+
+var NotificationCounter *prometheus.CounterVec
+
+func GetNotificationMetrics(namespace string) []prometheus.Collector {
+	var metrics []prometheus.Collector
+
+	NotificationCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      NOTIFICATION_COUNTER_NAME,
+			Help:      NOTIFICATION_COUNTER_DESC,
+		},
+		[]string{NOTIFICATION_TYPE_LABEL, NOTIFICATION_RESULT_LABEL},
+	)
+
+	metrics = append(metrics, NotificationCounter)
+
+	return metrics
+}
+
+func IncrNotificationCounter(notifType string, success bool) {
+	if utils.IsBusinessMetricsEnabled() && NotificationCounter != nil {
+		NotificationCounter.With(prometheus.Labels{
+			NOTIFICATION_TYPE_LABEL:   notifType,
+			NOTIFICATION_RESULT_LABEL: utils.GetStatus(&success),
+		}).Add(1)
+	}
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// AnalyticsEvent 3GPP TS 29.522 clause 5.6.2.3.3
+type AnalyticsEvent string
+
+const (
+	AnalyticsEvent_UE_MOBILITY         AnalyticsEvent = "UE_MOBILITY"
+	AnalyticsEvent_UE_COMM             AnalyticsEvent = "UE_COMM"
+	AnalyticsEvent_ABNORMAL_BEHAVIOR   AnalyticsEvent = "ABNORMAL_BEHAVIOR"
+	AnalyticsEvent_NETWORK_PERFORMANCE AnalyticsEvent = "NETWORK_PERFORMANCE"
+	AnalyticsEvent_QOS_SUSTAINABILITY  AnalyticsEvent = "QOS_SUSTAINABILITY"
+)
+
+// AnalyticsExposureSubsc 3GPP TS 29.522 clause 5.6.2.2.2
+type AnalyticsExposureSubsc struct {
+	AnalyEventsSubs []AnalyticsEventSubsc        `json:"analyEventsSubs"`
+	AnalyRepInfo    *models.ReportingInformation `json:"analyRepInfo,omitempty"`
+	NotifUri        string                       `json:"notifUri"`
+	NotifId         string                       `json:"notifId"`
+	EventNotifs     []AnalyticsEventNotif        `json:"eventNotifs,omitempty"`
+	SuppFeat        string                       `json:"suppFeat,omitempty"`
+}
+
+// AnalyticsEventNotification 3GPP TS 29.522 clause 5.6.2.2.3
+type AnalyticsEventNotification struct {
+	NotifId          string                `json:"notifId"`
+	AnalyEventNotifs []AnalyticsEventNotif `json:"analyEventNotifs"`
+}
+
+// AnalyticsEventNotif 3GPP TS 29.522 clause 5.6.2.2.4
+// The analytics information is carried in the Nnwdaf data types, the SUPIs
+// are removed before it is exposed.
+type AnalyticsEventNotif struct {
+	AnalyEvent      AnalyticsEvent                 `json:"analyEvent"`
+	Expiry          *time.Time                     `json:"expiry,omitempty"`
+	TimeStamp       *time.Time                     `json:"timeStamp"`
+	UeMobilityInfos []models.UeMobility            `json:"ueMobilityInfos,omitempty"`
+	UeCommInfos     []models.UeCommunication       `json:"ueCommInfos,omitempty"`
+	AbnormalInfos   []models.AbnormalBehaviour     `json:"abnormalInfos,omitempty"`
+	NwPerfInfos     []models.NetworkPerfInfo       `json:"nwPerfInfos,omitempty"`
+	QosSustainInfos []models.QosSustainabilityInfo `json:"qosSustainInfos,omitempty"`
+}
+
+// AnalyticsEventSubsc 3GPP TS 29.522 clause 5.6.2.2.5
+type AnalyticsEventSubsc struct {
+	AnalyEvent       AnalyticsEvent             `json:"analyEvent"`
+	AnalyEventFilter *AnalyticsEventFilterSubsc `json:"analyEventFilter,omitempty"`
+	TgtUe            *TargetUeId                `json:"tgtUe,omitempty"`
+}
+
+// AnalyticsEventFilterSubsc 3GPP TS 29.522 clause 5.6.2.2.6
+type AnalyticsEventFilterSubsc struct {
+	NwPerfReqs     []models.NetworkPerfRequirement `json:"nwPerfReqs,omitempty"`
+	LocArea        *models.LocationArea5G          `json:"locArea,omitempty"`
+	AppIds         []string                        `json:"appIds,omitempty"`
+	Dnn            string                          `json:"dnn,omitempty"`
+	Snssai         *models.Snssai                  `json:"snssai,omitempty"`
+	ExcepRequs     []models.Exception              `json:"excepRequs,omitempty"`
+	ExptAnaType    models.ExpectedAnalyticsType    `json:"exptAnaType,omitempty"`
+	ExptUeBehav    *models.ExpectedUeBehaviourData `json:"exptUeBehav,omitempty"`
+	QosReq         *models.QosRequirement          `json:"qosReq,omitempty"`
+	QosFlowRetThds []models.RetainabilityThreshold `json:"qosFlowRetThds,omitempty"`
+	RanUeThrouThds []string                        `json:"ranUeThrouThds,omitempty"`
+}
+
+// AnalyticsRequest 3GPP TS 29.522 clause 5.6.2.2.7
+type AnalyticsRequest struct {
+	AnalyEvent       AnalyticsEvent                    `json:"analyEvent"`
+	AnalyEventFilter *AnalyticsEventFilter             `json:"analyEventFilter,omitempty"`
+	AnalyRep         *models.EventReportingRequirement `json:"analyRep,omitempty"`
+	TgtUe            *TargetUeId                       `json:"tgtUe,omitempty"`
+	SuppFeat         string                            `json:"suppFeat"`
+}
+
+// AnalyticsData 3GPP TS 29.522 clause 5.6.2.2.8
+type AnalyticsData struct {
+	Start           *time.Time                     `json:"start,omitempty"`
+	Expiry          *time.Time                     `json:"expiry,omitempty"`
+	TimeStamp       *time.Time                     `json:"timeStamp,omitempty"`
+	UeMobilityInfos []models.UeMobility            `json:"ueMobilityInfos,omitempty"`
+	UeCommInfos     []models.UeCommunication       `json:"ueCommInfos,omitempty"`
+	AbnormalInfos   []models.AbnormalBehaviour     `json:"abnormalInfos,omitempty"`
+	NwPerfInfos     []models.NetworkPerfInfo       `json:"nwPerfInfos,omitempty"`
+	QosSustainInfos []models.QosSustainabilityInfo `json:"qosSustainInfos,omitempty"`
+	SuppFeat        string                         `json:"suppFeat,omitempty"`
+}
+
+// AnalyticsEventFilter 3GPP TS 29.522 clause 5.6.2.2.9
+type AnalyticsEventFilter struct {
+	LocArea     *models.LocationArea5G          `json:"locArea,omitempty"`
+	AppIds      []string                        `json:"appIds,omitempty"`
+	Dnn         string                          `json:"dnn,omitempty"`
+	Snssai      *models.Snssai                  `json:"snssai,omitempty"`
+	NwPerfTypes []models.NetworkPerfType        `json:"nwPerfTypes,omitempty"`
+	QosReq      *models.QosRequirement          `json:"qosReq,omitempty"`
+	ExcepIds    []models.ExceptionId            `json:"excepIds,omitempty"`
+	ExptAnaType models.ExpectedAnalyticsType    `json:"exptAnaType,omitempty"`
+	ExptUeBehav *models.ExpectedUeBehaviourData `json:"exptUeBehav,omitempty"`
+}
+
+// TargetUeId 3GPP TS 29.522 clause 5.6.2.2.10
+type TargetUeId struct {
+	AnyUeInd     bool   `json:"anyUeInd,omitempty"`
+	Gpsi         string `json:"gpsi,omitempty"`
+	ExterGroupId string `json:"exterGroupId,omitempty"`
+}
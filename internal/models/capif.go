@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+)
+
+// CAPIF protocols 3GPP TS 29.222 clause 8.2.4.3.4
+const (
+	CapifProtocol_HTTP_1_1 = "HTTP_1_1"
+	CapifProtocol_HTTP_2   = "HTTP_2"
+)
+
+// CAPIF data formats 3GPP TS 29.222 clause 8.2.4.3.5
+const CapifDataFormat_JSON = "JSON"
+
+// CAPIF security methods 3GPP TS 29.222 clause 8.2.4.3.6
+const (
+	CapifSecurityMethod_PSK   = "PSK"
+	CapifSecurityMethod_PKI   = "PKI"
+	CapifSecurityMethod_OAUTH = "OAUTH"
+)
+
+// ServiceApiDescription 3GPP TS 29.222 clause 8.2.4.2.2
+type ServiceApiDescription struct {
+	ApiName           string       `json:"apiName"`
+	ApiId             string       `json:"apiId,omitempty"`
+	AefProfiles       []AefProfile `json:"aefProfiles,omitempty"`
+	Description       string       `json:"description,omitempty"`
+	SupportedFeatures string       `json:"supportedFeatures,omitempty"`
+	ApiSuppFeats      string       `json:"apiSuppFeats,omitempty"`
+}
+
+// AefProfile 3GPP TS 29.222 clause 8.2.4.2.4
+type AefProfile struct {
+	AefId                 string                 `json:"aefId"`
+	Versions              []CapifVersion         `json:"versions"`
+	Protocol              string                 `json:"protocol,omitempty"`
+	DataFormat            string                 `json:"dataFormat,omitempty"`
+	SecurityMethods       []string               `json:"securityMethods,omitempty"`
+	DomainName            string                 `json:"domainName,omitempty"`
+	InterfaceDescriptions []InterfaceDescription `json:"interfaceDescriptions,omitempty"`
+}
+
+// CapifVersion is the Version data type of 3GPP TS 29.222 clause 8.2.4.2.5
+type CapifVersion struct {
+	ApiVersion string     `json:"apiVersion"`
+	Expiry     *time.Time `json:"expiry,omitempty"`
+}
+
+// InterfaceDescription 3GPP TS 29.222 clause 7.2.1.3.1 (CommonData)
+type InterfaceDescription struct {
+	Ipv4Addr        string   `json:"ipv4Addr,omitempty"`
+	Ipv6Addr        string   `json:"ipv6Addr,omitempty"`
+	Fqdn            string   `json:"fqdn,omitempty"`
+	Port            int      `json:"port,omitempty"`
+	ApiPrefix       string   `json:"apiPrefix,omitempty"`
+	SecurityMethods []string `json:"securityMethods,omitempty"`
+}
+
+// InvocationLog 3GPP TS 29.222 clause 8.7.4.2.2
+type InvocationLog struct {
+	AefId             string     `json:"aefId"`
+	ApiInvokerId      string     `json:"apiInvokerId"`
+	Logs              []CapifLog `json:"logs"`
+	SupportedFeatures string     `json:"supportedFeatures,omitempty"`
+}
+
+// CapifLog is the Log data type of 3GPP TS 29.222 clause 8.7.4.2.3
+type CapifLog struct {
+	ApiName        string                `json:"apiName"`
+	ApiId          string                `json:"apiId"`
+	ApiVersion     string                `json:"apiVersion,omitempty"`
+	ResultCode     string                `json:"result"`
+	InvocationTime *time.Time            `json:"invocationTime,omitempty"`
+	InvocationLat  int                   `json:"invocationLatency,omitempty"`
+	Protocol       string                `json:"protocol"`
+	Operation      string                `json:"operation,omitempty"`
+	ResourceName   string                `json:"resourceName"`
+	Uri            string                `json:"uri,omitempty"`
+	SrcInterface   *InterfaceDescription `json:"srcInterface,omitempty"`
+	DestInterface  *InterfaceDescription `json:"destInterface,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+// DeliveryResult 3GPP TS 29.122 clause 5.7.2.3.3
+type DeliveryResult string
+
+const (
+	DeliveryResult_SUCCESS     DeliveryResult = "SUCCESS"
+	DeliveryResult_UNKNOWN     DeliveryResult = "UNKNOWN"
+	DeliveryResult_FAILURE     DeliveryResult = "FAILURE"
+	DeliveryResult_TRIGGERED   DeliveryResult = "TRIGGERED"
+	DeliveryResult_EXPIRED     DeliveryResult = "EXPIRED"
+	DeliveryResult_UNCONFIRMED DeliveryResult = "UNCONFIRMED"
+	DeliveryResult_REPLACED    DeliveryResult = "REPLACED"
+	DeliveryResult_TERMINATE   DeliveryResult = "TERMINATE"
+)
+
+// Priority 3GPP TS 29.122 clause 5.7.2.3.4
+type Priority string
+
+const (
+	Priority_NO_PRIORITY Priority = "NO_PRIORITY"
+	Priority_PRIORITY    Priority = "PRIORITY"
+)
+
+// DeviceTriggering 3GPP TS 29.122 clause 5.7.2.1.2
+type DeviceTriggering struct {
+	Self                    string         `json:"self,omitempty"`
+	SupportedFeatures       string         `json:"supportedFeatures,omitempty"`
+	ExternalId              string         `json:"externalId,omitempty"`
+	Msisdn                  string         `json:"msisdn,omitempty"`
+	ApplicationPortId       int32          `json:"applicationPortId"`
+	AppSrcPortId            int32          `json:"appSrcPortId,omitempty"`
+	NotificationDestination string         `json:"notificationDestination"`
+	RequestTestNotification bool           `json:"requestTestNotification,omitempty"`
+	TriggerPayload          string         `json:"triggerPayload"` // base64 encoded
+	ValidityPeriod          int32          `json:"validityPeriod"` // seconds
+	Priority                Priority       `json:"priority"`
+	DeliveryResult          DeliveryResult `json:"deliveryResult,omitempty"`
+}
+
+// DeviceTriggeringDeliveryReportNotification 3GPP TS 29.122 clause 5.7.2.1.3
+type DeviceTriggeringDeliveryReportNotification struct {
+	Transaction string         `json:"transaction"`
+	Result      DeliveryResult `json:"result"`
+}
+
+// SmsSfDeviceTrigger is a device trigger submitted to the SMS-SF. The T4
+// interface of 3GPP TS 29.337 is Diameter based, the NEF uses this JSON
+// stand-in towards an SMS-SF gateway instead.
+type SmsSfDeviceTrigger struct {
+	ExternalId        string   `json:"externalId,omitempty"`
+	Msisdn            string   `json:"msisdn,omitempty"`
+	ApplicationPortId int32    `json:"applicationPortId"`
+	AppSrcPortId      int32    `json:"appSrcPortId,omitempty"`
+	TriggerPayload    string   `json:"triggerPayload"`
+	ValidityPeriod    int32    `json:"validityPeriod"`
+	Priority          Priority `json:"priority"`
+	// Where the SMS-SF posts the SmsSfDeliveryReport of the trigger
+	NotifUri string `json:"notifUri"`
+	// Result known when the trigger is submitted, TRIGGERED when pending
+	DeliveryResult DeliveryResult `json:"deliveryResult,omitempty"`
+}
+
+// SmsSfDeliveryReport is the delivery report of a device trigger sent by the
+// SMS-SF stand-in, see SmsSfDeviceTrigger.
+type SmsSfDeliveryReport struct {
+	Result DeliveryResult `json:"result"`
+}
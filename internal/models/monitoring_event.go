@@ -0,0 +1,98 @@
+// Package models holds the northbound T8 data types of 3GPP TS 29.122 which
+// are not part of the free5gc openapi models.
+package models
+
+import (
+	"time"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// MonitoringType 3GPP TS 29.122 clause 5.3.2.4.3
+type MonitoringType string
+
+const (
+	MonitoringType_LOSS_OF_CONNECTIVITY MonitoringType = "LOSS_OF_CONNECTIVITY"
+	MonitoringType_UE_REACHABILITY      MonitoringType = "UE_REACHABILITY"
+	MonitoringType_LOCATION_REPORTING   MonitoringType = "LOCATION_REPORTING"
+	MonitoringType_ROAMING_STATUS       MonitoringType = "ROAMING_STATUS"
+)
+
+// ReachabilityType 3GPP TS 29.122 clause 5.3.2.4.4
+type ReachabilityType string
+
+const (
+	ReachabilityType_SMS  ReachabilityType = "SMS"
+	ReachabilityType_DATA ReachabilityType = "DATA"
+)
+
+// LocationType 3GPP TS 29.122 clause 5.3.2.4.5
+type LocationType string
+
+const (
+	LocationType_CURRENT_LOCATION    LocationType = "CURRENT_LOCATION"
+	LocationType_LAST_KNOWN_LOCATION LocationType = "LAST_KNOWN_LOCATION"
+)
+
+// MonitoringEventSubscription 3GPP TS 29.122 clause 5.3.2.1.2
+type MonitoringEventSubscription struct {
+	Self                       string                 `json:"self,omitempty"`
+	SupportedFeatures          string                 `json:"supportedFeatures,omitempty"`
+	MtcProviderId              string                 `json:"mtcProviderId,omitempty"`
+	AfServiceId                string                 `json:"afServiceId,omitempty"`
+	ExternalId                 string                 `json:"externalId,omitempty"`
+	Msisdn                     string                 `json:"msisdn,omitempty"`
+	ExternalGroupId            string                 `json:"externalGroupId,omitempty"`
+	NotificationDestination    string                 `json:"notificationDestination"`
+	MonitoringType             MonitoringType         `json:"monitoringType"`
+	MaximumNumberOfReports     int32                  `json:"maximumNumberOfReports,omitempty"`
+	MonitorExpireTime          *time.Time             `json:"monitorExpireTime,omitempty"`
+	RepPeriod                  int32                  `json:"repPeriod,omitempty"`
+	GroupReportGuardTime       int32                  `json:"groupReportGuardTime,omitempty"`
+	MaximumDetectionTime       int32                  `json:"maximumDetectionTime,omitempty"`
+	ReachabilityType           ReachabilityType       `json:"reachabilityType,omitempty"`
+	MaximumLatency             int32                  `json:"maximumLatency,omitempty"`
+	MaximumResponseTime        int32                  `json:"maximumResponseTime,omitempty"`
+	SuggestedNumberOfDlPackets int32                  `json:"suggestedNumberOfDlPackets,omitempty"`
+	IdleStatusIndication       bool                   `json:"idleStatusIndication,omitempty"`
+	LocationType               LocationType           `json:"locationType,omitempty"`
+	MinimumReportInterval      int32                  `json:"minimumReportInterval,omitempty"`
+	MonitoringEventReport      *MonitoringEventReport `json:"monitoringEventReport,omitempty"`
+}
+
+// MonitoringNotification 3GPP TS 29.122 clause 5.3.2.1.3
+type MonitoringNotification struct {
+	Subscription           string                  `json:"subscription"`
+	MonitoringEventReports []MonitoringEventReport `json:"monitoringEventReports,omitempty"`
+	CancelInd              bool                    `json:"cancelInd,omitempty"`
+}
+
+// MonitoringEventReport 3GPP TS 29.122 clause 5.3.2.1.4
+type MonitoringEventReport struct {
+	ExternalId            string           `json:"externalId,omitempty"`
+	Msisdn                string           `json:"msisdn,omitempty"`
+	LocationInfo          *LocationInfo    `json:"locationInfo,omitempty"`
+	LossOfConnectReason   *int32           `json:"lossOfConnectReason,omitempty"`
+	MonitoringType        MonitoringType   `json:"monitoringType"`
+	ReachabilityType      ReachabilityType `json:"reachabilityType,omitempty"`
+	RoamingStatus         *bool            `json:"roamingStatus,omitempty"`
+	PlmnId                *models.PlmnId   `json:"plmnId,omitempty"`
+	EventTime             *time.Time       `json:"eventTime,omitempty"`
+	MaxUEAvailabilityTime *time.Time       `json:"maxUEAvailabilityTime,omitempty"`
+}
+
+// LocationInfo 3GPP TS 29.122 clause 5.3.2.2.2
+type LocationInfo struct {
+	AgeOfLocationInfo int32  `json:"ageOfLocationInfo,omitempty"`
+	CellId            string `json:"cellId,omitempty"`
+	EnodeBId          string `json:"enodeBId,omitempty"`
+	TrackingAreaId    string `json:"trackingAreaId,omitempty"`
+}
+
+// Loss of connectivity reasons of 3GPP TS 29.336 clause 8.4.58, carried in
+// MonitoringEventReport.LossOfConnectReason.
+const (
+	LossOfConnectReason_UE_DETACHED                int32 = 0
+	LossOfConnectReason_MAX_DETECTION_TIME_EXPIRED int32 = 2
+	LossOfConnectReason_UE_PURGED                  int32 = 4
+)
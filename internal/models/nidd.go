@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// PdnEstablishmentOptions 3GPP TS 29.122 clause 5.6.2.3.2
+type PdnEstablishmentOptions string
+
+const (
+	PdnEstablishmentOptions_WAIT_FOR_UE    PdnEstablishmentOptions = "WAIT_FOR_UE"
+	PdnEstablishmentOptions_INDICATE_ERROR PdnEstablishmentOptions = "INDICATE_ERROR"
+	PdnEstablishmentOptions_SEND_TRIGGER   PdnEstablishmentOptions = "SEND_TRIGGER"
+)
+
+// DeliveryStatus 3GPP TS 29.122 clause 5.6.2.3.3
+type DeliveryStatus string
+
+const (
+	DeliveryStatus_SUCCESS                             DeliveryStatus = "SUCCESS"
+	DeliveryStatus_SUCCESS_NEXT_HOP_ACKNOWLEDGED       DeliveryStatus = "SUCCESS_NEXT_HOP_ACKNOWLEDGED"
+	DeliveryStatus_SUCCESS_NEXT_HOP_UNACKNOWLEDGED     DeliveryStatus = "SUCCESS_NEXT_HOP_UNACKNOWLEDGED"
+	DeliveryStatus_SUCCESS_ACKNOWLEDGED                DeliveryStatus = "SUCCESS_ACKNOWLEDGED"
+	DeliveryStatus_SUCCESS_UNACKNOWLEDGED              DeliveryStatus = "SUCCESS_UNACKNOWLEDGED"
+	DeliveryStatus_TRIGGERED                           DeliveryStatus = "TRIGGERED"
+	DeliveryStatus_BUFFERING                           DeliveryStatus = "BUFFERING"
+	DeliveryStatus_BUFFERING_TEMPORARILY_NOT_REACHABLE DeliveryStatus = "BUFFERING_TEMPORARILY_NOT_REACHABLE"
+	DeliveryStatus_SENDING                             DeliveryStatus = "SENDING"
+	DeliveryStatus_FAILURE                             DeliveryStatus = "FAILURE"
+	DeliveryStatus_FAILURE_RDS_DISABLED                DeliveryStatus = "FAILURE_RDS_DISABLED"
+	DeliveryStatus_FAILURE_NEXT_HOP                    DeliveryStatus = "FAILURE_NEXT_HOP"
+	DeliveryStatus_FAILURE_TIMEOUT                     DeliveryStatus = "FAILURE_TIMEOUT"
+	DeliveryStatus_FAILURE_TEMPORARILY_NOT_REACHABLE   DeliveryStatus = "FAILURE_TEMPORARILY_NOT_REACHABLE"
+)
+
+// NiddStatus 3GPP TS 29.122 clause 5.6.2.3.4
+type NiddStatus string
+
+const (
+	NiddStatus_ACTIVE                       NiddStatus = "ACTIVE"
+	NiddStatus_TERMINATED_UE_NOT_AUTHORIZED NiddStatus = "TERMINATED_UE_NOT_AUTHORIZED"
+	NiddStatus_TERMINATED                   NiddStatus = "TERMINATED"
+	NiddStatus_RDS_PORT_UNKNOWN             NiddStatus = "RDS_PORT_UNKNOWN"
+)
+
+// NiddConfiguration 3GPP TS 29.122 clause 5.6.2.1.2
+type NiddConfiguration struct {
+	Self                      string                     `json:"self,omitempty"`
+	SupportedFeatures         string                     `json:"supportedFeatures,omitempty"`
+	ExternalId                string                     `json:"externalId,omitempty"`
+	Msisdn                    string                     `json:"msisdn,omitempty"`
+	ExternalGroupId           string                     `json:"externalGroupId,omitempty"`
+	Duration                  *time.Time                 `json:"duration,omitempty"`
+	ReliableDataService       bool                       `json:"reliableDataService,omitempty"`
+	PdnEstablishmentOption    PdnEstablishmentOptions    `json:"pdnEstablishmentOption,omitempty"`
+	NotificationDestination   string                     `json:"notificationDestination"`
+	RequestTestNotification   bool                       `json:"requestTestNotification,omitempty"`
+	MaximumPacketSize         int32                      `json:"maximumPacketSize,omitempty"`
+	NiddDownlinkDataTransfers []NiddDownlinkDataTransfer `json:"niddDownlinkDataTransfers,omitempty"`
+	Status                    NiddStatus                 `json:"status,omitempty"`
+}
+
+// NiddConfigurationPatch 3GPP TS 29.122 clause 5.6.2.1.4, an absent attribute
+// is left unchanged
+type NiddConfigurationPatch struct {
+	Duration                *time.Time              `json:"duration,omitempty"`
+	PdnEstablishmentOption  PdnEstablishmentOptions `json:"pdnEstablishmentOption,omitempty"`
+	NotificationDestination string                  `json:"notificationDestination,omitempty"`
+	MaximumPacketSize       int32                   `json:"maximumPacketSize,omitempty"`
+}
+
+// NiddDownlinkDataTransfer 3GPP TS 29.122 clause 5.6.2.1.3
+type NiddDownlinkDataTransfer struct {
+	ExternalId                  string                  `json:"externalId,omitempty"`
+	Msisdn                      string                  `json:"msisdn,omitempty"`
+	Self                        string                  `json:"self,omitempty"`
+	Data                        string                  `json:"data"` // base64 encoded
+	ReliableDataService         bool                    `json:"reliableDataService,omitempty"`
+	MaximumLatency              int32                   `json:"maximumLatency,omitempty"` // seconds
+	Priority                    int32                   `json:"priority,omitempty"`
+	PdnEstablishmentOption      PdnEstablishmentOptions `json:"pdnEstablishmentOption,omitempty"`
+	DeliveryStatus              DeliveryStatus          `json:"deliveryStatus,omitempty"`
+	RequestedRetransmissionTime *time.Time              `json:"requestedRetransmissionTime,omitempty"`
+}
+
+// NiddUplinkDataNotification 3GPP TS 29.122 clause 5.6.2.1.4
+type NiddUplinkDataNotification struct {
+	NiddConfiguration string `json:"niddConfiguration"`
+	ExternalId        string `json:"externalId,omitempty"`
+	Msisdn            string `json:"msisdn,omitempty"`
+	Data              string `json:"data"` // base64 encoded
+}
+
+// NiddDownlinkDataDeliveryStatusNotification 3GPP TS 29.122 clause 5.6.2.1.5
+type NiddDownlinkDataDeliveryStatusNotification struct {
+	NiddDownlinkDataTransfer    string         `json:"niddDownlinkDataTransfer"`
+	DeliveryStatus              DeliveryStatus `json:"deliveryStatus"`
+	RequestedRetransmissionTime *time.Time     `json:"requestedRetransmissionTime,omitempty"`
+}
@@ -0,0 +1,130 @@
+package sbi
+
+import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getAnalyticsExposureRoutes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:afID/subscriptions",
+			APIFunc: s.apiGetAnalyticsExposureSubscriptions,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/:afID/subscriptions",
+			APIFunc: s.apiPostAnalyticsExposureSubscription,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:afID/subscriptions/:subID",
+			APIFunc: s.apiGetIndividualAnalyticsExposureSubscription,
+		},
+		{
+			Method:  http.MethodPut,
+			Pattern: "/:afID/subscriptions/:subID",
+			APIFunc: s.apiPutIndividualAnalyticsExposureSubscription,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/:afID/subscriptions/:subID",
+			APIFunc: s.apiDeleteIndividualAnalyticsExposureSubscription,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/:afID/fetch",
+			APIFunc: s.apiPostFetchAnalytics,
+		},
+	}
+}
+
+func (s *Server) apiGetAnalyticsExposureSubscriptions(gc *gin.Context) {
+	s.Processor().GetAnalyticsExposureSubscriptions(gc, gc.Param("afID"))
+}
+
+func (s *Server) apiPostAnalyticsExposureSubscription(gc *gin.Context) {
+	var anaSub nef_models.AnalyticsExposureSubsc
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&anaSub, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PostAnalyticsExposureSubscription(gc, gc.Param("afID"), &anaSub)
+}
+
+func (s *Server) apiGetIndividualAnalyticsExposureSubscription(gc *gin.Context) {
+	s.Processor().GetIndividualAnalyticsExposureSubscription(
+		gc, gc.Param("afID"), gc.Param("subID"))
+}
+
+func (s *Server) apiPutIndividualAnalyticsExposureSubscription(gc *gin.Context) {
+	var anaSub nef_models.AnalyticsExposureSubsc
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&anaSub, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PutIndividualAnalyticsExposureSubscription(
+		gc, gc.Param("afID"), gc.Param("subID"), &anaSub)
+}
+
+func (s *Server) apiDeleteIndividualAnalyticsExposureSubscription(gc *gin.Context) {
+	s.Processor().DeleteIndividualAnalyticsExposureSubscription(
+		gc, gc.Param("afID"), gc.Param("subID"))
+}
+
+func (s *Server) apiPostFetchAnalytics(gc *gin.Context) {
+	var anaReq nef_models.AnalyticsRequest
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&anaReq, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().FetchAnalytics(gc, gc.Param("afID"), &anaReq)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/metrics/sbi"
@@ -17,6 +18,51 @@ func (s *Server) getCallbackRoutes() []Route {
 			Pattern: "/notification/smf",
 			APIFunc: s.apiPostSmfNotification,
 		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/smf/:notifID/ack",
+			APIFunc: s.apiPostAfAckNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/udm-ee/:corrID",
+			APIFunc: s.apiPostUdmEeNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/amf-ee/:corrID",
+			APIFunc: s.apiPostAmfEventNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/af-ee/:corrID",
+			APIFunc: s.apiPostAfEventNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/nwdaf/:corrID",
+			APIFunc: s.apiPostNwdafEventNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/smssf/:corrID",
+			APIFunc: s.apiPostSmsSfDeliveryReport,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/qos/:corrId/notify",
+			APIFunc: s.apiPostQosNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/qos/:corrId/terminate",
+			APIFunc: s.apiPostQosTermination,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/pfd-reports",
+			APIFunc: s.apiPostPfdChangeReports,
+		},
 	}
 }
 
@@ -42,11 +88,191 @@ func (s *Server) apiPostSmfNotification(gc *gin.Context) {
 
 	s.Processor().SmfNotification(gc, &eeNotif)
 }
-<<<<<<< Updated upstream
-=======
+
+func (s *Server) apiPostAfAckNotification(gc *gin.Context) {
+	var ackInfo models.AfAckInfo
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&ackInfo, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().AfAckNotification(gc, gc.Param("notifID"), &ackInfo)
+}
+
+func (s *Server) apiPostUdmEeNotification(gc *gin.Context) {
+	var udmReports []models.UdmEeMonitoringReport
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&udmReports, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().UdmEeNotification(gc, gc.Param("corrID"), udmReports)
+}
+
+func (s *Server) apiPostAfEventNotification(gc *gin.Context) {
+	var afNotif models.AfEventExposureNotif
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&afNotif, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().AfEventNotification(gc, gc.Param("corrID"), &afNotif)
+}
+
+func (s *Server) apiPostAmfEventNotification(gc *gin.Context) {
+	var amfNotif models.AmfEventNotification
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&amfNotif, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().AmfEventNotification(gc, gc.Param("corrID"), &amfNotif)
+}
+
+func (s *Server) apiPostNwdafEventNotification(gc *gin.Context) {
+	var nwdafNotifs []models.NnwdafEventsSubscriptionNotification
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&nwdafNotifs, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().NwdafEventNotification(gc, gc.Param("corrID"), nwdafNotifs)
+}
+
+func (s *Server) apiPostSmsSfDeliveryReport(gc *gin.Context) {
+	var report nef_models.SmsSfDeliveryReport
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&report, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().SmsSfDeliveryReport(gc, gc.Param("corrID"), &report)
+}
 
 func (s *Server) apiPostQosNotification(gc *gin.Context) {
-	var ascUpdate models.AppSessionContextUpdateData
+	var evsNotif models.PcfPolicyAuthorizationEventsNotification
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	if err := openapi.Deserialize(&evsNotif, reqBody, "application/json"); err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().AsSessionQosEventNotification(gc, gc.Param("corrId"), &evsNotif)
+}
+
+func (s *Server) apiPostQosTermination(gc *gin.Context) {
+	var termInfo models.TerminationInfo
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	if err := openapi.Deserialize(&termInfo, reqBody, "application/json"); err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().AsSessionQosTermination(gc, gc.Param("corrId"), &termInfo)
+}
+
+func (s *Server) apiPostPfdChangeReports(gc *gin.Context) {
+	var reports []models.PfdChangeReport
 	reqBody, err := gc.GetRawData()
 	if err != nil {
 		logger.SBILog.Errorf("Get Request Body error: %+v", err)
@@ -56,7 +282,8 @@ func (s *Server) apiPostQosNotification(gc *gin.Context) {
 		return
 	}
 
-	if err := openapi.Deserialize(&ascUpdate, reqBody, "application/json"); err != nil {
+	err = openapi.Deserialize(&reports, reqBody, "application/json")
+	if err != nil {
 		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
 		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
 		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -64,6 +291,5 @@ func (s *Server) apiPostQosNotification(gc *gin.Context) {
 		return
 	}
 
-	s.Processor().AsSessionQosNotification(gc, gc.Param("corrId"), &ascUpdate)
+	s.Processor().PfdChangeReports(gc, reports)
 }
->>>>>>> Stashed changes
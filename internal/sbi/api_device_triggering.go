@@ -0,0 +1,102 @@
+package sbi
+
+import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getDeviceTriggeringRoutes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/transactions",
+			APIFunc: s.apiGetDeviceTriggeringTransactions,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/:scsAsID/transactions",
+			APIFunc: s.apiPostDeviceTriggeringTransaction,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/transactions/:transID",
+			APIFunc: s.apiGetIndividualDeviceTriggeringTransaction,
+		},
+		{
+			Method:  http.MethodPut,
+			Pattern: "/:scsAsID/transactions/:transID",
+			APIFunc: s.apiPutIndividualDeviceTriggeringTransaction,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/:scsAsID/transactions/:transID",
+			APIFunc: s.apiDeleteIndividualDeviceTriggeringTransaction,
+		},
+	}
+}
+
+func (s *Server) apiGetDeviceTriggeringTransactions(gc *gin.Context) {
+	s.Processor().GetDeviceTriggeringTransactions(gc, gc.Param("scsAsID"))
+}
+
+func (s *Server) apiPostDeviceTriggeringTransaction(gc *gin.Context) {
+	var devTrig nef_models.DeviceTriggering
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&devTrig, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PostDeviceTriggeringTransaction(gc, gc.Param("scsAsID"), &devTrig)
+}
+
+func (s *Server) apiGetIndividualDeviceTriggeringTransaction(gc *gin.Context) {
+	s.Processor().GetIndividualDeviceTriggeringTransaction(
+		gc, gc.Param("scsAsID"), gc.Param("transID"))
+}
+
+func (s *Server) apiPutIndividualDeviceTriggeringTransaction(gc *gin.Context) {
+	var devTrig nef_models.DeviceTriggering
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&devTrig, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PutIndividualDeviceTriggeringTransaction(
+		gc, gc.Param("scsAsID"), gc.Param("transID"), &devTrig)
+}
+
+func (s *Server) apiDeleteIndividualDeviceTriggeringTransaction(gc *gin.Context) {
+	s.Processor().DeleteIndividualDeviceTriggeringTransaction(
+		gc, gc.Param("scsAsID"), gc.Param("transID"))
+}
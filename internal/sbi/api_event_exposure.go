@@ -0,0 +1,90 @@
+package sbi
+
+import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getEventExposureRoutes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodPost,
+			Pattern: "/subscriptions",
+			APIFunc: s.apiPostNefEventExposureSubscription,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/subscriptions/:subscriptionId",
+			APIFunc: s.apiGetNefEventExposureSubscription,
+		},
+		{
+			Method:  http.MethodPut,
+			Pattern: "/subscriptions/:subscriptionId",
+			APIFunc: s.apiPutNefEventExposureSubscription,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/subscriptions/:subscriptionId",
+			APIFunc: s.apiDeleteNefEventExposureSubscription,
+		},
+	}
+}
+
+func (s *Server) apiPostNefEventExposureSubscription(gc *gin.Context) {
+	var eeSubsc models.NefEventExposureSubsc
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&eeSubsc, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PostNefEventExposureSubscription(gc, &eeSubsc)
+}
+
+func (s *Server) apiGetNefEventExposureSubscription(gc *gin.Context) {
+	s.Processor().GetNefEventExposureSubscription(gc, gc.Param("subscriptionId"))
+}
+
+func (s *Server) apiPutNefEventExposureSubscription(gc *gin.Context) {
+	var eeSubsc models.NefEventExposureSubsc
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&eeSubsc, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PutNefEventExposureSubscription(gc, gc.Param("subscriptionId"), &eeSubsc)
+}
+
+func (s *Server) apiDeleteNefEventExposureSubscription(gc *gin.Context) {
+	s.Processor().DeleteNefEventExposureSubscription(gc, gc.Param("subscriptionId"))
+}
@@ -27,6 +27,16 @@ func (s *Server) getPFDFRoutes() []Route {
 			Pattern: "/subscriptions",
 			APIFunc: s.apiPostPFDSubscriptions,
 		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/subscriptions/:subID",
+			APIFunc: s.apiGetIndividualPFDSubscription,
+		},
+		{
+			Method:  http.MethodPatch,
+			Pattern: "/subscriptions/:subID",
+			APIFunc: s.apiPatchIndividualPFDSubscription,
+		},
 		{
 			Method:  http.MethodDelete,
 			Pattern: "/subscriptions/:subID",
@@ -36,13 +46,11 @@ func (s *Server) getPFDFRoutes() []Route {
 }
 
 func (s *Server) apiGetApplicationsPFD(gc *gin.Context) {
-	// TODO: support URI query parameters: supported-features
-	s.Processor().GetApplicationsPFD(gc, gc.QueryArray("application-ids"))
+	s.Processor().GetApplicationsPFD(gc, gc.QueryArray("application-ids"), gc.Query("supported-features"))
 }
 
 func (s *Server) apiGetIndividualApplicationPFD(gc *gin.Context) {
-	// TODO: support URI query parameters: supported-features
-	s.Processor().GetIndividualApplicationPFD(gc, gc.Param("appID"))
+	s.Processor().GetIndividualApplicationPFD(gc, gc.Param("appID"), gc.Query("supported-features"))
 }
 
 func (s *Server) apiPostPFDSubscriptions(gc *gin.Context) {
@@ -68,6 +76,33 @@ func (s *Server) apiPostPFDSubscriptions(gc *gin.Context) {
 	s.Processor().PostPFDSubscriptions(gc, &pfdSubsc)
 }
 
+func (s *Server) apiGetIndividualPFDSubscription(gc *gin.Context) {
+	s.Processor().GetIndividualPFDSubscription(gc, gc.Param("subID"))
+}
+
+func (s *Server) apiPatchIndividualPFDSubscription(gc *gin.Context) {
+	var pfdSubsc models.PfdSubscription
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&pfdSubsc, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PatchIndividualPFDSubscription(gc, gc.Param("subID"), &pfdSubsc)
+}
+
 func (s *Server) apiDeleteIndividualPFDSubscription(gc *gin.Context) {
 	s.Processor().DeleteIndividualPFDSubscription(gc, gc.Param("subID"))
 }
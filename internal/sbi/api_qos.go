@@ -50,7 +50,7 @@ func (s *Server) apiListAsSessionQosSubs(gc *gin.Context) {
 }
 
 func (s *Server) apiPostAsSessionQosSub(gc *gin.Context) {
-	var asc models.AppSessionContext
+	var qosSub models.AsSessionWithQoSSubscription
 	reqBody, err := gc.GetRawData()
 	if err != nil {
 		logger.SBILog.Errorf("Get Request Body error: %+v", err)
@@ -60,7 +60,7 @@ func (s *Server) apiPostAsSessionQosSub(gc *gin.Context) {
 		return
 	}
 
-	if err := openapi.Deserialize(&asc, reqBody, "application/json"); err != nil {
+	if err := openapi.Deserialize(&qosSub, reqBody, "application/json"); err != nil {
 		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
 		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
 		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -68,7 +68,7 @@ func (s *Server) apiPostAsSessionQosSub(gc *gin.Context) {
 		return
 	}
 
-	s.Processor().PostAsSessionQosSub(gc, gc.Param("scsAsId"), &asc)
+	s.Processor().PostAsSessionQosSub(gc, gc.Param("scsAsId"), &qosSub)
 }
 
 func (s *Server) apiGetAsSessionQosSub(gc *gin.Context) {
@@ -76,7 +76,7 @@ func (s *Server) apiGetAsSessionQosSub(gc *gin.Context) {
 }
 
 func (s *Server) apiPutAsSessionQosSub(gc *gin.Context) {
-	var ascUpdate models.AppSessionContextUpdateData
+	var qosSub models.AsSessionWithQoSSubscription
 	reqBody, err := gc.GetRawData()
 	if err != nil {
 		logger.SBILog.Errorf("Get Request Body error: %+v", err)
@@ -86,7 +86,7 @@ func (s *Server) apiPutAsSessionQosSub(gc *gin.Context) {
 		return
 	}
 
-	if err := openapi.Deserialize(&ascUpdate, reqBody, "application/json"); err != nil {
+	if err := openapi.Deserialize(&qosSub, reqBody, "application/json"); err != nil {
 		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
 		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
 		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -94,11 +94,11 @@ func (s *Server) apiPutAsSessionQosSub(gc *gin.Context) {
 		return
 	}
 
-	s.Processor().PutAsSessionQosSub(gc, gc.Param("scsAsId"), gc.Param("subscriptionId"), &ascUpdate)
+	s.Processor().PutAsSessionQosSub(gc, gc.Param("scsAsId"), gc.Param("subscriptionId"), &qosSub)
 }
 
 func (s *Server) apiPatchAsSessionQosSub(gc *gin.Context) {
-	var ascUpdate models.AppSessionContextUpdateData
+	var qosSubPatch models.AsSessionWithQoSSubscriptionPatch
 	reqBody, err := gc.GetRawData()
 	if err != nil {
 		logger.SBILog.Errorf("Get Request Body error: %+v", err)
@@ -108,7 +108,7 @@ func (s *Server) apiPatchAsSessionQosSub(gc *gin.Context) {
 		return
 	}
 
-	if err := openapi.Deserialize(&ascUpdate, reqBody, "application/json"); err != nil {
+	if err := openapi.Deserialize(&qosSubPatch, reqBody, "application/json"); err != nil {
 		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
 		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
 		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -116,7 +116,8 @@ func (s *Server) apiPatchAsSessionQosSub(gc *gin.Context) {
 		return
 	}
 
-	s.Processor().PatchAsSessionQosSub(gc, gc.Param("scsAsId"), gc.Param("subscriptionId"), &ascUpdate)
+	// The patch schema is checked above, the raw body keeps the nulls of the merge patch
+	s.Processor().PatchAsSessionQosSub(gc, gc.Param("scsAsId"), gc.Param("subscriptionId"), reqBody)
 }
 
 func (s *Server) apiDeleteAsSessionQosSub(gc *gin.Context) {
@@ -0,0 +1,129 @@
+package sbi
+
+import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getSmContextRoutes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodPost,
+			Pattern: "/sm-contexts",
+			APIFunc: s.apiPostSmContext,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/sm-contexts/:smContextID/release",
+			APIFunc: s.apiReleaseSmContext,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/sm-contexts/:smContextID/update",
+			APIFunc: s.apiUpdateSmContext,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/sm-contexts/:smContextID/deliver",
+			APIFunc: s.apiDeliverSmContext,
+		},
+	}
+}
+
+func (s *Server) apiPostSmContext(gc *gin.Context) {
+	var createData models.NefSmContextSmContextCreateData
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&createData, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().PostSmContext(gc, &createData)
+}
+
+func (s *Server) apiReleaseSmContext(gc *gin.Context) {
+	var releaseData models.NefSmContextSmContextReleaseData
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&releaseData, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().ReleaseSmContext(gc, gc.Param("smContextID"), &releaseData)
+}
+
+func (s *Server) apiUpdateSmContext(gc *gin.Context) {
+	var updateData models.NefSmContextSmContextUpdateData
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&updateData, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().UpdateSmContext(gc, gc.Param("smContextID"), &updateData)
+}
+
+// apiDeliverSmContext takes the MO data as a multipart/related body
+func (s *Server) apiDeliverSmContext(gc *gin.Context) {
+	var deliverReq models.DeliverRequest
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusInternalServerError, pd)
+		return
+	}
+
+	err = openapi.Deserialize(&deliverReq, reqBody, gc.GetHeader("Content-Type"))
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		gc.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	s.Processor().DeliverSmContext(gc, gc.Param("smContextID"), &deliverReq)
+}
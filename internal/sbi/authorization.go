@@ -0,0 +1,198 @@
+package sbi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	causeRequestNotAuthorized = "REQUEST_NOT_AUTHORIZED"
+	causeServiceNotAuthorized = "REQUESTED_SERVICE_NOT_AUTHORIZED"
+)
+
+// afRequestScope collects the request attributes restricted by the AF policy
+// over the bodies of the northbound APIs
+type afRequestScope struct {
+	AfAppId       string                    `json:"afAppId,omitempty"`
+	ExterAppId    string                    `json:"exterAppId,omitempty"`
+	ExternalAppId string                    `json:"externalAppId,omitempty"`
+	PfdDatas      map[string]models.PfdData `json:"pfdDatas,omitempty"`
+	Dnn           string                    `json:"dnn,omitempty"`
+	Snssai        *models.Snssai            `json:"snssai,omitempty"`
+	Ipv4Addr      string                    `json:"ipv4Addr,omitempty"`
+	Ipv6Addr      string                    `json:"ipv6Addr,omitempty"`
+	UeIpv4Addr    string                    `json:"ueIpv4Addr,omitempty"`
+	UeIpv6Addr    string                    `json:"ueIpv6Addr,omitempty"`
+	// UE identifiers
+	Gpsi            string                 `json:"gpsi,omitempty"`
+	ExternalId      string                 `json:"externalId,omitempty"`
+	Msisdn          string                 `json:"msisdn,omitempty"`
+	ExternalGroupId string                 `json:"externalGroupId,omitempty"`
+	AnyUeInd        bool                   `json:"anyUeInd,omitempty"`
+	TgtUe           *nef_models.TargetUeId `json:"tgtUe,omitempty"`
+	AnalyEventsSubs []struct {
+		TgtUe *nef_models.TargetUeId `json:"tgtUe,omitempty"`
+	} `json:"analyEventsSubs,omitempty"`
+}
+
+func (r *afRequestScope) appIds(pathAppID string) []string {
+	var appIDs []string
+	for _, appID := range []string{pathAppID, r.AfAppId, r.ExterAppId, r.ExternalAppId} {
+		if appID != "" {
+			appIDs = append(appIDs, appID)
+		}
+	}
+	for key, pfdData := range r.PfdDatas {
+		appIDs = append(appIDs, key)
+		if pfdData.ExternalAppId != "" {
+			appIDs = append(appIDs, pfdData.ExternalAppId)
+		}
+	}
+	return appIDs
+}
+
+func (r *afRequestScope) ueAddrs() []string {
+	var addrs []string
+	for _, addr := range []string{r.Ipv4Addr, r.Ipv6Addr, r.UeIpv4Addr, r.UeIpv6Addr} {
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// ueIds returns the GPSIs and the external group IDs of the request, and
+// whether it targets any UE
+func (r *afRequestScope) ueIds() (gpsis, groupIds []string, anyUe bool) {
+	tgtUes := []*nef_models.TargetUeId{r.TgtUe}
+	for _, evtSub := range r.AnalyEventsSubs {
+		tgtUes = append(tgtUes, evtSub.TgtUe)
+	}
+
+	gpsis = append(gpsis, r.Gpsi)
+	if r.ExternalId != "" {
+		gpsis = append(gpsis, "extid-"+r.ExternalId)
+	}
+	if r.Msisdn != "" {
+		gpsis = append(gpsis, "msisdn-"+r.Msisdn)
+	}
+	groupIds = append(groupIds, r.ExternalGroupId)
+	anyUe = r.AnyUeInd
+	for _, tgtUe := range tgtUes {
+		if tgtUe != nil {
+			gpsis = append(gpsis, tgtUe.Gpsi)
+			groupIds = append(groupIds, tgtUe.ExterGroupId)
+			anyUe = anyUe || tgtUe.AnyUeInd
+		}
+	}
+	return slices.DeleteFunc(gpsis, isEmpty), slices.DeleteFunc(groupIds, isEmpty), anyUe
+}
+
+func isEmpty(s string) bool {
+	return s == ""
+}
+
+// authorizeAf rejects the requests of the AF identified by the path parameter
+// when they are not allowed by its configured policy
+func (s *Server) authorizeAf(serviceName, afIDParam string) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		afID := gc.Param(afIDParam)
+		policy, enabled := s.Config().AfAuthorization(afID)
+		if !enabled {
+			gc.Next()
+			return
+		}
+
+		var pd *models.ProblemDetails
+		switch {
+		case policy == nil:
+			pd = openapi.ProblemDetailsForbidden(
+				fmt.Sprintf("AF[%s] is not authorized", afID), causeRequestNotAuthorized)
+		case !policy.AllowsService(serviceName):
+			pd = openapi.ProblemDetailsForbidden(
+				fmt.Sprintf("AF[%s] is not authorized for %s", afID, serviceName), causeRequestNotAuthorized)
+		default:
+			pd = s.authorizeAfRequest(gc, afID, policy)
+		}
+
+		if pd != nil {
+			logger.SBILog.Warnf("Reject request of AF[%s]: %s", afID, pd.Detail)
+			gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			gc.AbortWithStatusJSON(int(pd.Status), pd)
+			return
+		}
+		gc.Next()
+	}
+}
+
+func (s *Server) authorizeAfRequest(
+	gc *gin.Context,
+	afID string,
+	policy *factory.AfAuthorization,
+) *models.ProblemDetails {
+	var scope afRequestScope
+	if gc.Request.Body != nil {
+		reqBody, err := io.ReadAll(gc.Request.Body)
+		if err != nil {
+			return openapi.ProblemDetailsSystemFailure(err.Error())
+		}
+		// Restore the body for the API handler
+		gc.Request.Body = io.NopCloser(bytes.NewReader(reqBody))
+		// A body the policy cannot be checked against is never let through
+		if len(reqBody) > 0 {
+			if err = json.Unmarshal(reqBody, &scope); err != nil {
+				return openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+			}
+		}
+	}
+
+	forbidden := func(detail string) *models.ProblemDetails {
+		return openapi.ProblemDetailsForbidden(
+			fmt.Sprintf("AF[%s] is not authorized for %s", afID, detail), causeServiceNotAuthorized)
+	}
+
+	for _, appID := range scope.appIds(gc.Param("appID")) {
+		if !policy.AllowsExternalAppId(appID) {
+			return forbidden("application " + appID)
+		}
+	}
+	if scope.Dnn != "" && !policy.AllowsDnn(scope.Dnn) {
+		return forbidden("DNN " + scope.Dnn)
+	}
+	if scope.Snssai != nil && !policy.AllowsSnssai(scope.Snssai) {
+		return forbidden(fmt.Sprintf("S-NSSAI %d-%s", scope.Snssai.Sst, scope.Snssai.Sd))
+	}
+	for _, addr := range scope.ueAddrs() {
+		if !policy.AllowsUeAddr(addr) {
+			return forbidden("UE address " + addr)
+		}
+	}
+
+	gpsis, groupIds, anyUe := scope.ueIds()
+	// The UEs of an AF restricted to its UEs cannot be checked for any UE
+	if anyUe && policy.RestrictsUes() {
+		return forbidden("any UE")
+	}
+	for _, gpsi := range gpsis {
+		if !policy.AllowsGpsi(gpsi) {
+			return forbidden("UE " + gpsi)
+		}
+	}
+	for _, groupId := range groupIds {
+		if !policy.AllowsExternalGroupId(groupId) {
+			return forbidden("group " + groupId)
+		}
+	}
+	return nil
+}
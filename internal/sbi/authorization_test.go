@@ -0,0 +1,201 @@
+package sbi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type nefTestApp struct {
+	nef
+
+	cfg *factory.Config
+}
+
+func (a *nefTestApp) Config() *factory.Config {
+	return a.cfg
+}
+
+func TestAuthorizeAf(t *testing.T) {
+	cfg := &factory.Config{
+		Configuration: &factory.Configuration{
+			AfAuthorization: []factory.AfAuthorization{
+				{
+					AfId:           "af1",
+					Services:       []string{factory.ServiceTraffInflu, factory.ServiceMonEvt},
+					ExternalAppIds: []string{"app1"},
+					Dnns:           []string{"internet"},
+					Snssais:        []models.Snssai{{Sst: 1, Sd: "010203"}},
+					UeIpv4Ranges:   []string{"10.60.0.0/16"},
+					UeIpv6Ranges:   []string{"2001:db8::/32"},
+					Gpsis:          []string{"msisdn-88690*", "extid-*@af1.free5gc.org"},
+				},
+			},
+		},
+	}
+	s := &Server{nef: &nefTestApp{cfg: cfg}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	created := func(gc *gin.Context) {
+		gc.Status(http.StatusCreated)
+	}
+	router.POST(factory.TraffInfluResUriPrefix+"/:afID/subscriptions",
+		s.authorizeAf(factory.ServiceTraffInflu, "afID"), created)
+	router.PUT(factory.PfdMngResUriPrefix+"/:scsAsID/transactions/:transID/applications/:appID",
+		s.authorizeAf(factory.ServicePfdMng, "scsAsID"), created)
+	router.POST(factory.MonEvtResUriPrefix+"/:scsAsID/subscriptions",
+		s.authorizeAf(factory.ServiceMonEvt, "scsAsID"), created)
+
+	tiUri := factory.TraffInfluResUriPrefix + "/af1/subscriptions"
+	testCases := []struct {
+		description    string
+		method         string
+		uri            string
+		body           string
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Allowed request",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","dnn":"internet","snssai":{"sst":1,"sd":"010203"},"ipv4Addr":"10.60.0.1"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC2: Unknown AF",
+			method:         http.MethodPost,
+			uri:            factory.TraffInfluResUriPrefix + "/af2/subscriptions",
+			body:           `{"afAppId":"app1"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC3: Service not allowed",
+			method:         http.MethodPut,
+			uri:            factory.PfdMngResUriPrefix + "/af1/transactions/1/applications/app1",
+			body:           `{"externalAppId":"app1"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC4: Application not allowed",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app2"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC5: DNN not allowed",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","dnn":"ims"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC6: S-NSSAI not allowed",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","snssai":{"sst":1,"sd":"112233"}}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC7: UE IPv4 address out of range",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","ipv4Addr":"10.61.0.1"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC8: UE IPv6 prefix in range",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","ipv6Addr":"2001:db8:1::/64"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC9: UE IPv6 address out of range",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","ipv6Addr":"2001:db9::1"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC10: Malformed body",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app2","dnn":1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC11: Body which is not an object",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `["app2"]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC12: GPSI allowed",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","gpsi":"msisdn-886900000001"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC13: GPSI not allowed",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","gpsi":"msisdn-886100000001"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC14: External group ID of an AF restricted to GPSIs",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","externalGroupId":"group1@af1.free5gc.org"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC15: Any UE of an AF restricted to GPSIs",
+			method:         http.MethodPost,
+			uri:            tiUri,
+			body:           `{"afAppId":"app1","anyUeInd":true}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC16: External ID allowed",
+			method:         http.MethodPost,
+			uri:            factory.MonEvtResUriPrefix + "/af1/subscriptions",
+			body:           `{"externalId":"123@af1.free5gc.org","monitoringType":"LOSS_OF_CONNECTIVITY"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC17: MSISDN not allowed",
+			method:         http.MethodPost,
+			uri:            factory.MonEvtResUriPrefix + "/af1/subscriptions",
+			body:           `{"msisdn":"886100000001","monitoringType":"LOSS_OF_CONNECTIVITY"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.uri, strings.NewReader(tc.body))
+			rsp := httptest.NewRecorder()
+			router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedStatus, rsp.Code)
+		})
+	}
+
+	// Without AF authorization configured any AF is allowed
+	cfg.Configuration.AfAuthorization = nil
+	req := httptest.NewRequest(http.MethodPost, factory.TraffInfluResUriPrefix+"/af2/subscriptions",
+		strings.NewReader(`{"afAppId":"app2"}`))
+	rsp := httptest.NewRecorder()
+	router.ServeHTTP(rsp, req)
+	require.Equal(t, http.StatusCreated, rsp.Code)
+}
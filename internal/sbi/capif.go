@@ -0,0 +1,41 @@
+package sbi
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// logApiInvocation reports the invocations of the northbound API to the CAPIF
+// core function once handled, the API invoker being the AF of the path parameter
+func (s *Server) logApiInvocation(serviceName, afIDParam string) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		invocationTime := time.Now()
+		gc.Next()
+
+		invocation := &nef_models.CapifLog{
+			ResultCode:     strconv.Itoa(gc.Writer.Status()),
+			InvocationTime: &invocationTime,
+			InvocationLat:  int(time.Since(invocationTime).Milliseconds()),
+			Protocol:       nef_models.CapifProtocol_HTTP_1_1,
+			Operation:      gc.Request.Method,
+			ResourceName:   gc.FullPath(),
+			Uri:            gc.Request.URL.Path,
+		}
+		if gc.Request.ProtoMajor == 2 {
+			invocation.Protocol = nef_models.CapifProtocol_HTTP_2
+		}
+		if ip := net.ParseIP(gc.ClientIP()); ip != nil {
+			if ip.To4() != nil {
+				invocation.SrcInterface = &nef_models.InterfaceDescription{Ipv4Addr: ip.String()}
+			} else {
+				invocation.SrcInterface = &nef_models.InterfaceDescription{Ipv6Addr: ip.String()}
+			}
+		}
+
+		go s.Processor().LogApiInvocation(serviceName, gc.Param(afIDParam), invocation)
+	}
+}
@@ -0,0 +1,278 @@
+package consumer
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/free5gc/openapi"
+	AmfEventExposure "github.com/free5gc/openapi/amf/EventExposure"
+	"github.com/free5gc/openapi/amf/MT"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/nrf/NFDiscovery"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+type namfService struct {
+	consumer *Consumer
+
+	mu        sync.RWMutex
+	clients   map[string]*AmfEventExposure.APIClient
+	mtClients map[string]*MT.APIClient
+}
+
+func (s *namfService) getEventExposureClient(uri string) *AmfEventExposure.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.clients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := AmfEventExposure.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = AmfEventExposure.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[uri] = client
+	return client
+}
+
+func (s *namfService) getMTClient(uri string) *MT.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.mtClients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := MT.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = MT.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mtClients[uri] = client
+	return client
+}
+
+func (s *namfService) getAmfEvtsUri() (string, error) {
+	uri := s.consumer.Context().AmfEvtsUri()
+	if uri == "" {
+		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+			ServiceNames: []models.ServiceName{
+				models.ServiceName_NAMF_EVTS,
+			},
+		}
+		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+			models.ServiceName_NAMF_EVTS, models.NrfNfManagementNfType_AMF, models.NrfNfManagementNfType_NEF,
+			&localVarOptionals)
+		if err == nil {
+			s.consumer.Context().SetAmfEvtsUri(sUri)
+		}
+		return sUri, err
+	}
+	return uri, nil
+}
+
+// CreateAmfEventSubscription Subscribe to AMF events of an UE or a group of UEs.
+// 3GPP TS 29.518 release 17 version 17.6.0
+// Resource structure: 6.2.2
+// Request/Response: 6.2.3.2.3.1
+func (s *namfService) CreateAmfEventSubscription(eeSub *models.AmfCreateEventSubscription) (
+	*models.AmfCreatedEventSubscription, *models.ProblemDetails, error,
+) {
+	uri, err := s.getAmfEvtsUri()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := s.getEventExposureClient(uri)
+
+	if client == nil {
+		return nil, nil, openapi.ReportError("could not initialize the EventExposure client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NAMF_EVTS, models.NrfNfManagementNfType_AMF)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	param := AmfEventExposure.CreateSubscriptionRequest{
+		AmfCreateEventSubscription: eeSub,
+	}
+
+	eeSubRsp, errEeSub := client.SubscriptionsCollectionCollectionApi.CreateSubscription(ctx, &param)
+
+	if errEeSub != nil {
+		switch apiErr := errEeSub.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case AmfEventExposure.CreateSubscriptionError:
+				return nil, &errorModel.ProblemDetails, nil
+			case error:
+				return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return &eeSubRsp.AmfCreatedEventSubscription, nil, nil
+}
+
+// DeleteAmfEventSubscription Unsubscribe from AMF events.
+// 3GPP TS 29.518 release 17 version 17.6.0
+// Resource structure: 6.2.2
+// Request/Response: 6.2.3.3.3.2
+func (s *namfService) DeleteAmfEventSubscription(subID string) (*models.ProblemDetails, error) {
+	uri, err := s.getAmfEvtsUri()
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.getEventExposureClient(uri)
+
+	if client == nil {
+		return nil, openapi.ReportError("could not initialize the EventExposure client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NAMF_EVTS, models.NrfNfManagementNfType_AMF)
+	if err != nil {
+		return nil, err
+	}
+
+	param := AmfEventExposure.DeleteSubscriptionRequest{
+		SubscriptionId: &subID,
+	}
+
+	_, errEeSub := client.IndividualSubscriptionDocumentApi.DeleteSubscription(ctx, &param)
+
+	if errEeSub != nil {
+		switch apiErr := errEeSub.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case AmfEventExposure.DeleteSubscriptionError:
+				return &errorModel.ProblemDetails, nil
+			case error:
+				return openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *namfService) getAmfMtUri() (string, error) {
+	uri := s.consumer.Context().AmfMtUri()
+	if uri == "" {
+		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+			ServiceNames: []models.ServiceName{
+				models.ServiceName_NAMF_MT,
+			},
+		}
+		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+			models.ServiceName_NAMF_MT, models.NrfNfManagementNfType_AMF, models.NrfNfManagementNfType_NEF,
+			&localVarOptionals)
+		if err == nil {
+			s.consumer.Context().SetAmfMtUri(sUri)
+		}
+		return sUri, err
+	}
+	return uri, nil
+}
+
+// EnableUeReachability Ask the AMF to page the UE, the reachability of the UE
+// once paged is returned.
+// 3GPP TS 29.518 release 17 version 17.6.0
+// Resource structure: 6.4.3.3
+// Request/Response: 6.4.3.3.3.1
+func (s *namfService) EnableUeReachability(supi string) (
+	models.UeReachability, *models.ProblemDetails, error,
+) {
+	uri, err := s.getAmfMtUri()
+	if err != nil {
+		return "", nil, err
+	}
+
+	client := s.getMTClient(uri)
+
+	if client == nil {
+		return "", nil, openapi.ReportError("could not initialize the MT client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NAMF_MT, models.NrfNfManagementNfType_AMF)
+	if err != nil {
+		return "", nil, err
+	}
+
+	param := MT.EnableUeReachabilityRequest{
+		UeContextId: &supi,
+		EnableUeReachabilityReqData: &models.EnableUeReachabilityReqData{
+			Reachability: models.UeReachability_REACHABLE,
+		},
+	}
+
+	rsp, errMt := client.UeReachIndDocumentApi.EnableUeReachability(ctx, &param)
+
+	if errMt != nil {
+		switch apiErr := errMt.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case MT.EnableUeReachabilityError:
+				// The 504 responses are decoded into ProblemDetailsEnableUeReachability
+				if apiErr.ErrorStatus == http.StatusGatewayTimeout {
+					pd := errorModel.ProblemDetailsEnableUeReachability
+					return "", &models.ProblemDetails{
+						Title:  pd.Title,
+						Status: int32(apiErr.ErrorStatus),
+						Detail: pd.Detail,
+						Cause:  pd.Cause,
+					}, nil
+				}
+				return "", &errorModel.ProblemDetails, nil
+			case error:
+				return "", openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return "", nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return "", openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return "", nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return rsp.EnableUeReachabilityRspData.Reachability, nil, nil
+}
@@ -0,0 +1,148 @@
+package consumer
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/bsf/Management"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/nrf/NFDiscovery"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+const npcfPolicyAuthResUriPrefix = "/" + string(models.ServiceName_NPCF_POLICYAUTHORIZATION) + "/v1"
+
+type nbsfService struct {
+	consumer *Consumer
+
+	mu      sync.RWMutex
+	clients map[string]*Management.APIClient
+}
+
+func (s *nbsfService) getManagementClient(uri string) *Management.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.clients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := Management.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = Management.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[uri] = client
+	return client
+}
+
+func (s *nbsfService) getBsfMgmtUri() (string, error) {
+	uri := s.consumer.Context().BsfMgmtUri()
+	if uri == "" {
+		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+			ServiceNames: []models.ServiceName{
+				models.ServiceName_NBSF_MANAGEMENT,
+			},
+		}
+		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+			models.ServiceName_NBSF_MANAGEMENT, models.NrfNfManagementNfType_BSF, models.NrfNfManagementNfType_NEF,
+			&localVarOptionals)
+		if err == nil {
+			s.consumer.Context().SetBsfMgmtUri(sUri)
+		}
+		return sUri, err
+	}
+	return uri, nil
+}
+
+// GetPcfBinding Retrieve the PCF session binding information matching the query parameters.
+// A nil binding without error means the BSF has no matching binding.
+// 3GPP TS 29.521 release 17 version 17.6.0
+// Resource structure: 5.3.1
+// Request/Response: 5.3.2.3.2
+func (s *nbsfService) GetPcfBinding(param *Management.GetPCFBindingsRequest) (
+	*models.PcfBinding, *models.ProblemDetails, error,
+) {
+	uri, err := s.getBsfMgmtUri()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := s.getManagementClient(uri)
+
+	if client == nil {
+		return nil, nil, openapi.ReportError("could not initialize the Management client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NBSF_MANAGEMENT, models.NrfNfManagementNfType_BSF)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pcfBindingsRsp, errPcfBindings := client.PCFBindingsCollectionApi.GetPCFBindings(ctx, param)
+
+	if errPcfBindings != nil {
+		switch apiErr := errPcfBindings.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case Management.GetPCFBindingsError:
+				return nil, &errorModel.ProblemDetails, nil
+			case error:
+				return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	if pcfBindingsRsp == nil || reflect.DeepEqual(pcfBindingsRsp.PcfBinding, models.PcfBinding{}) {
+		// 204 No Content
+		return nil, nil, nil
+	}
+	return &pcfBindingsRsp.PcfBinding, nil, nil
+}
+
+// PcfPaUriFromBinding builds the Npcf_PolicyAuthorization URI of the PCF in the binding,
+// or returns "" when the binding carries no PCF address.
+func (s *nbsfService) PcfPaUriFromBinding(binding *models.PcfBinding) string {
+	if binding == nil {
+		return ""
+	}
+
+	scheme := s.consumer.Config().SbiScheme()
+	for _, ipEndPoint := range binding.PcfIpEndPoints {
+		host := ipEndPoint.Ipv4Address
+		if host == "" && ipEndPoint.Ipv6Address != "" {
+			host = "[" + ipEndPoint.Ipv6Address + "]"
+		}
+		if host == "" {
+			continue
+		}
+		if ipEndPoint.Port != 0 {
+			host = fmt.Sprintf("%s:%d", host, ipEndPoint.Port)
+		}
+		return scheme + "://" + host + npcfPolicyAuthResUriPrefix
+	}
+	if binding.PcfFqdn != "" {
+		return scheme + "://" + binding.PcfFqdn + npcfPolicyAuthResUriPrefix
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package consumer
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	capifPublishResUriPrefix = "/published-apis/v1"
+	capifLoggingResUriPrefix = "/api-invocation-logs/v1"
+)
+
+// ncapifService consumes the CAPIF core function APIs of 3GPP TS 29.222,
+// which are not part of the free5gc openapi clients.
+type ncapifService struct {
+	consumer *Consumer
+
+	client *http.Client
+}
+
+func (s *ncapifService) serviceApisUri() string {
+	return s.consumer.Config().CapifUri() + capifPublishResUriPrefix + "/" +
+		url.PathEscape(s.consumer.Config().CapifApfId()) + "/service-apis"
+}
+
+// sendCapifRequest sends the JSON body to the CAPIF core function and decodes
+// the response body into rsp. A ProblemDetails is returned on a non-2xx status.
+func (s *ncapifService) sendCapifRequest(method, uri string, body, rsp interface{}) (
+	http.Header, *models.ProblemDetails, error,
+) {
+	return sendJSONRequest(s.client, "CAPIF core function", method, uri, body, rsp)
+}
+
+// GetServiceApis Retrieve the service APIs published by the APF of the NEF.
+// 3GPP TS 29.222 release 17
+// Resource structure: 8.2.2.2
+// Request/Response: 8.2.2.2.3.2
+func (s *ncapifService) GetServiceApis() (
+	[]nef_models.ServiceApiDescription, *models.ProblemDetails, error,
+) {
+	var descs []nef_models.ServiceApiDescription
+	_, pd, err := s.sendCapifRequest(http.MethodGet, s.serviceApisUri(), nil, &descs)
+	if pd != nil && pd.Status == http.StatusNotFound {
+		// Nothing published by the APF yet
+		return nil, nil, nil
+	}
+	return descs, pd, err
+}
+
+// PublishServiceApi Publish a service API to the CAPIF core function.
+// 3GPP TS 29.222 release 17
+// Resource structure: 8.2.2.2
+// Request/Response: 8.2.2.2.3.1
+func (s *ncapifService) PublishServiceApi(desc *nef_models.ServiceApiDescription) (
+	*nef_models.ServiceApiDescription, *models.ProblemDetails, error,
+) {
+	published := &nef_models.ServiceApiDescription{}
+	header, pd, err := s.sendCapifRequest(http.MethodPost, s.serviceApisUri(), desc, published)
+	if pd != nil || err != nil {
+		return nil, pd, err
+	}
+
+	if published.ApiId == "" {
+		// Take the serviceApiId from the Location header
+		if location, errParse := url.Parse(header.Get("Location")); errParse == nil {
+			published.ApiId = path.Base(location.Path)
+		}
+	}
+	return published, nil, nil
+}
+
+// UpdateServiceApi Update a published service API.
+// 3GPP TS 29.222 release 17
+// Resource structure: 8.2.2.3
+// Request/Response: 8.2.2.3.3.2
+func (s *ncapifService) UpdateServiceApi(serviceApiId string, desc *nef_models.ServiceApiDescription) (
+	*nef_models.ServiceApiDescription, *models.ProblemDetails, error,
+) {
+	updated := &nef_models.ServiceApiDescription{}
+	uri := s.serviceApisUri() + "/" + url.PathEscape(serviceApiId)
+	_, pd, err := s.sendCapifRequest(http.MethodPut, uri, desc, updated)
+	if pd != nil || err != nil {
+		return nil, pd, err
+	}
+	if updated.ApiId == "" {
+		updated.ApiId = serviceApiId
+	}
+	return updated, nil, nil
+}
+
+// UnpublishServiceApi Unpublish a published service API.
+// 3GPP TS 29.222 release 17
+// Resource structure: 8.2.2.3
+// Request/Response: 8.2.2.3.3.3
+func (s *ncapifService) UnpublishServiceApi(serviceApiId string) (*models.ProblemDetails, error) {
+	uri := s.serviceApisUri() + "/" + url.PathEscape(serviceApiId)
+	_, pd, err := s.sendCapifRequest(http.MethodDelete, uri, nil, nil)
+	return pd, err
+}
+
+// LogApiInvocations Log the API invocations on the AEF of the NEF.
+// 3GPP TS 29.222 release 17
+// Resource structure: 8.7.2.2
+// Request/Response: 8.7.2.2.3.1
+func (s *ncapifService) LogApiInvocations(invocationLog *nef_models.InvocationLog) (
+	*models.ProblemDetails, error,
+) {
+	uri := s.consumer.Config().CapifUri() + capifLoggingResUriPrefix + "/" +
+		url.PathEscape(s.consumer.Config().CapifAefId()) + "/logs"
+	_, pd, err := s.sendCapifRequest(http.MethodPost, uri, invocationLog, nil)
+	return pd, err
+}
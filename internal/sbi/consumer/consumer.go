@@ -1,12 +1,27 @@
 package consumer
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
 	"github.com/free5gc/nef/internal/logger"
 	"github.com/free5gc/nef/pkg/app"
 	"github.com/free5gc/openapi"
+	AmfEventExposure "github.com/free5gc/openapi/amf/EventExposure"
+	"github.com/free5gc/openapi/amf/MT"
+	"github.com/free5gc/openapi/bsf/Management"
+	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/openapi/nrf/NFDiscovery"
 	"github.com/free5gc/openapi/nrf/NFManagement"
+	"github.com/free5gc/openapi/nwdaf/AnalyticsInfo"
+	"github.com/free5gc/openapi/nwdaf/EventsSubscription"
 	"github.com/free5gc/openapi/pcf/PolicyAuthorization"
+	"github.com/free5gc/openapi/smf/NIDD"
+	UdmEventExposure "github.com/free5gc/openapi/udm/EventExposure"
+	"github.com/free5gc/openapi/udm/SubscriberDataManagement"
 	"github.com/free5gc/openapi/udr/DataRepository"
 )
 
@@ -21,6 +36,14 @@ type Consumer struct {
 	*nnrfService
 	*npcfService
 	*nudrService
+	*nudmService
+	*nbsfService
+	*namfService
+	*ncapifService
+	*nafService
+	*nnwdafService
+	*nsmsfService
+	*nsmfService
 }
 
 func NewConsumer(nef nef) (*Consumer, error) {
@@ -43,6 +66,49 @@ func NewConsumer(nef nef) (*Consumer, error) {
 		consumer: c,
 		clients:  make(map[string]*DataRepository.APIClient),
 	}
+
+	c.nudmService = &nudmService{
+		consumer:  c,
+		clients:   make(map[string]*SubscriberDataManagement.APIClient),
+		eeClients: make(map[string]*UdmEventExposure.APIClient),
+	}
+
+	c.nbsfService = &nbsfService{
+		consumer: c,
+		clients:  make(map[string]*Management.APIClient),
+	}
+
+	c.namfService = &namfService{
+		consumer:  c,
+		clients:   make(map[string]*AmfEventExposure.APIClient),
+		mtClients: make(map[string]*MT.APIClient),
+	}
+
+	c.ncapifService = &ncapifService{
+		consumer: c,
+		client:   http.DefaultClient,
+	}
+
+	c.nafService = &nafService{
+		consumer: c,
+		client:   &http.Client{Timeout: nafRequestTimeout},
+	}
+
+	c.nnwdafService = &nnwdafService{
+		consumer:    c,
+		evtsClients: make(map[string]*EventsSubscription.APIClient),
+		anaClients:  make(map[string]*AnalyticsInfo.APIClient),
+	}
+
+	c.nsmsfService = &nsmsfService{
+		consumer: c,
+		client:   &http.Client{Timeout: smsSfRequestTimeout},
+	}
+
+	c.nsmfService = &nsmfService{
+		consumer:    c,
+		niddClients: make(map[string]*NIDD.APIClient),
+	}
 	return c, nil
 }
 
@@ -55,3 +121,58 @@ func handleAPIServiceNoResponse(err error) (int, interface{}) {
 	pd := openapi.ProblemDetailsSystemFailure(detail)
 	return int(pd.Status), pd
 }
+
+// sendJSONRequest sends the JSON body to the peer and decodes the response body
+// into rsp, for the APIs which are not part of the free5gc openapi clients.
+// A ProblemDetails is returned on a non-2xx status.
+func sendJSONRequest(client *http.Client, peer, method, uri string, body, rsp interface{}) (
+	http.Header, *models.ProblemDetails, error,
+) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, uri, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpRsp, err := client.Do(req)
+	if err != nil {
+		return nil, openapi.ProblemDetailsSystemFailure(err.Error()), nil
+	}
+	defer func() {
+		_ = httpRsp.Body.Close()
+	}()
+
+	rspBody, err := io.ReadAll(httpRsp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if httpRsp.StatusCode >= http.StatusMultipleChoices {
+		pd := &models.ProblemDetails{}
+		if err = json.Unmarshal(rspBody, pd); err != nil || pd.Status == 0 {
+			pd = &models.ProblemDetails{
+				Status: int32(httpRsp.StatusCode),
+				Detail: fmt.Sprintf("%s returned %d", peer, httpRsp.StatusCode),
+			}
+		}
+		return httpRsp.Header, pd, nil
+	}
+
+	if rsp != nil && len(rspBody) > 0 {
+		if err = json.Unmarshal(rspBody, rsp); err != nil {
+			return nil, nil, err
+		}
+	}
+	return httpRsp.Header, nil, nil
+}
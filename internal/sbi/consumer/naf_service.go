@@ -0,0 +1,62 @@
+package consumer
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	nafRequestTimeout      = 5 * time.Second
+	nafEvtExpoResUriPrefix = "/naf-eventexposure/v1"
+)
+
+// nafService consumes the Naf_EventExposure API of 3GPP TS 29.517 offered by
+// the AFs, which is not part of the free5gc openapi clients.
+type nafService struct {
+	consumer *Consumer
+
+	client *http.Client
+}
+
+// CreateAfEventSubscription Subscribe to the events of an AF.
+// 3GPP TS 29.517 release 17
+// Resource structure: 6.1.3.2
+// Request/Response: 6.1.3.2.3.1
+func (s *nafService) CreateAfEventSubscription(afUri string, subsc *models.AfEventExposureSubsc) (
+	string, *models.AfEventExposureSubsc, *models.ProblemDetails, error,
+) {
+	created := &models.AfEventExposureSubsc{}
+	uri := strings.TrimSuffix(afUri, "/") + nafEvtExpoResUriPrefix + "/subscriptions"
+	header, pd, err := sendJSONRequest(s.client, "AF", http.MethodPost, uri, subsc, created)
+	if pd != nil || err != nil {
+		return "", nil, pd, err
+	}
+	return header.Get("Location"), created, nil, nil
+}
+
+// ReplaceAfEventSubscription Replace a subscription to the events of an AF.
+// 3GPP TS 29.517 release 17
+// Resource structure: 6.1.3.3
+// Request/Response: 6.1.3.3.3.2
+func (s *nafService) ReplaceAfEventSubscription(subUri string, subsc *models.AfEventExposureSubsc) (
+	*models.AfEventExposureSubsc, *models.ProblemDetails, error,
+) {
+	replaced := &models.AfEventExposureSubsc{}
+	_, pd, err := sendJSONRequest(s.client, "AF", http.MethodPut, subUri, subsc, replaced)
+	if pd != nil || err != nil {
+		return nil, pd, err
+	}
+	return replaced, nil, nil
+}
+
+// DeleteAfEventSubscription Unsubscribe from the events of an AF.
+// 3GPP TS 29.517 release 17
+// Resource structure: 6.1.3.3
+// Request/Response: 6.1.3.3.3.3
+func (s *nafService) DeleteAfEventSubscription(subUri string) (*models.ProblemDetails, error) {
+	_, pd, err := sendJSONRequest(s.client, "AF", http.MethodDelete, subUri, nil, nil)
+	return pd, err
+}
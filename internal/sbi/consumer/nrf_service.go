@@ -176,11 +176,11 @@ func (s *nnrfService) buildNfProfile() (
 
 	cfg := s.consumer.Config()
 	profile.Ipv4Addresses = append(profile.Ipv4Addresses, cfg.SbiRegisterIP())
-	nfServices := cfg.NFServices()
-	if len(nfServices) == 0 {
-		return nil, fmt.Errorf("buildNfProfile err: NFServices is Empty")
+	// Only the northbound APIs may be served, the profile then lists no NF service
+	profile.NfServices = cfg.NFServices()
+	if len(profile.NfServices) == 0 {
+		logger.ConsumerLog.Warnf("No SBI service in serviceList, register without NF service")
 	}
-	profile.NfServices = nfServices
 	return profile, nil
 }
 
@@ -0,0 +1,331 @@
+package consumer
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/nrf/NFDiscovery"
+	"github.com/free5gc/openapi/nwdaf/AnalyticsInfo"
+	"github.com/free5gc/openapi/nwdaf/EventsSubscription"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+type nnwdafService struct {
+	consumer *Consumer
+
+	mu          sync.RWMutex
+	evtsClients map[string]*EventsSubscription.APIClient
+	anaClients  map[string]*AnalyticsInfo.APIClient
+}
+
+func (s *nnwdafService) getEventsSubscriptionClient(uri string) *EventsSubscription.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.evtsClients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := EventsSubscription.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = EventsSubscription.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evtsClients[uri] = client
+	return client
+}
+
+func (s *nnwdafService) getAnalyticsInfoClient(uri string) *AnalyticsInfo.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.anaClients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := AnalyticsInfo.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = AnalyticsInfo.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.anaClients[uri] = client
+	return client
+}
+
+func (s *nnwdafService) getNwdafEvtsUri() (string, error) {
+	uri := s.consumer.Context().NwdafEvtsUri()
+	if uri == "" {
+		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+			ServiceNames: []models.ServiceName{
+				models.ServiceName_NNWDAF_EVENTSSUBSCRIPTION,
+			},
+		}
+		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+			models.ServiceName_NNWDAF_EVENTSSUBSCRIPTION, models.NrfNfManagementNfType_NWDAF,
+			models.NrfNfManagementNfType_NEF, &localVarOptionals)
+		if err == nil {
+			s.consumer.Context().SetNwdafEvtsUri(sUri)
+		}
+		return sUri, err
+	}
+	return uri, nil
+}
+
+func (s *nnwdafService) getNwdafAnaUri() (string, error) {
+	uri := s.consumer.Context().NwdafAnaUri()
+	if uri == "" {
+		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+			ServiceNames: []models.ServiceName{
+				models.ServiceName_NNWDAF_ANALYTICSINFO,
+			},
+		}
+		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+			models.ServiceName_NNWDAF_ANALYTICSINFO, models.NrfNfManagementNfType_NWDAF,
+			models.NrfNfManagementNfType_NEF, &localVarOptionals)
+		if err == nil {
+			s.consumer.Context().SetNwdafAnaUri(sUri)
+		}
+		return sUri, err
+	}
+	return uri, nil
+}
+
+// CreateNwdafEventsSubscription Subscribe to network data analytics.
+// The subscription ID is taken from the Location header of the response.
+// 3GPP TS 29.520 release 17 version 17.6.0
+// Resource structure: 5.1.3.1
+// Request/Response: 5.1.3.2.3.1
+func (s *nnwdafService) CreateNwdafEventsSubscription(sub *models.NnwdafEventsSubscription) (
+	string, *models.NnwdafEventsSubscription, *models.ProblemDetails, error,
+) {
+	uri, err := s.getNwdafEvtsUri()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	client := s.getEventsSubscriptionClient(uri)
+
+	if client == nil {
+		return "", nil, nil, openapi.ReportError("could not initialize the EventsSubscription client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNWDAF_EVENTSSUBSCRIPTION,
+		models.NrfNfManagementNfType_NWDAF)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	param := EventsSubscription.CreateNWDAFEventsSubscriptionRequest{
+		NnwdafEventsSubscription: sub,
+	}
+
+	rsp, errSub := client.NWDAFEventsSubscriptionsCollectionApi.CreateNWDAFEventsSubscription(ctx, &param)
+
+	if errSub != nil {
+		switch apiErr := errSub.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case EventsSubscription.CreateNWDAFEventsSubscriptionError:
+				return "", nil, &errorModel.ProblemDetails, nil
+			case error:
+				return "", nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return "", nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return "", nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return "", nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	subID := rsp.Location[strings.LastIndex(rsp.Location, "/")+1:]
+	if subID == "" {
+		return "", nil, nil, openapi.ReportError("no subscription ID in the NWDAF response")
+	}
+	return subID, &rsp.NnwdafEventsSubscription, nil, nil
+}
+
+// UpdateNwdafEventsSubscription Modify a subscription to network data analytics.
+// 3GPP TS 29.520 release 17 version 17.6.0
+// Resource structure: 5.1.3.1
+// Request/Response: 5.1.3.3.3.2
+func (s *nnwdafService) UpdateNwdafEventsSubscription(subID string, sub *models.NnwdafEventsSubscription) (
+	*models.NnwdafEventsSubscription, *models.ProblemDetails, error,
+) {
+	uri, err := s.getNwdafEvtsUri()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := s.getEventsSubscriptionClient(uri)
+
+	if client == nil {
+		return nil, nil, openapi.ReportError("could not initialize the EventsSubscription client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNWDAF_EVENTSSUBSCRIPTION,
+		models.NrfNfManagementNfType_NWDAF)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	param := EventsSubscription.UpdateNWDAFEventsSubscriptionRequest{
+		SubscriptionId:           &subID,
+		NnwdafEventsSubscription: sub,
+	}
+
+	rsp, errSub := client.IndividualNWDAFEventsSubscriptionDocumentApi.UpdateNWDAFEventsSubscription(ctx, &param)
+
+	if errSub != nil {
+		switch apiErr := errSub.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case EventsSubscription.UpdateNWDAFEventsSubscriptionError:
+				return nil, &errorModel.ProblemDetails, nil
+			case error:
+				return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return &rsp.NnwdafEventsSubscription, nil, nil
+}
+
+// DeleteNwdafEventsSubscription Unsubscribe from network data analytics.
+// 3GPP TS 29.520 release 17 version 17.6.0
+// Resource structure: 5.1.3.1
+// Request/Response: 5.1.3.3.3.1
+func (s *nnwdafService) DeleteNwdafEventsSubscription(subID string) (*models.ProblemDetails, error) {
+	uri, err := s.getNwdafEvtsUri()
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.getEventsSubscriptionClient(uri)
+
+	if client == nil {
+		return nil, openapi.ReportError("could not initialize the EventsSubscription client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNWDAF_EVENTSSUBSCRIPTION,
+		models.NrfNfManagementNfType_NWDAF)
+	if err != nil {
+		return nil, err
+	}
+
+	param := EventsSubscription.DeleteNWDAFEventsSubscriptionRequest{
+		SubscriptionId: &subID,
+	}
+
+	_, errSub := client.IndividualNWDAFEventsSubscriptionDocumentApi.DeleteNWDAFEventsSubscription(ctx, &param)
+
+	if errSub != nil {
+		switch apiErr := errSub.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case EventsSubscription.DeleteNWDAFEventsSubscriptionError:
+				return &errorModel.ProblemDetails, nil
+			case error:
+				return openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return nil, nil
+}
+
+// GetNwdafAnalytics Request the network data analytics available at the NWDAF.
+// 3GPP TS 29.520 release 17 version 17.6.0
+// Resource structure: 5.2.3.1
+// Request/Response: 5.2.3.2.3.1
+func (s *nnwdafService) GetNwdafAnalytics(param *AnalyticsInfo.GetNWDAFAnalyticsRequest) (
+	*models.NwdafAnalyticsInfoAnalyticsData, *models.ProblemDetails, error,
+) {
+	uri, err := s.getNwdafAnaUri()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := s.getAnalyticsInfoClient(uri)
+
+	if client == nil {
+		return nil, nil, openapi.ReportError("could not initialize the AnalyticsInfo client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNWDAF_ANALYTICSINFO,
+		models.NrfNfManagementNfType_NWDAF)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rsp, errAna := client.NWDAFAnalyticsDocumentApi.GetNWDAFAnalytics(ctx, param)
+
+	if errAna != nil {
+		switch apiErr := errAna.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case AnalyticsInfo.GetNWDAFAnalyticsError:
+				// The 500 responses are decoded into ProblemDetailsAnalyticsInfoRequest
+				if pd := errorModel.ProblemDetailsAnalyticsInfoRequest; pd.Status != 0 {
+					return nil, &models.ProblemDetails{
+						Title:  pd.Title,
+						Status: pd.Status,
+						Detail: pd.Detail,
+						Cause:  pd.Cause,
+					}, nil
+				}
+				return nil, &errorModel.ProblemDetails, nil
+			case error:
+				return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return &rsp.NwdafAnalyticsInfoAnalyticsData, nil, nil
+}
@@ -66,14 +66,23 @@ func (s *npcfService) getPcfPolicyAuthUri() (string, error) {
 	return uri, nil
 }
 
+// resolvePcfPolicyAuthUri returns pcfUri, the PCF selected through the BSF binding,
+// or the PCF discovered via NRF when no PCF was selected.
+func (s *npcfService) resolvePcfPolicyAuthUri(pcfUri string) (string, error) {
+	if pcfUri != "" {
+		return pcfUri, nil
+	}
+	return s.getPcfPolicyAuthUri()
+}
+
 // GetAppSession Reads an existing Individual Application Session Context resource.
 // 3GPP TS 29.514 release 17 version 17.6.0
 // Resource structure: 5.3.1
 // Request/Response: 5.3.3.3.1
-func (s *npcfService) GetAppSession(appSessionId string) (
+func (s *npcfService) GetAppSession(pcfUri, appSessionId string) (
 	*models.AppSessionContext, *models.ProblemDetails, error,
 ) {
-	uri, err := s.getPcfPolicyAuthUri()
+	uri, err := s.resolvePcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -124,8 +133,10 @@ func (s *npcfService) GetAppSession(appSessionId string) (
 // 3GPP TS 29.514 release 17 version 17.6.0
 // Resource structure: 5.3.1
 // Request/Response: 5.3.2.3.1
-func (s *npcfService) PostAppSessions(asc *models.AppSessionContext) (string, *models.ProblemDetails, error) {
-	uri, err := s.getPcfPolicyAuthUri()
+func (s *npcfService) PostAppSessions(pcfUri string, asc *models.AppSessionContext) (
+	string, *models.ProblemDetails, error,
+) {
+	uri, err := s.resolvePcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return "", nil, err
 	}
@@ -179,7 +190,7 @@ func (s *npcfService) PostAppSessions(asc *models.AppSessionContext) (string, *m
 }
 
 func (s *npcfService) PutAppSession(
-	appSessionId string,
+	pcfUri, appSessionId string,
 	ascUpdateData *models.AppSessionContextUpdateData,
 	asc *models.AppSessionContext,
 ) (int, interface{}, string) {
@@ -191,7 +202,7 @@ func (s *npcfService) PutAppSession(
 		modRsp  *PolicyAuthorization.ModAppSessionResponse
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	uri, err := s.resolvePcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return rspCode, rspBody, appSessionId
 	}
@@ -243,10 +254,10 @@ func (s *npcfService) PutAppSession(
 // 3GPP TS 29.514 release 17 version 17.6.0
 // Resource structure: 5.3.1-1
 // Request/Response: 5.3.3.3.2
-func (s *npcfService) PatchAppSession(appSessionId string,
+func (s *npcfService) PatchAppSession(pcfUri, appSessionId string,
 	ascUpdateData *models.AppSessionContextUpdateData,
 ) (*models.AppSessionContext, *models.ProblemDetails, error) {
-	uri, err := s.getPcfPolicyAuthUri()
+	uri, err := s.resolvePcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -304,8 +315,8 @@ func (s *npcfService) PatchAppSession(appSessionId string,
 // 3GPP TS 29.514 Release 17 version 17.6.0
 // Resource structure 5.3.1
 // Request/Response: 5.3.3.4.2
-func (s *npcfService) DeleteAppSession(appSessionId string) (int, *models.ProblemDetails, error) {
-	uri, err := s.getPcfPolicyAuthUri()
+func (s *npcfService) DeleteAppSession(pcfUri, appSessionId string) (int, *models.ProblemDetails, error) {
+	uri, err := s.resolvePcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -0,0 +1,119 @@
+package consumer
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/smf/NIDD"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+const (
+	smfNiddResUriPrefix    = "/" + string(models.ServiceName_NSMF_NIDD) + "/"
+	smfNiddPduSessionsPath = "/pdu-sessions/"
+	smfNiddMtDataContentId = "mtData"
+)
+
+type nsmfService struct {
+	consumer *Consumer
+
+	mu          sync.RWMutex
+	niddClients map[string]*NIDD.APIClient
+}
+
+func (s *nsmfService) getNIDDClient(uri string) *NIDD.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.niddClients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := NIDD.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = NIDD.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.niddClients[uri] = client
+	return client
+}
+
+// DeliverNiddMtData Deliver the MT non-IP data to the UE through the SMF of its
+// PDU session. dlNiddEndPoint is the Individual PDU session resource given by
+// the SMF in the Nnef_SMContext SM context. The DeliverError is returned when
+// the SMF could not reach the UE.
+// 3GPP TS 29.542 release 17 version 17.4.0
+// Resource structure: 6.1.3.2
+// Request/Response: 6.1.5.2.2
+func (s *nsmfService) DeliverNiddMtData(dlNiddEndPoint string, data []byte) (
+	*models.DeliverError, *models.ProblemDetails, error,
+) {
+	// E.g. {apiRoot}/nsmf-nidd/v1/pdu-sessions/{pduSessionRef}
+	rootIdx := strings.Index(dlNiddEndPoint, smfNiddResUriPrefix)
+	refIdx := strings.LastIndex(dlNiddEndPoint, smfNiddPduSessionsPath)
+	if rootIdx < 0 || refIdx < rootIdx {
+		return nil, nil, openapi.ReportError("invalid dlNiddEndPoint: %s", dlNiddEndPoint)
+	}
+	uri, pduSessionRef := dlNiddEndPoint[:rootIdx], dlNiddEndPoint[refIdx+len(smfNiddPduSessionsPath):]
+
+	client := s.getNIDDClient(uri)
+
+	if client == nil {
+		return nil, nil, openapi.ReportError("could not initialize the NIDD client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NSMF_NIDD, models.NrfNfManagementNfType_SMF)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	param := NIDD.DeliverRequest{
+		PduSessionRef: &pduSessionRef,
+		DeliverRequest: &models.DeliverRequest{
+			JsonData: &models.SmfNiddDeliverReqData{
+				MtData: &models.RefToBinaryData{ContentId: smfNiddMtDataContentId},
+			},
+			BinaryMtData: data,
+		},
+	}
+
+	_, errDeliver := client.IndividualPDUSessionApi.Deliver(ctx, &param)
+
+	if errDeliver != nil {
+		switch apiErr := errDeliver.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case NIDD.DeliverError:
+				// The UE not reachable is reported in the DeliverError of the 504
+				if apiErr.ErrorStatus == http.StatusGatewayTimeout {
+					return &errorModel.DeliverError, nil, nil
+				}
+				return nil, &errorModel.ProblemDetails, nil
+			case error:
+				return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return nil, nil, nil
+}
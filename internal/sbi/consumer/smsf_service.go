@@ -0,0 +1,79 @@
+package consumer
+
+import (
+	"net/http"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+const smsSfRequestTimeout = 5 * time.Second
+
+// nsmsfService submits the device triggers to the SMS-SF configured in
+// deviceTriggering.smsSfUri. The T4 interface of 3GPP TS 29.337 is Diameter
+// based, the SMS-SF is reached through a JSON stand-in API instead:
+//
+//	POST   {smsSfUri}/device-triggers           submit, 201 with Location
+//	PUT    {smsSfUri}/device-triggers/{trigId}  replace
+//	DELETE {smsSfUri}/device-triggers/{trigId}  recall
+//
+// The delivery reports are posted by the SMS-SF to the notifUri of the trigger.
+type nsmsfService struct {
+	consumer *Consumer
+
+	client *http.Client
+}
+
+func (s *nsmsfService) deviceTriggersUri() (string, error) {
+	uri := s.consumer.Config().DevTrigSmsSfUri()
+	if uri == "" {
+		return "", openapi.ReportError("no SMS-SF is configured for device triggering")
+	}
+	return uri + "/device-triggers", nil
+}
+
+// SubmitDeviceTrigger Submit a device trigger to the SMS-SF, the URI of the
+// trigger at the SMS-SF is taken from the Location header of the response.
+func (s *nsmsfService) SubmitDeviceTrigger(trig *nef_models.SmsSfDeviceTrigger) (
+	string, *nef_models.SmsSfDeviceTrigger, *models.ProblemDetails, error,
+) {
+	uri, err := s.deviceTriggersUri()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	submitted := &nef_models.SmsSfDeviceTrigger{}
+	header, pd, err := sendJSONRequest(s.client, "SMS-SF", http.MethodPost, uri, trig, submitted)
+	if pd != nil || err != nil {
+		return "", nil, pd, err
+	}
+	trigUri := header.Get("Location")
+	if trigUri == "" {
+		return "", nil, nil, openapi.ReportError("no Location in the SMS-SF response")
+	}
+	return trigUri, submitted, nil, nil
+}
+
+// ReplaceDeviceTrigger Replace a pending device trigger at the SMS-SF.
+func (s *nsmsfService) ReplaceDeviceTrigger(trigUri string, trig *nef_models.SmsSfDeviceTrigger) (
+	*nef_models.SmsSfDeviceTrigger, *models.ProblemDetails, error,
+) {
+	replaced := &nef_models.SmsSfDeviceTrigger{}
+	_, pd, err := sendJSONRequest(s.client, "SMS-SF", http.MethodPut, trigUri, trig, replaced)
+	if pd != nil || err != nil {
+		return nil, pd, err
+	}
+	return replaced, nil, nil
+}
+
+// RecallDeviceTrigger Recall a device trigger from the SMS-SF.
+func (s *nsmsfService) RecallDeviceTrigger(trigUri string) (*models.ProblemDetails, error) {
+	_, pd, err := sendJSONRequest(s.client, "SMS-SF", http.MethodDelete, trigUri, nil, nil)
+	if pd != nil && pd.Status == http.StatusNotFound {
+		// Not known to the SMS-SF any more
+		return nil, nil
+	}
+	return pd, err
+}
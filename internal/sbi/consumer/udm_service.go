@@ -0,0 +1,318 @@
+package consumer
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/nrf/NFDiscovery"
+	UdmEventExposure "github.com/free5gc/openapi/udm/EventExposure"
+	"github.com/free5gc/openapi/udm/SubscriberDataManagement"
+	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+)
+
+type nudmService struct {
+	consumer *Consumer
+
+	mu        sync.RWMutex
+	clients   map[string]*SubscriberDataManagement.APIClient
+	eeClients map[string]*UdmEventExposure.APIClient
+}
+
+func (s *nudmService) getSubscriberDataManagementClient(uri string) *SubscriberDataManagement.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.clients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := SubscriberDataManagement.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = SubscriberDataManagement.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[uri] = client
+	return client
+}
+
+func (s *nudmService) getEventExposureClient(uri string) *UdmEventExposure.APIClient {
+	if uri == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+
+	client, ok := s.eeClients[uri]
+
+	if ok {
+		defer s.mu.RUnlock()
+		return client
+	}
+
+	configuration := UdmEventExposure.NewConfiguration()
+	configuration.SetBasePath(uri)
+	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(http.DefaultClient)
+	client = UdmEventExposure.NewAPIClient(configuration)
+
+	s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eeClients[uri] = client
+	return client
+}
+
+func (s *nudmService) getUdmSdmUri() (string, error) {
+	uri := s.consumer.Context().UdmSdmUri()
+	if uri == "" {
+		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+			ServiceNames: []models.ServiceName{
+				models.ServiceName_NUDM_SDM,
+			},
+		}
+		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+			models.ServiceName_NUDM_SDM, models.NrfNfManagementNfType_UDM, models.NrfNfManagementNfType_NEF, &localVarOptionals)
+		if err == nil {
+			s.consumer.Context().SetUdmSdmUri(sUri)
+		}
+		return sUri, err
+	}
+	return uri, nil
+}
+
+// GetGroupIdentifiers Retrieve the internal group identifier mapped to an external group identifier.
+// 3GPP TS 29.503 release 17 version 17.6.0
+// Resource structure: 6.1.2
+// Request/Response: 6.1.3.21.3.1
+func (s *nudmService) GetGroupIdentifiers(afID, extGroupID string) (
+	*models.UdmSdmGroupIdentifiers, *models.ProblemDetails, error,
+) {
+	uri, err := s.getUdmSdmUri()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := s.getSubscriberDataManagementClient(uri)
+
+	if client == nil {
+		return nil, nil, openapi.ReportError("could not initialize the SubscriberDataManagement client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_SDM, models.NrfNfManagementNfType_UDM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	param := SubscriberDataManagement.GetGroupIdentifiersRequest{}
+	param.SetExtGroupId(extGroupID)
+	if afID != "" {
+		param.SetAfId(afID)
+	}
+
+	groupIdentifiersRsp, errGroupIdentifiers := client.GroupIdentifiersApi.GetGroupIdentifiers(ctx, &param)
+
+	if errGroupIdentifiers != nil {
+		switch apiErr := errGroupIdentifiers.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case SubscriberDataManagement.GetGroupIdentifiersError:
+				return nil, &errorModel.ProblemDetails, nil
+			case error:
+				return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return &groupIdentifiersRsp.UdmSdmGroupIdentifiers, nil, nil
+}
+
+// GetSupi Translate a GPSI into the SUPI of the UE.
+// 3GPP TS 29.503 release 17 version 17.6.0
+// Resource structure: 6.1.2
+// Request/Response: 6.1.3.22.3.1
+func (s *nudmService) GetSupi(afID, gpsi string) (string, *models.ProblemDetails, error) {
+	uri, err := s.getUdmSdmUri()
+	if err != nil {
+		return "", nil, err
+	}
+
+	client := s.getSubscriberDataManagementClient(uri)
+
+	if client == nil {
+		return "", nil, openapi.ReportError("could not initialize the SubscriberDataManagement client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_SDM, models.NrfNfManagementNfType_UDM)
+	if err != nil {
+		return "", nil, err
+	}
+
+	param := SubscriberDataManagement.GetSupiOrGpsiRequest{}
+	param.SetUeId(gpsi)
+	if afID != "" {
+		param.SetAfId(afID)
+	}
+
+	idTranslationRsp, errIdTranslation := client.GPSIToSUPITranslationOrSUPIToGPSITranslationApi.
+		GetSupiOrGpsi(ctx, &param)
+
+	if errIdTranslation != nil {
+		switch apiErr := errIdTranslation.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case SubscriberDataManagement.GetSupiOrGpsiError:
+				return "", &errorModel.ProblemDetails, nil
+			case error:
+				return "", openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return "", nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return "", openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return "", nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return idTranslationRsp.IdTranslationResult.Supi, nil, nil
+}
+
+func (s *nudmService) getUdmEeUri() (string, error) {
+	uri := s.consumer.Context().UdmEeUri()
+	if uri == "" {
+		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+			ServiceNames: []models.ServiceName{
+				models.ServiceName_NUDM_EE,
+			},
+		}
+		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+			models.ServiceName_NUDM_EE, models.NrfNfManagementNfType_UDM, models.NrfNfManagementNfType_NEF, &localVarOptionals)
+		if err == nil {
+			s.consumer.Context().SetUdmEeUri(sUri)
+		}
+		return sUri, err
+	}
+	return uri, nil
+}
+
+// CreateEeSubscription Subscribe to a monitoring event of an UE or a group of UEs.
+// 3GPP TS 29.503 release 17 version 17.6.0
+// Resource structure: 6.4.2
+// Request/Response: 6.4.3.2.3.1
+func (s *nudmService) CreateEeSubscription(ueIdentity string, eeSub *models.UdmEeEeSubscription) (
+	*models.UdmEeCreatedEeSubscription, *models.ProblemDetails, error,
+) {
+	uri, err := s.getUdmEeUri()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := s.getEventExposureClient(uri)
+
+	if client == nil {
+		return nil, nil, openapi.ReportError("could not initialize the EventExposure client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_EE, models.NrfNfManagementNfType_UDM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	param := UdmEventExposure.CreateEeSubscriptionRequest{
+		UeIdentity:          &ueIdentity,
+		UdmEeEeSubscription: eeSub,
+	}
+
+	eeSubRsp, errEeSub := client.CreateEESubscriptionApi.CreateEeSubscription(ctx, &param)
+
+	if errEeSub != nil {
+		switch apiErr := errEeSub.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case UdmEventExposure.CreateEeSubscriptionError:
+				return nil, &errorModel.ProblemDetails, nil
+			case error:
+				return nil, openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return nil, openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return &eeSubRsp.UdmEeCreatedEeSubscription, nil, nil
+}
+
+// DeleteEeSubscription Unsubscribe from a monitoring event.
+// 3GPP TS 29.503 release 17 version 17.6.0
+// Resource structure: 6.4.2
+// Request/Response: 6.4.3.3.3.1
+func (s *nudmService) DeleteEeSubscription(ueIdentity, subID string) (*models.ProblemDetails, error) {
+	uri, err := s.getUdmEeUri()
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.getEventExposureClient(uri)
+
+	if client == nil {
+		return nil, openapi.ReportError("could not initialize the EventExposure client")
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_EE, models.NrfNfManagementNfType_UDM)
+	if err != nil {
+		return nil, err
+	}
+
+	param := UdmEventExposure.DeleteEeSubscriptionRequest{
+		UeIdentity:     &ueIdentity,
+		SubscriptionId: &subID,
+	}
+
+	_, errEeSub := client.DeleteEESubscriptionApi.DeleteEeSubscription(ctx, &param)
+
+	if errEeSub != nil {
+		switch apiErr := errEeSub.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errorModel := apiErr.Model().(type) {
+			case UdmEventExposure.DeleteEeSubscriptionError:
+				return &errorModel.ProblemDetails, nil
+			case error:
+				return openapi.ProblemDetailsSystemFailure(errorModel.Error()), nil
+			default:
+				return nil, openapi.ReportError("openapi error")
+			}
+		case error:
+			return openapi.ProblemDetailsSystemFailure(apiErr.Error()), nil
+		default:
+			return nil, openapi.ReportError("server no response")
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	nef_models "github.com/free5gc/nef/internal/models"
+)
+
+const anaExpoNotifyTimeout = 5 * time.Second
+
+// AnaExpoNotifier delivers the analytics event notifications to the AF
+// (TS 29.522 clause 5.6.5.2).
+type AnaExpoNotifier struct {
+	client *http.Client
+}
+
+func NewAnaExpoNotifier() (*AnaExpoNotifier, error) {
+	return &AnaExpoNotifier{
+		client: &http.Client{Timeout: anaExpoNotifyTimeout},
+	}, nil
+}
+
+// NotifyAf posts the AnalyticsEventNotification to the AF notification URI.
+func (n *AnaExpoNotifier) NotifyAf(uri string, notif *nef_models.AnalyticsEventNotification) error {
+	_, _, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeAnaExpo, err == nil)
+	return err
+}
@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	nef_models "github.com/free5gc/nef/internal/models"
+)
+
+const devTrigNotifyTimeout = 5 * time.Second
+
+// DevTrigNotifier delivers the device triggering delivery reports to the AF
+// (TS 29.122 clause 5.7.3.4).
+type DevTrigNotifier struct {
+	client *http.Client
+}
+
+func NewDevTrigNotifier() (*DevTrigNotifier, error) {
+	return &DevTrigNotifier{
+		client: &http.Client{Timeout: devTrigNotifyTimeout},
+	}, nil
+}
+
+// NotifyAf posts the DeviceTriggeringDeliveryReportNotification to the AF
+// notificationDestination.
+func (n *DevTrigNotifier) NotifyAf(
+	uri string, notif *nef_models.DeviceTriggeringDeliveryReportNotification,
+) error {
+	_, _, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeDevTrig, err == nil)
+	return err
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	"github.com/free5gc/openapi/models"
+)
+
+const evtExpoNotifyTimeout = 5 * time.Second
+
+// EvtExpoNotifier delivers the Nnef_EventExposure notifications to the
+// consumer NFs, e.g. the NWDAF (TS 29.591 clause 6.1.5.2).
+type EvtExpoNotifier struct {
+	client *http.Client
+}
+
+func NewEvtExpoNotifier() (*EvtExpoNotifier, error) {
+	return &EvtExpoNotifier{
+		client: &http.Client{Timeout: evtExpoNotifyTimeout},
+	}, nil
+}
+
+// NotifyNf posts the NefEventExposureNotif to the notifUri of the consumer NF.
+func (n *EvtExpoNotifier) NotifyNf(uri string, notif *models.NefEventExposureNotif) error {
+	_, _, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeEvtExpo, err == nil)
+	return err
+}
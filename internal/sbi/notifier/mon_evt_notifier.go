@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	nef_models "github.com/free5gc/nef/internal/models"
+)
+
+const monEvtNotifyTimeout = 5 * time.Second
+
+// MonEvtNotifier delivers monitoring event reports to the AF
+// (TS 29.122 clause 5.3.3.3).
+type MonEvtNotifier struct {
+	client *http.Client
+}
+
+func NewMonEvtNotifier() (*MonEvtNotifier, error) {
+	return &MonEvtNotifier{
+		client: &http.Client{Timeout: monEvtNotifyTimeout},
+	}, nil
+}
+
+// NotifyAf posts the MonitoringNotification to the AF notification destination.
+func (n *MonEvtNotifier) NotifyAf(uri string, notif *nef_models.MonitoringNotification) error {
+	_, _, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeMonEvt, err == nil)
+	return err
+}
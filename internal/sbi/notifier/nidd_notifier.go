@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	nef_models "github.com/free5gc/nef/internal/models"
+)
+
+const niddNotifyTimeout = 5 * time.Second
+
+// NiddNotifier delivers the MO data and the downlink data delivery status to
+// the AF (TS 29.122 clause 5.6.3.5).
+type NiddNotifier struct {
+	client *http.Client
+}
+
+func NewNiddNotifier() (*NiddNotifier, error) {
+	return &NiddNotifier{
+		client: &http.Client{Timeout: niddNotifyTimeout},
+	}, nil
+}
+
+// NotifyUplinkData posts the NiddUplinkDataNotification to the AF
+// notificationDestination.
+func (n *NiddNotifier) NotifyUplinkData(
+	uri string, notif *nef_models.NiddUplinkDataNotification,
+) error {
+	_, _, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeNiddUplink, err == nil)
+	return err
+}
+
+// NotifyDeliveryStatus posts the NiddDownlinkDataDeliveryStatusNotification to
+// the AF notificationDestination.
+func (n *NiddNotifier) NotifyDeliveryStatus(
+	uri string, notif *nef_models.NiddDownlinkDataDeliveryStatusNotification,
+) error {
+	_, _, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeNiddDlStatus, err == nil)
+	return err
+}
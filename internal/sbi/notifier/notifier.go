@@ -1,14 +1,109 @@
 package notifier
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/free5gc/nef/internal/store"
+)
+
 type Notifier struct {
 	PfdChangeNotifier *PfdChangeNotifier
+	UpPathChgNotifier *UpPathChgNotifier
+	MonEvtNotifier    *MonEvtNotifier
+	QosNotifier       *QosNotifier
+	PfdMngNotifier    *PfdMngNotifier
+	EvtExpoNotifier   *EvtExpoNotifier
+	AnaExpoNotifier   *AnaExpoNotifier
+	DevTrigNotifier   *DevTrigNotifier
+	NiddNotifier      *NiddNotifier
 }
 
-func NewNotifier() (*Notifier, error) {
+func NewNotifier(st store.Store) (*Notifier, error) {
 	var err error
 	n := &Notifier{}
-	if n.PfdChangeNotifier, err = NewPfdChangeNotifier(); err != nil {
+	if n.PfdChangeNotifier, err = NewPfdChangeNotifier(st); err != nil {
+		return nil, err
+	}
+	if n.UpPathChgNotifier, err = NewUpPathChgNotifier(); err != nil {
+		return nil, err
+	}
+	if n.MonEvtNotifier, err = NewMonEvtNotifier(); err != nil {
+		return nil, err
+	}
+	if n.QosNotifier, err = NewQosNotifier(); err != nil {
+		return nil, err
+	}
+	if n.PfdMngNotifier, err = NewPfdMngNotifier(); err != nil {
+		return nil, err
+	}
+	if n.EvtExpoNotifier, err = NewEvtExpoNotifier(); err != nil {
+		return nil, err
+	}
+	if n.AnaExpoNotifier, err = NewAnaExpoNotifier(); err != nil {
+		return nil, err
+	}
+	if n.DevTrigNotifier, err = NewDevTrigNotifier(); err != nil {
+		return nil, err
+	}
+	if n.NiddNotifier, err = NewNiddNotifier(); err != nil {
 		return nil, err
 	}
 	return n, nil
 }
+
+// LoadFromStore restores the subscriptions kept in the state store
+func (n *Notifier) LoadFromStore() error {
+	return n.PfdChangeNotifier.LoadFromStore()
+}
+
+// Stop stops the background delivery of the notifications
+func (n *Notifier) Stop() {
+	n.PfdChangeNotifier.Stop()
+}
+
+// postJSON posts body as JSON to uri and returns the response body and status.
+// A non-2xx status is reported as an error together with the response.
+func postJSON(client *http.Client, uri string, body interface{}) ([]byte, int, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("marshal notification: %w", err)
+	}
+
+	rspBody, _, status, err := postRaw(client, uri, reqBody)
+	return rspBody, status, err
+}
+
+// postRaw posts the JSON encoded reqBody to uri. The status is 0 when no
+// response was received.
+func postRaw(client *http.Client, uri string, reqBody []byte) ([]byte, http.Header, int, error) {
+	if uri == "" {
+		return nil, nil, 0, fmt.Errorf("empty notification URI")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("post to [%s]: %w", uri, err)
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	rspBody, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, rsp.Header, rsp.StatusCode, fmt.Errorf("read response from [%s]: %w", uri, err)
+	}
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return rspBody, rsp.Header, rsp.StatusCode, fmt.Errorf("[%s] returned %d", uri, rsp.StatusCode)
+	}
+	return rspBody, rsp.Header, rsp.StatusCode, nil
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	"github.com/free5gc/openapi/models"
+)
+
+const pfdMngNotifyTimeout = 5 * time.Second
+
+// PfdMngNotifier delivers the PFD management notifications, reporting the PFDs
+// which failed to be deployed, to the AF (TS 29.122 clause 5.11.3.5).
+type PfdMngNotifier struct {
+	client *http.Client
+}
+
+func NewPfdMngNotifier() (*PfdMngNotifier, error) {
+	return &PfdMngNotifier{
+		client: &http.Client{Timeout: pfdMngNotifyTimeout},
+	}, nil
+}
+
+// NotifyAf posts the PfdReports to the AF notification destination.
+func (n *PfdMngNotifier) NotifyAf(uri string, reports []models.PfdReport) error {
+	_, _, err := postJSON(n.client, uri, reports)
+	business.IncrNotificationCounter(business.NotifTypePfdReport, err == nil)
+	return err
+}
@@ -1,72 +1,179 @@
 package notifier
 
 import (
-	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"runtime/debug"
-	"strconv"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/free5gc/nef/internal/logger"
-	// "github.com/free5gc/openapi/Nnef_PFDmanagement"
+	"github.com/free5gc/nef/internal/metrics/business"
+	"github.com/free5gc/nef/internal/store"
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/openapi/nef/PFDmanagement"
-	sbi_metrics "github.com/free5gc/util/metrics/sbi"
+	"github.com/google/uuid"
+)
+
+const bucketPfdSubs = "pfdSubs"
+
+const (
+	pfdNotifyTimeout      = 5 * time.Second
+	pfdNotifyWorkers      = 8
+	pfdNotifyQueueSize    = 1024
+	pfdNotifyMaxRetries   = 3
+	pfdNotifyRetryDelay   = 500 * time.Millisecond // doubled on each retry
+	pfdNotifyMaxRedirects = 3
+	// Consecutive failed deliveries to a subscription opening its circuit, and
+	// how long the notifications to it are dropped before trying again
+	pfdNotifyBreakerThreshold = 3
+	pfdNotifyBreakerCooldown  = 30 * time.Second
+	// Feature of Nnef_PFDmanagement negotiated on the subscriptions: the PFDs
+	// changed are notified with partialFlag instead of all the PFDs of the application
+	pfdSuppFeatPartialUpdate = 1
 )
 
 type PfdChangeNotifier struct {
-	clientPfdManagement *PFDmanagement.APIClient
-	mu                  sync.RWMutex
+	client *http.Client
+	mu     sync.RWMutex
 
-	numPfdSubID   uint64
+	store         store.Store
+	subs          map[string]*models.PfdSubscription
 	appIdToSubIDs map[string]map[string]bool
-	subIdToURI    map[string]string
+	allAppSubIDs  map[string]bool // subscriptions monitoring all the applications
+	partialSubIDs map[string]bool // subscriptions supporting partial update
+	breakers      map[string]*pfdNotifyBreaker
+	// Handles the PFDs the SMFs failed to apply
+	reportHandler func([]models.PfdChangeReport)
+
+	// Notifications held back within the allowedDelay of the PFDs, by subscription
+	batchMu sync.Mutex
+	batches map[string]*pfdNotifyBatch
+
+	queue      chan *pfdNotifyJob
+	done       chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+	retryDelay time.Duration
 }
 
 type PfdNotifyContext struct {
-	notifier             *PfdChangeNotifier
-	appIdToNotification  map[string]models.PfdChangeNotification
-	subIdToChangedAppIDs map[string][]string
+	notifier                   *PfdChangeNotifier
+	appIdToNotification        map[string]models.PfdChangeNotification
+	appIdToPartialNotification map[string][]models.PfdChangeNotification
+	appIdToAllowedDelay        map[string]time.Duration
+	subIdToChangedAppIDs       map[string][]string
 }
 
-func NewPfdChangeNotifier() (*PfdChangeNotifier, error) {
-	return &PfdChangeNotifier{
-		appIdToSubIDs: make(map[string]map[string]bool),
-		subIdToURI:    make(map[string]string),
-	}, nil
+type pfdNotifyJob struct {
+	subID         string
+	notifications []models.PfdChangeNotification
 }
 
-func (n *PfdChangeNotifier) initPfdManagementApiClient() {
-	n.mu.Lock()
-	defer n.mu.Unlock()
+// pfdNotifyBatch gathers the notifications of a subscription until the
+// earliest deadline given by their allowedDelay
+type pfdNotifyBatch struct {
+	notifications []models.PfdChangeNotification
+	deadline      time.Time
+	timer         *time.Timer
+}
 
-	if n.clientPfdManagement != nil {
-		return
+// pfdNotifyBreaker is the circuit breaker of a subscription
+type pfdNotifyBreaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+func NewPfdChangeNotifier(st store.Store) (*PfdChangeNotifier, error) {
+	n := &PfdChangeNotifier{
+		store: st,
+		client: &http.Client{
+			Timeout: pfdNotifyTimeout,
+			// Redirects are handled per TS 29.500 clause 6.10.9
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		appIdToSubIDs: make(map[string]map[string]bool),
+		allAppSubIDs:  make(map[string]bool),
+		partialSubIDs: make(map[string]bool),
+		subs:          make(map[string]*models.PfdSubscription),
+		breakers:      make(map[string]*pfdNotifyBreaker),
+		batches:       make(map[string]*pfdNotifyBatch),
+		queue:         make(chan *pfdNotifyJob, pfdNotifyQueueSize),
+		done:          make(chan struct{}),
+		retryDelay:    pfdNotifyRetryDelay,
 	}
 
-	config := PFDmanagement.NewConfiguration()
-	config.SetMetrics(sbi_metrics.SbiMetricHook)
-	config.SetHTTPClient(http.DefaultClient)
-	n.clientPfdManagement = PFDmanagement.NewAPIClient(config)
+	n.wg.Add(pfdNotifyWorkers)
+	for i := 0; i < pfdNotifyWorkers; i++ {
+		go n.runWorker()
+	}
+	return n, nil
 }
 
-func (n *PfdChangeNotifier) AddPfdSub(pfdSub *models.PfdSubscription) string {
-	n.initPfdManagementApiClient()
+// Stop stops the workers; the notifications not delivered yet are dropped
+func (n *PfdChangeNotifier) Stop() {
+	n.stopOnce.Do(func() {
+		close(n.done)
 
+		n.batchMu.Lock()
+		for subID, batch := range n.batches {
+			batch.timer.Stop()
+			delete(n.batches, subID)
+		}
+		n.batchMu.Unlock()
+	})
+	n.wg.Wait()
+}
+
+// SetReportHandler sets the handler of the PfdChangeReports returned by the
+// SMFs in the notification responses
+func (n *PfdChangeNotifier) SetReportHandler(handler func([]models.PfdChangeReport)) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	n.reportHandler = handler
+}
 
-	n.numPfdSubID++
-	subID := strconv.FormatUint(n.numPfdSubID, 10)
-	n.subIdToURI[subID] = pfdSub.NotifyUri
-	// TODO: If pfdSub.ApplicationIds is empty, it may means monitoring all appIDs
-	for _, appID := range pfdSub.ApplicationIds {
-		if _, exist := n.appIdToSubIDs[appID]; !exist {
-			n.appIdToSubIDs[appID] = make(map[string]bool)
+func (n *PfdChangeNotifier) getReportHandler() func([]models.PfdChangeReport) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.reportHandler
+}
+
+// LoadFromStore restores the PFD subscriptions kept in the state store, so the
+// SMFs keep being notified across restarts.
+func (n *PfdChangeNotifier) LoadFromStore() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	records, err := n.store.List(bucketPfdSubs)
+	if err != nil {
+		return fmt.Errorf("list stored PFD subscriptions: %w", err)
+	}
+	for subID, data := range records {
+		pfdSub := &models.PfdSubscription{}
+		if err = json.Unmarshal(data, pfdSub); err != nil {
+			return fmt.Errorf("invalid stored PFD subscription [%s]: %w", subID, err)
 		}
-		n.appIdToSubIDs[appID][subID] = true
+		n.indexSub(subID, pfdSub)
 	}
+	logger.PFDManageLog.Infof("Restored %d PFD subscriptions", len(records))
+	return nil
+}
+
+func (n *PfdChangeNotifier) AddPfdSub(pfdSub *models.PfdSubscription) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	subID := uuid.New().String()
+	sub := *pfdSub
+	n.indexSub(subID, &sub)
+	n.persistSub(subID)
 
 	return subID
 }
@@ -75,38 +182,400 @@ func (n *PfdChangeNotifier) DeletePfdSub(subID string) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	if _, exist := n.subIdToURI[subID]; !exist {
+	if _, exist := n.subs[subID]; !exist {
 		return errors.New("subscription not found")
 	}
-	delete(n.subIdToURI, subID)
-	for _, subIDs := range n.appIdToSubIDs {
-		delete(subIDs, subID)
+	n.unindexSub(subID)
+	delete(n.breakers, subID)
+	if err := n.store.Delete(bucketPfdSubs, subID); err != nil {
+		logger.PFDManageLog.Errorf("Delete stored PFD subscription[%s] failed: %+v", subID, err)
 	}
 	return nil
 }
 
+// GetPfdSub returns a copy of the subscription, with the features negotiated
+func (n *PfdChangeNotifier) GetPfdSub(subID string) (*models.PfdSubscription, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	pfdSub, exist := n.subs[subID]
+	if !exist {
+		return nil, errors.New("subscription not found")
+	}
+	return copyPfdSub(pfdSub), nil
+}
+
+// PatchPfdSub applies the present attributes of the patch to the subscription
+// and re-indexes it, so no PFD change is matched against a half-updated
+// application list. An empty applicationIds array monitors all the
+// applications, the supportedFeatures negotiated at creation are kept.
+func (n *PfdChangeNotifier) PatchPfdSub(
+	subID string, patch *models.PfdSubscription,
+) (*models.PfdSubscription, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	pfdSub, exist := n.subs[subID]
+	if !exist {
+		return nil, errors.New("subscription not found")
+	}
+	patched := copyPfdSub(pfdSub)
+	if patch.ApplicationIds != nil {
+		patched.ApplicationIds = append([]string(nil), patch.ApplicationIds...)
+	}
+	if patch.NotifyUri != "" && patch.NotifyUri != patched.NotifyUri {
+		patched.NotifyUri = patch.NotifyUri
+		// The failures of the former notify URI do not count anymore
+		delete(n.breakers, subID)
+	}
+
+	n.unindexSub(subID)
+	n.indexSub(subID, patched)
+	n.persistSub(subID)
+
+	return copyPfdSub(patched), nil
+}
+
+func copyPfdSub(pfdSub *models.PfdSubscription) *models.PfdSubscription {
+	c := *pfdSub
+	c.ApplicationIds = append([]string(nil), pfdSub.ApplicationIds...)
+	return &c
+}
+
+// indexSub must be called with n.mu held.
+func (n *PfdChangeNotifier) indexSub(subID string, pfdSub *models.PfdSubscription) {
+	n.subs[subID] = pfdSub
+	if len(pfdSub.ApplicationIds) == 0 {
+		// Absent applicationIds: the PFD changes of all the applications are
+		// notified (TS 29.551 clause 5.2.2.4.2)
+		n.allAppSubIDs[subID] = true
+	}
+	if feats, err := openapi.NewSupportedFeature(pfdSub.SupportedFeatures); err == nil &&
+		feats.GetFeature(pfdSuppFeatPartialUpdate) {
+		n.partialSubIDs[subID] = true
+	}
+	for _, appID := range pfdSub.ApplicationIds {
+		if _, exist := n.appIdToSubIDs[appID]; !exist {
+			n.appIdToSubIDs[appID] = make(map[string]bool)
+		}
+		n.appIdToSubIDs[appID][subID] = true
+	}
+}
+
+// unindexSub must be called with n.mu held.
+func (n *PfdChangeNotifier) unindexSub(subID string) {
+	delete(n.subs, subID)
+	delete(n.allAppSubIDs, subID)
+	delete(n.partialSubIDs, subID)
+	for appID, subIDs := range n.appIdToSubIDs {
+		delete(subIDs, subID)
+		if len(subIDs) == 0 {
+			delete(n.appIdToSubIDs, appID)
+		}
+	}
+}
+
+// persistSub must be called with n.mu held.
+func (n *PfdChangeNotifier) persistSub(subID string) {
+	data, err := json.Marshal(n.subs[subID])
+	if err != nil {
+		logger.PFDManageLog.Errorf("Marshal PFD subscription[%s] for store failed: %+v", subID, err)
+		return
+	}
+	if err = n.store.Put(bucketPfdSubs, subID, data); err != nil {
+		logger.PFDManageLog.Errorf("Persist PFD subscription[%s] failed: %+v", subID, err)
+	}
+}
+
 func (n *PfdChangeNotifier) getSubIDs(appID string) []string {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
-	subIDs := make([]string, 0, len(n.appIdToSubIDs[appID]))
+	subIDs := make([]string, 0, len(n.appIdToSubIDs[appID])+len(n.allAppSubIDs))
 	for subID := range n.appIdToSubIDs[appID] {
 		subIDs = append(subIDs, subID)
 	}
+	for subID := range n.allAppSubIDs {
+		if !n.appIdToSubIDs[appID][subID] {
+			subIDs = append(subIDs, subID)
+		}
+	}
 	return subIDs
 }
 
 func (n *PfdChangeNotifier) getSubURI(subID string) string {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
-	return n.subIdToURI[subID]
+	if sub, ok := n.subs[subID]; ok {
+		return sub.NotifyUri
+	}
+	return ""
+}
+
+func (n *PfdChangeNotifier) supportsPartialUpdate(subID string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.partialSubIDs[subID]
+}
+
+func (n *PfdChangeNotifier) setSubURI(subID, uri string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if sub, exist := n.subs[subID]; exist {
+		sub.NotifyUri = uri
+		n.persistSub(subID)
+	}
+}
+
+// breakerAllows tells whether the circuit of the subscription is closed, or
+// half-open after the cool-down
+func (n *PfdChangeNotifier) breakerAllows(subID string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	b, ok := n.breakers[subID]
+	return !ok || b.failures < pfdNotifyBreakerThreshold || !time.Now().Before(b.openUntil)
+}
+
+func (n *PfdChangeNotifier) recordDelivery(subID string, delivered bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, exist := n.subs[subID]; !exist {
+		return
+	}
+	if delivered {
+		delete(n.breakers, subID)
+		return
+	}
+	b, ok := n.breakers[subID]
+	if !ok {
+		b = &pfdNotifyBreaker{}
+		n.breakers[subID] = b
+	}
+	b.failures++
+	if b.failures >= pfdNotifyBreakerThreshold {
+		b.openUntil = time.Now().Add(pfdNotifyBreakerCooldown)
+		logger.PFDManageLog.Warnf("PFD subscription[%s] unreachable, suspend notifications for %s",
+			subID, pfdNotifyBreakerCooldown)
+	}
+}
+
+func (n *PfdChangeNotifier) enqueue(job *pfdNotifyJob) {
+	select {
+	case n.queue <- job:
+	default:
+		logger.PFDManageLog.Warnf("PFD notification queue is full, drop notification of subscription[%s]",
+			job.subID)
+		business.IncrNotificationCounter(business.NotifTypePfdChange, false)
+	}
+}
+
+// schedule holds the job back for the delay, together with the notifications
+// of the subscription already waiting, and queues them at the earliest deadline
+func (n *PfdChangeNotifier) schedule(job *pfdNotifyJob, delay time.Duration) {
+	n.batchMu.Lock()
+	defer n.batchMu.Unlock()
+
+	batch, ok := n.batches[job.subID]
+	if !ok && delay <= 0 {
+		n.enqueue(job)
+		return
+	}
+
+	deadline := time.Now().Add(delay)
+	if !ok {
+		subID := job.subID
+		newBatch := &pfdNotifyBatch{deadline: deadline}
+		newBatch.timer = time.AfterFunc(delay, func() {
+			n.flushBatch(subID, newBatch)
+		})
+		batch = newBatch
+		n.batches[job.subID] = batch
+	}
+	batch.merge(job.notifications)
+
+	switch {
+	case delay <= 0:
+		batch.timer.Stop()
+		delete(n.batches, job.subID)
+		n.enqueue(&pfdNotifyJob{subID: job.subID, notifications: batch.notifications})
+	case deadline.Before(batch.deadline):
+		// A timer which already fired is about to flush the batch anyway
+		if batch.timer.Stop() {
+			batch.deadline = deadline
+			batch.timer.Reset(delay)
+		}
+	}
+}
+
+// flushBatch queues the batch of the subscription unless it was queued
+// already, a later batch of the subscription is left to its own timer
+func (n *PfdChangeNotifier) flushBatch(subID string, batch *pfdNotifyBatch) {
+	n.batchMu.Lock()
+	ok := n.batches[subID] == batch
+	if ok {
+		delete(n.batches, subID)
+	}
+	n.batchMu.Unlock()
+
+	if ok {
+		n.enqueue(&pfdNotifyJob{subID: subID, notifications: batch.notifications})
+	}
+}
+
+// merge adds the notifications to the batch, where the PFDs of an application
+// replace the ones notified before unless they are a partial update
+func (b *pfdNotifyBatch) merge(notifications []models.PfdChangeNotification) {
+	for _, notif := range notifications {
+		if !notif.PartialFlag {
+			b.notifications = slices.DeleteFunc(b.notifications, func(old models.PfdChangeNotification) bool {
+				return old.ApplicationId == notif.ApplicationId
+			})
+		}
+		b.notifications = append(b.notifications, notif)
+	}
+}
+
+func (n *PfdChangeNotifier) runWorker() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case job := <-n.queue:
+			n.safeDeliver(job)
+		}
+	}
+}
+
+// safeDeliver delivers the job, a panic fails the delivery but keeps the
+// worker running
+func (n *PfdChangeNotifier) safeDeliver(job *pfdNotifyJob) {
+	defer func() {
+		if p := recover(); p != nil {
+			logger.PFDManageLog.Errorf("Notify PFD subscription[%s] panicked: %v\n%s",
+				job.subID, p, string(debug.Stack()))
+			n.recordDelivery(job.subID, false)
+			business.IncrNotificationCounter(business.NotifTypePfdChange, false)
+		}
+	}()
+
+	n.deliver(job)
+}
+
+// deliver sends the notifications of the job, retrying with an exponential
+// backoff while the failure is transient
+func (n *PfdChangeNotifier) deliver(job *pfdNotifyJob) {
+	if !n.breakerAllows(job.subID) {
+		logger.PFDManageLog.Debugf("Circuit of PFD subscription[%s] is open, drop notification", job.subID)
+		business.IncrNotificationCounter(business.NotifTypePfdChange, false)
+		return
+	}
+
+	reqBody, err := json.Marshal(job.notifications)
+	if err != nil {
+		logger.PFDManageLog.Errorf("Marshal PFD notification failed: %+v", err)
+		return
+	}
+
+	delay := n.retryDelay
+	for attempt := 0; ; attempt++ {
+		retry, errSend := n.send(job.subID, reqBody)
+		if errSend == nil {
+			n.recordDelivery(job.subID, true)
+			business.IncrNotificationCounter(business.NotifTypePfdChange, true)
+			return
+		}
+		if !retry || attempt >= pfdNotifyMaxRetries {
+			logger.PFDManageLog.Warnf("Notify PFD subscription[%s] failed: %+v", job.subID, errSend)
+			n.recordDelivery(job.subID, false)
+			business.IncrNotificationCounter(business.NotifTypePfdChange, false)
+			return
+		}
+
+		logger.PFDManageLog.Debugf("Notify PFD subscription[%s] failed, retry in %s: %+v",
+			job.subID, delay, errSend)
+		select {
+		case <-n.done:
+			business.IncrNotificationCounter(business.NotifTypePfdChange, false)
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// send posts the notifications to the subscription and tells whether a
+// failure is worth a retry. 3GPP TS 29.551 release 17 clause 5.2.2.5
+func (n *PfdChangeNotifier) send(subID string, reqBody []byte) (bool, error) {
+	uri := n.getSubURI(subID)
+	for redirects := 0; ; redirects++ {
+		if uri == "" {
+			return false, fmt.Errorf("subscription[%s] not found", subID)
+		}
+
+		rspBody, header, status, err := postRaw(n.client, uri, reqBody)
+		switch {
+		case status == 0, status == http.StatusTooManyRequests, status >= http.StatusInternalServerError:
+			return true, err
+		case status == http.StatusTemporaryRedirect, status == http.StatusPermanentRedirect:
+			location, errLoc := resolveLocation(uri, header.Get("Location"))
+			if errLoc != nil || redirects >= pfdNotifyMaxRedirects {
+				return false, err
+			}
+			if status == http.StatusPermanentRedirect {
+				n.setSubURI(subID, location)
+			}
+			uri = location
+			continue
+		case status == http.StatusNotFound:
+			// The subscription is gone on the consumer side
+			logger.PFDManageLog.Infof("Notify URI of PFD subscription[%s] not found, remove it", subID)
+			_ = n.DeletePfdSub(subID)
+			return false, err
+		case err != nil:
+			return false, err
+		case status == http.StatusOK:
+			var reports []models.PfdChangeReport
+			if err = json.Unmarshal(rspBody, &reports); err != nil {
+				logger.PFDManageLog.Warnf("Decode PfdChangeReport of subscription[%s] failed: %+v", subID, err)
+			}
+			for _, report := range reports {
+				logger.PFDManageLog.Warnf("PFD subscription[%s] failed to apply PFDs of %v: %+v",
+					subID, report.ApplicationId, report.PfdError)
+			}
+			if handler := n.getReportHandler(); handler != nil && len(reports) > 0 {
+				handler(reports)
+			}
+		}
+		return false, nil
+	}
+}
+
+func resolveLocation(uri, location string) (string, error) {
+	if location == "" {
+		return "", errors.New("no Location header in redirect")
+	}
+	base, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
 }
 
 func (n *PfdChangeNotifier) NewPfdNotifyContext() *PfdNotifyContext {
 	return &PfdNotifyContext{
-		notifier:             n,
-		appIdToNotification:  make(map[string]models.PfdChangeNotification),
-		subIdToChangedAppIDs: make(map[string][]string),
+		notifier:                   n,
+		appIdToNotification:        make(map[string]models.PfdChangeNotification),
+		appIdToPartialNotification: make(map[string][]models.PfdChangeNotification),
+		appIdToAllowedDelay:        make(map[string]time.Duration),
+		subIdToChangedAppIDs:       make(map[string][]string),
 	}
 }
 
@@ -117,31 +586,44 @@ func (nc *PfdNotifyContext) AddNotification(appID string, notif *models.PfdChang
 	}
 }
 
+// AddPartialNotification adds the PFD change of the application together with
+// its partial form, which is notified to the subscriptions supporting partial
+// update instead. The partial form takes one notification for the PFDs added
+// or updated and another, with removalFlag, for the PFDs removed.
+func (nc *PfdNotifyContext) AddPartialNotification(
+	appID string, notif *models.PfdChangeNotification, partialNotifs []models.PfdChangeNotification,
+) {
+	nc.appIdToPartialNotification[appID] = partialNotifs
+	nc.AddNotification(appID, notif)
+}
+
+// SetAllowedDelay lets the notification of the application wait up to the
+// allowedDelay in seconds, to be sent with the other changes in the meantime
+func (nc *PfdNotifyContext) SetAllowedDelay(appID string, allowedDelay int32) {
+	nc.appIdToAllowedDelay[appID] = time.Duration(allowedDelay) * time.Second
+}
+
+// FlushNotifications queues the notifications of each subscription for
+// delivery in the background, within the shortest allowedDelay of them
 func (nc *PfdNotifyContext) FlushNotifications() {
 	for subID, appIDs := range nc.subIdToChangedAppIDs {
+		partial := nc.notifier.supportsPartialUpdate(subID)
 		pfdChangeNotifications := make([]models.PfdChangeNotification, 0, len(appIDs))
+		delay := time.Duration(-1)
 		for _, appID := range appIDs {
+			if appDelay := nc.appIdToAllowedDelay[appID]; delay < 0 || appDelay < delay {
+				delay = appDelay
+			}
+			if partialNotifs, ok := nc.appIdToPartialNotification[appID]; partial && ok {
+				pfdChangeNotifications = append(pfdChangeNotifications, partialNotifs...)
+				continue
+			}
 			pfdChangeNotifications = append(pfdChangeNotifications, nc.appIdToNotification[appID])
 		}
 
-		go func(id string) {
-			defer func() {
-				if p := recover(); p != nil {
-					// Print stack for panic to log. Fatalf() will let program exit.
-					logger.PFDManageLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
-				}
-			}()
-
-			notifyReq := &PFDmanagement.NnefPFDmanagementNotifyRequest{
-				PfdChangeNotification: pfdChangeNotifications,
-			}
-
-			_, err := nc.notifier.clientPfdManagement.PFDSubscriptionsApi.NnefPFDmanagementNotify(
-				context.TODO(), nc.notifier.getSubURI(id), notifyReq)
-			if err != nil {
-				logger.PFDManageLog.Fatal(err)
-			}
-		}(subID)
-		// TODO: Handle the response of notification properly
+		nc.notifier.schedule(&pfdNotifyJob{
+			subID:         subID,
+			notifications: pfdChangeNotifications,
+		}, delay)
 	}
 }
@@ -0,0 +1,330 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/internal/store"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPfdChangeNotifierDelivery(t *testing.T) {
+	n, err := NewPfdChangeNotifier(store.NewMemoryStore())
+	require.NoError(t, err)
+	defer n.Stop()
+	n.retryDelay = time.Millisecond
+
+	var unavailable, delivered atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/notify")
+		w.WriteHeader(http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/notify", func(w http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	notifs := []models.PfdChangeNotification{{ApplicationId: "app1", RemovalFlag: true}}
+
+	testCases := []struct {
+		description string
+		path        string
+		expectedURI string
+		verify      func(t *testing.T)
+	}{
+		{
+			description: "TC1: Retry while the SMF is unavailable",
+			path:        "/unavailable",
+			expectedURI: srv.URL + "/unavailable",
+			verify: func(t *testing.T) {
+				require.Equal(t, int32(3), unavailable.Load())
+			},
+		},
+		{
+			description: "TC2: Permanent redirect updates the notify URI",
+			path:        "/moved",
+			expectedURI: srv.URL + "/notify",
+			verify: func(t *testing.T) {
+				require.Equal(t, int32(1), delivered.Load())
+			},
+		},
+		{
+			description: "TC3: Subscription removed when the notify URI is not found",
+			path:        "/gone",
+			expectedURI: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			subID := n.AddPfdSub(&models.PfdSubscription{
+				ApplicationIds: []string{"app1"},
+				NotifyUri:      srv.URL + tc.path,
+			})
+			defer func() {
+				_ = n.DeletePfdSub(subID)
+			}()
+
+			n.deliver(&pfdNotifyJob{subID: subID, notifications: notifs})
+			require.Equal(t, tc.expectedURI, n.getSubURI(subID))
+			if tc.verify != nil {
+				tc.verify(t)
+			}
+		})
+	}
+}
+
+func TestPfdChangeNotifierBreaker(t *testing.T) {
+	n, err := NewPfdChangeNotifier(store.NewMemoryStore())
+	require.NoError(t, err)
+	defer n.Stop()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	subID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      srv.URL,
+	})
+	job := &pfdNotifyJob{subID: subID, notifications: []models.PfdChangeNotification{{ApplicationId: "app1"}}}
+	for i := 0; i < pfdNotifyBreakerThreshold+2; i++ {
+		n.deliver(job)
+	}
+	require.Equal(t, int32(pfdNotifyBreakerThreshold), requests.Load())
+	require.False(t, n.breakerAllows(subID))
+
+	require.NoError(t, n.DeletePfdSub(subID))
+	require.True(t, n.breakerAllows(subID))
+}
+
+func TestPfdChangeNotifierWorkerPanic(t *testing.T) {
+	n, err := NewPfdChangeNotifier(store.NewMemoryStore())
+	require.NoError(t, err)
+	defer n.Stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"applicationId":["app1"],"pfdError":{"cause":"SYSTEM_FAILURE"}}]`))
+	}))
+	defer srv.Close()
+
+	var reports atomic.Int32
+	n.SetReportHandler(func([]models.PfdChangeReport) {
+		if reports.Add(1) == 1 {
+			panic("report handler failed")
+		}
+	})
+
+	subID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      srv.URL,
+	})
+	job := &pfdNotifyJob{subID: subID, notifications: []models.PfdChangeNotification{{ApplicationId: "app1"}}}
+	n.enqueue(job)
+	n.enqueue(job)
+
+	// The panic fails its delivery only, the next notification is delivered
+	require.Eventually(t, func() bool {
+		return reports.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestPfdChangeNotifierAllApps(t *testing.T) {
+	n, err := NewPfdChangeNotifier(store.NewMemoryStore())
+	require.NoError(t, err)
+	defer n.Stop()
+
+	appSubID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      "http://smf1/notify",
+	})
+	allSubID := n.AddPfdSub(&models.PfdSubscription{
+		NotifyUri: "http://smf2/notify",
+	})
+
+	require.ElementsMatch(t, []string{appSubID, allSubID}, n.getSubIDs("app1"))
+	require.ElementsMatch(t, []string{allSubID}, n.getSubIDs("app2"))
+
+	nc := n.NewPfdNotifyContext()
+	nc.AddNotification("app2", &models.PfdChangeNotification{ApplicationId: "app2", RemovalFlag: true})
+	require.Equal(t, map[string][]string{allSubID: {"app2"}}, nc.subIdToChangedAppIDs)
+
+	require.NoError(t, n.DeletePfdSub(allSubID))
+	require.Empty(t, n.getSubIDs("app2"))
+	require.NoError(t, n.DeletePfdSub(appSubID))
+}
+
+func TestPfdNotifyContextPartialUpdate(t *testing.T) {
+	n, err := NewPfdChangeNotifier(store.NewMemoryStore())
+	require.NoError(t, err)
+	// Keep the jobs in the queue
+	n.Stop()
+
+	fullSubID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      "http://smf1/notify",
+	})
+	partialSubID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds:    []string{"app1"},
+		NotifyUri:         "http://smf2/notify",
+		SupportedFeatures: "1",
+	})
+
+	notif := models.PfdChangeNotification{
+		ApplicationId: "app1",
+		Pfds:          []models.PfdContent{{PfdId: "pfd1", Urls: []string{"^http://test.example.com(/\\S*)?$"}}},
+	}
+	partialNotifs := []models.PfdChangeNotification{
+		{
+			ApplicationId: "app1",
+			PartialFlag:   true,
+			Pfds:          []models.PfdContent{{PfdId: "pfd1", Urls: []string{"^http://test.example.com(/\\S*)?$"}}},
+		},
+		{
+			ApplicationId: "app1",
+			PartialFlag:   true,
+			RemovalFlag:   true,
+			Pfds:          []models.PfdContent{{PfdId: "pfd2"}},
+		},
+	}
+	nc := n.NewPfdNotifyContext()
+	nc.AddPartialNotification("app1", &notif, partialNotifs)
+	nc.FlushNotifications()
+
+	jobs := map[string][]models.PfdChangeNotification{}
+	for i := 0; i < 2; i++ {
+		job := <-n.queue
+		jobs[job.subID] = job.notifications
+	}
+	require.Equal(t, map[string][]models.PfdChangeNotification{
+		fullSubID:    {notif},
+		partialSubID: partialNotifs,
+	}, jobs)
+}
+
+func TestPfdChangeNotifierAllowedDelay(t *testing.T) {
+	n, err := NewPfdChangeNotifier(store.NewMemoryStore())
+	require.NoError(t, err)
+	// Keep the jobs in the queue
+	n.Stop()
+
+	subID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1", "app2"},
+		NotifyUri:      "http://smf1/notify",
+	})
+
+	notif1 := models.PfdChangeNotification{ApplicationId: "app1", Pfds: []models.PfdContent{{PfdId: "pfd1"}}}
+	notif2 := models.PfdChangeNotification{ApplicationId: "app2", RemovalFlag: true}
+	notif1Update := models.PfdChangeNotification{ApplicationId: "app1", Pfds: []models.PfdContent{{PfdId: "pfd2"}}}
+
+	nc := n.NewPfdNotifyContext()
+	nc.AddNotification("app1", &notif1)
+	nc.SetAllowedDelay("app1", 60)
+	nc.FlushNotifications()
+	require.Empty(t, n.queue)
+
+	nc = n.NewPfdNotifyContext()
+	nc.AddNotification("app2", &notif2)
+	nc.SetAllowedDelay("app2", 60)
+	nc.AddNotification("app1", &notif1Update)
+	nc.SetAllowedDelay("app1", 60)
+	nc.FlushNotifications()
+	require.Empty(t, n.queue)
+
+	// A timer firing for a batch already queued leaves the current batch alone
+	n.flushBatch(subID, &pfdNotifyBatch{})
+	require.Empty(t, n.queue)
+	require.Len(t, n.batches, 1)
+
+	// The changes held back are sent together with the ones without delay
+	nc = n.NewPfdNotifyContext()
+	nc.AddNotification("app2", &notif2)
+	nc.FlushNotifications()
+	job := <-n.queue
+	require.Equal(t, subID, job.subID)
+	require.ElementsMatch(t, []models.PfdChangeNotification{notif1Update, notif2}, job.notifications)
+	require.Empty(t, n.batches)
+}
+
+func TestPfdChangeNotifierLoadFromStore(t *testing.T) {
+	st := store.NewMemoryStore()
+	n, err := NewPfdChangeNotifier(st)
+	require.NoError(t, err)
+	n.Stop()
+
+	appSubID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds:    []string{"app1"},
+		NotifyUri:         "http://smf1/notify",
+		SupportedFeatures: "1",
+	})
+	allSubID := n.AddPfdSub(&models.PfdSubscription{
+		NotifyUri: "http://smf2/notify",
+	})
+	removedSubID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      "http://smf3/notify",
+	})
+	require.NotEqual(t, appSubID, allSubID)
+	require.NoError(t, n.DeletePfdSub(removedSubID))
+	n.setSubURI(appSubID, "http://smf1/moved")
+
+	restored, err := NewPfdChangeNotifier(st)
+	require.NoError(t, err)
+	defer restored.Stop()
+	require.NoError(t, restored.LoadFromStore())
+
+	require.ElementsMatch(t, []string{appSubID, allSubID}, restored.getSubIDs("app1"))
+	require.ElementsMatch(t, []string{allSubID}, restored.getSubIDs("app2"))
+	require.Equal(t, "http://smf1/moved", restored.getSubURI(appSubID))
+	require.True(t, restored.partialSubIDs[appSubID])
+}
+
+func TestPfdChangeNotifierPatchPfdSub(t *testing.T) {
+	n, err := NewPfdChangeNotifier(store.NewMemoryStore())
+	require.NoError(t, err)
+	n.Stop()
+
+	subID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds:    []string{"app1", "app2"},
+		NotifyUri:         "http://smf1/notify",
+		SupportedFeatures: "1",
+	})
+
+	patched, err := n.PatchPfdSub(subID, &models.PfdSubscription{ApplicationIds: []string{"app2", "app3"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"app2", "app3"}, patched.ApplicationIds)
+	require.Equal(t, "1", patched.SupportedFeatures)
+	require.Empty(t, n.getSubIDs("app1"))
+	require.Equal(t, []string{subID}, n.getSubIDs("app3"))
+	require.True(t, n.supportsPartialUpdate(subID))
+
+	// An empty application list monitors all the applications
+	_, err = n.PatchPfdSub(subID, &models.PfdSubscription{ApplicationIds: []string{}})
+	require.NoError(t, err)
+	require.Equal(t, []string{subID}, n.getSubIDs("app1"))
+	require.Empty(t, n.appIdToSubIDs)
+
+	_, err = n.PatchPfdSub("unknown", &models.PfdSubscription{})
+	require.Error(t, err)
+}
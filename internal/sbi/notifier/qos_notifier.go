@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	"github.com/free5gc/openapi/models"
+)
+
+const qosNotifyTimeout = 5 * time.Second
+
+// QosNotifier delivers the user plane events of an AS session with QoS to the
+// AF (TS 29.122 clause 5.14.3.3).
+type QosNotifier struct {
+	client *http.Client
+}
+
+func NewQosNotifier() (*QosNotifier, error) {
+	return &QosNotifier{
+		client: &http.Client{Timeout: qosNotifyTimeout},
+	}, nil
+}
+
+// NotifyAf posts the UserPlaneNotificationData to the AF notification destination.
+func (n *QosNotifier) NotifyAf(uri string, notif *models.UserPlaneNotificationData) error {
+	_, _, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeAsSessionQos, err == nil)
+	return err
+}
@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/metrics/business"
+	"github.com/free5gc/openapi/models"
+)
+
+const upPathChgNotifyTimeout = 5 * time.Second
+
+// UpPathChgNotifier delivers UP path change events to the AF
+// (TS 29.522 clause 5.4.3.3.2) and relays the AF acknowledgement back to
+// the SMF (TS 29.508 clause 4.2.2.2).
+type UpPathChgNotifier struct {
+	client *http.Client
+}
+
+func NewUpPathChgNotifier() (*UpPathChgNotifier, error) {
+	return &UpPathChgNotifier{
+		client: &http.Client{Timeout: upPathChgNotifyTimeout},
+	}, nil
+}
+
+// NotifyAf posts the EventNotification to the AF notification destination.
+// An AfAckInfo is returned when the AF acknowledged synchronously in a
+// 200 response; a 204 response returns nil.
+func (n *UpPathChgNotifier) NotifyAf(
+	uri string,
+	notif *models.EventNotification,
+) (*models.AfAckInfo, error) {
+	rspBody, status, err := postJSON(n.client, uri, notif)
+	business.IncrNotificationCounter(business.NotifTypeUpPathChange, err == nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || len(rspBody) == 0 {
+		return nil, nil
+	}
+
+	ackInfo := &models.AfAckInfo{}
+	if err = json.Unmarshal(rspBody, ackInfo); err != nil {
+		return nil, fmt.Errorf("decode AfAckInfo: %w", err)
+	}
+	return ackInfo, nil
+}
+
+// AckSmf relays the AF acknowledgement to the ackUri given by the SMF.
+func (n *UpPathChgNotifier) AckSmf(uri string, ack *models.AckOfNotify) error {
+	_, _, err := postJSON(n.client, uri, ack)
+	business.IncrNotificationCounter(business.NotifTypeSmfAck, err == nil)
+	return err
+}
@@ -0,0 +1,600 @@
+package processor
+
+import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/nwdaf/AnalyticsInfo"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+// anaExpoNwdafEvents are the analytics events exposed to the AFs and the
+// NWDAF events serving them
+var anaExpoNwdafEvents = map[nef_models.AnalyticsEvent]models.NwdafEvent{
+	nef_models.AnalyticsEvent_UE_MOBILITY:         models.NwdafEvent_UE_MOBILITY,
+	nef_models.AnalyticsEvent_UE_COMM:             models.NwdafEvent_UE_COMMUNICATION,
+	nef_models.AnalyticsEvent_ABNORMAL_BEHAVIOR:   models.NwdafEvent_ABNORMAL_BEHAVIOUR,
+	nef_models.AnalyticsEvent_NETWORK_PERFORMANCE: models.NwdafEvent_NETWORK_PERFORMANCE,
+	nef_models.AnalyticsEvent_QOS_SUSTAINABILITY:  models.NwdafEvent_QOS_SUSTAINABILITY,
+}
+
+// GetAnalyticsExposureSubscriptions Read all analytics exposure subscriptions for a given AF
+// 3GPP TS 29.522 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.2.3.1
+func (p *Processor) GetAnalyticsExposureSubscriptions(
+	c *gin.Context,
+	afID string,
+) {
+	logger.AnaExpoLog.Infof("GetAnalyticsExposureSubscriptions - afID[%s]", afID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var anaSubs []nef_models.AnalyticsExposureSubsc
+	for _, sub := range af.AnaSubs {
+		if sub.AnaSub == nil {
+			continue
+		}
+		anaSubs = append(anaSubs, *sub.AnaSub)
+	}
+	c.JSON(http.StatusOK, &anaSubs)
+}
+
+// PostAnalyticsExposureSubscription Create a new analytics exposure subscription
+// 3GPP TS 29.522 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.2.3.2
+func (p *Processor) PostAnalyticsExposureSubscription(
+	c *gin.Context,
+	afID string,
+	anaSub *nef_models.AnalyticsExposureSubsc,
+) {
+	logger.AnaExpoLog.Infof("PostAnalyticsExposureSubscription - afID[%s]", afID)
+
+	problemDetails := validateAnalyticsExposureSubsc(anaSub)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(afID)
+	if af == nil {
+		af = nefCtx.NewAf(afID)
+		if af == nil {
+			pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	correID := nefCtx.NewCorreID()
+	afSub := af.NewAnaSub(correID, anaSub)
+	if afSub == nil {
+		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	nwdafSub, pd := p.convertAnalyticsExposureSubscToNwdafEventsSubscription(afID, afSub)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	nwdafSubID, created, pd, err := p.Consumer().CreateNwdafEventsSubscription(nwdafSub)
+	switch {
+	case pd != nil:
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	case err != nil:
+		pd = openapi.ProblemDetailsSystemFailure("Query to NWDAF failed")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	afSub.NwdafSubID = nwdafSubID
+	anaSub.EventNotifs = convertNwdafEventNotifications(created.EventNotifications)
+
+	af.AnaSubs[afSub.SubID] = afSub
+	af.Log.Infoln("Analytics exposure subscription is added")
+
+	nefCtx.AddAf(af)
+
+	c.Header("Location", p.genAnaExpoSubURI(afID, afSub.SubID))
+	c.JSON(http.StatusCreated, anaSub)
+}
+
+// GetIndividualAnalyticsExposureSubscription Read an analytics exposure subscription
+// 3GPP TS 29.522 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.3.3.1
+func (p *Processor) GetIndividualAnalyticsExposureSubscription(
+	c *gin.Context,
+	afID, subID string,
+) {
+	logger.AnaExpoLog.Infof("GetIndividualAnalyticsExposureSubscription - afID[%s], subID[%s]", afID, subID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	sub, ok := af.AnaSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	c.JSON(http.StatusOK, sub.AnaSub)
+}
+
+// PutIndividualAnalyticsExposureSubscription Replace an analytics exposure subscription
+// 3GPP TS 29.522 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.3.3.2
+func (p *Processor) PutIndividualAnalyticsExposureSubscription(
+	c *gin.Context,
+	afID, subID string,
+	anaSub *nef_models.AnalyticsExposureSubsc,
+) {
+	logger.AnaExpoLog.Infof("PutIndividualAnalyticsExposureSubscription - afID[%s], subID[%s]", afID, subID)
+
+	problemDetails := validateAnalyticsExposureSubsc(anaSub)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub, ok := af.AnaSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	// The NWDAF subscription is modified in place, the old parameters are kept
+	// if the modification is rejected
+	newSub := &context.AfAnaSubscription{
+		SubID:        sub.SubID,
+		AnaSub:       anaSub,
+		NotifCorreID: sub.NotifCorreID,
+		NwdafSubID:   sub.NwdafSubID,
+		Log:          sub.Log,
+	}
+	nwdafSub, pd := p.convertAnalyticsExposureSubscToNwdafEventsSubscription(afID, newSub)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	updated, pd, err := p.Consumer().UpdateNwdafEventsSubscription(sub.NwdafSubID, nwdafSub)
+	switch {
+	case pd != nil:
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	case err != nil:
+		pd = openapi.ProblemDetailsSystemFailure("Query to NWDAF failed")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	anaSub.EventNotifs = convertNwdafEventNotifications(updated.EventNotifications)
+
+	af.AnaSubs[subID] = newSub
+	af.Persist()
+
+	c.JSON(http.StatusOK, anaSub)
+}
+
+// DeleteIndividualAnalyticsExposureSubscription Delete an analytics exposure subscription
+// 3GPP TS 29.522 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.3.3.3
+func (p *Processor) DeleteIndividualAnalyticsExposureSubscription(
+	c *gin.Context,
+	afID, subID string,
+) {
+	logger.AnaExpoLog.Infof("DeleteIndividualAnalyticsExposureSubscription - afID[%s], subID[%s]", afID, subID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub, ok := af.AnaSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	pd, err := p.Consumer().DeleteNwdafEventsSubscription(sub.NwdafSubID)
+	switch {
+	case pd != nil:
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	case err != nil:
+		pd = openapi.ProblemDetailsSystemFailure("Query to NWDAF failed")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	af.DeleteAnaSub(subID)
+	c.Status(http.StatusNoContent)
+}
+
+// FetchAnalytics Fetch the analytics information available at the NWDAF
+// 3GPP TS 29.522 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.4.2.3
+func (p *Processor) FetchAnalytics(
+	c *gin.Context,
+	afID string,
+	anaReq *nef_models.AnalyticsRequest,
+) {
+	logger.AnaExpoLog.Infof("FetchAnalytics - afID[%s]", afID)
+
+	nwdafEvent, pd := validateAnalyticsEvent(anaReq.AnalyEvent, anaReq.TgtUe)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	eventID := models.EventId(nwdafEvent)
+	param := &AnalyticsInfo.GetNWDAFAnalyticsRequest{
+		EventId: &eventID,
+		AnaReq:  anaReq.AnalyRep,
+	}
+	if anaReq.SuppFeat != "" {
+		param.SupportedFeatures = &anaReq.SuppFeat
+	}
+	if anaReq.TgtUe != nil {
+		if param.TgtUe, pd = p.convertTargetUeId(afID, anaReq.TgtUe); pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+	if filter := anaReq.AnalyEventFilter; filter != nil {
+		if param.EventFilter, pd = convertAnalyticsEventFilter(filter); pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+
+	nwdafData, pd, err := p.Consumer().GetNwdafAnalytics(param)
+	switch {
+	case pd != nil:
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	case err != nil:
+		pd = openapi.ProblemDetailsSystemFailure("Query to NWDAF failed")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	c.JSON(http.StatusOK, &nef_models.AnalyticsData{
+		Start:           nwdafData.Start,
+		Expiry:          nwdafData.Expiry,
+		TimeStamp:       nwdafData.TimeStampGen,
+		UeMobilityInfos: nwdafData.UeMobs,
+		UeCommInfos:     nwdafData.UeComms,
+		AbnormalInfos:   removeAbnormalBehaviourSupis(nwdafData.AbnorBehavrs),
+		NwPerfInfos:     nwdafData.NwPerfs,
+		QosSustainInfos: nwdafData.QosSustainInfos,
+		SuppFeat:        nwdafData.SuppFeat,
+	})
+}
+
+// NwdafEventNotification relays the Nnwdaf_EventsSubscription notifications to the AF.
+// 3GPP TS 29.520 release 17 version 17.6.0
+// Resource structure: {notificationURI}
+// Request: array(NnwdafEventsSubscriptionNotification), Response: 204
+func (p *Processor) NwdafEventNotification(
+	c *gin.Context,
+	corrID string,
+	nwdafNotifs []models.NnwdafEventsSubscriptionNotification,
+) {
+	logger.AnaExpoLog.Infof("NwdafEventNotification - corrID[%s]", corrID)
+
+	af, sub := p.Context().FindAfAnaSub(corrID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	// The subscription may have been replaced or deleted meanwhile
+	af.Mu.RLock()
+	sub, ok := af.AnaSubs[sub.SubID]
+	if !ok || sub.AnaSub == nil {
+		af.Mu.RUnlock()
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	notifID, notifUri := sub.AnaSub.NotifId, sub.AnaSub.NotifUri
+	af.Mu.RUnlock()
+
+	var eventNotifs []nef_models.AnalyticsEventNotif
+	for i := range nwdafNotifs {
+		eventNotifs = append(eventNotifs, convertNwdafEventNotifications(nwdafNotifs[i].EventNotifications)...)
+	}
+	if len(eventNotifs) == 0 {
+		sub.Log.Debugln("No analytics event notification to relay")
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	notif := &nef_models.AnalyticsEventNotification{
+		NotifId:          notifID,
+		AnalyEventNotifs: eventNotifs,
+	}
+	if err := p.Notifier().AnaExpoNotifier.NotifyAf(notifUri, notif); err != nil {
+		sub.Log.Errorf("Failed to notify AF of analytics events: %+v", err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func validateAnalyticsExposureSubsc(anaSub *nef_models.AnalyticsExposureSubsc) *models.ProblemDetails {
+	if anaSub.NotifUri == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notifUri")
+	}
+	if anaSub.NotifId == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notifId")
+	}
+	if len(anaSub.AnalyEventsSubs) == 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing analyEventsSubs")
+	}
+	for _, eventSub := range anaSub.AnalyEventsSubs {
+		if _, pd := validateAnalyticsEvent(eventSub.AnalyEvent, eventSub.TgtUe); pd != nil {
+			return pd
+		}
+	}
+	return nil
+}
+
+// validateAnalyticsEvent returns the NWDAF event serving the analytics event.
+// The UE related analytics need the target UE.
+func validateAnalyticsEvent(
+	event nef_models.AnalyticsEvent,
+	tgtUe *nef_models.TargetUeId,
+) (models.NwdafEvent, *models.ProblemDetails) {
+	nwdafEvent, ok := anaExpoNwdafEvents[event]
+	switch {
+	case event == "":
+		return "", openapi.ProblemDetailsMalformedReqSyntax("Missing analyEvent")
+	case !ok:
+		return "", openapi.ProblemDetailsMalformedReqSyntax("Unsupported analyEvent: " + string(event))
+	}
+
+	if event == nef_models.AnalyticsEvent_UE_MOBILITY || event == nef_models.AnalyticsEvent_UE_COMM {
+		if tgtUe == nil || (!tgtUe.AnyUeInd && tgtUe.Gpsi == "" && tgtUe.ExterGroupId == "") {
+			return "", openapi.ProblemDetailsMalformedReqSyntax("Missing tgtUe of " + string(event))
+		}
+	}
+	return nwdafEvent, nil
+}
+
+func (p *Processor) genAnaExpoSubURI(
+	afID, subscriptionId string,
+) string {
+	// E.g. https://localhost:29505/3gpp-analyticsexposure/v1/{afId}/subscriptions/{subscriptionId}
+	return p.Config().ServiceUri(factory.ServiceAnaExpo) + "/" + afID + "/subscriptions/" + subscriptionId
+}
+
+func (p *Processor) genNwdafNotificationUri(notifCorreID string) string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/nwdaf/" + notifCorreID
+}
+
+func (p *Processor) convertAnalyticsExposureSubscToNwdafEventsSubscription(
+	afID string,
+	sub *context.AfAnaSubscription,
+) (*models.NnwdafEventsSubscription, *models.ProblemDetails) {
+	nwdafSub := &models.NnwdafEventsSubscription{
+		EvtReq:          sub.AnaSub.AnalyRepInfo,
+		NotificationURI: p.genNwdafNotificationUri(sub.NotifCorreID),
+		NotifCorrId:     sub.NotifCorreID,
+	}
+
+	for _, eventSub := range sub.AnaSub.AnalyEventsSubs {
+		nwdafEventSub := models.NwdafEventsSubscriptionEventSubscription{
+			Event: anaExpoNwdafEvents[eventSub.AnalyEvent],
+		}
+		if eventSub.TgtUe != nil {
+			tgtUe, pd := p.convertTargetUeId(afID, eventSub.TgtUe)
+			if pd != nil {
+				return nil, pd
+			}
+			nwdafEventSub.TgtUe = tgtUe
+		}
+
+		if filter := eventSub.AnalyEventFilter; filter != nil {
+			networkArea, pd := convertLocationArea(filter.LocArea)
+			if pd != nil {
+				return nil, pd
+			}
+			nwdafEventSub.NetworkArea = networkArea
+			nwdafEventSub.AppIds = filter.AppIds
+			if filter.Dnn != "" {
+				nwdafEventSub.Dnns = []string{filter.Dnn}
+			}
+			if filter.Snssai != nil {
+				nwdafEventSub.Snssaia = []models.Snssai{*filter.Snssai}
+			}
+			nwdafEventSub.NwPerfRequs = filter.NwPerfReqs
+			nwdafEventSub.ExcepRequs = filter.ExcepRequs
+			nwdafEventSub.ExptAnaType = filter.ExptAnaType
+			nwdafEventSub.ExptUeBehav = filter.ExptUeBehav
+			nwdafEventSub.QosRequ = filter.QosReq
+			nwdafEventSub.QosFlowRetThds = filter.QosFlowRetThds
+			nwdafEventSub.RanUeThrouThds = filter.RanUeThrouThds
+		}
+		nwdafSub.EventSubscriptions = append(nwdafSub.EventSubscriptions, nwdafEventSub)
+	}
+	return nwdafSub, nil
+}
+
+// convertTargetUeId translates the target UE of the AF into the NWDAF target
+// UE information, the external group ID is resolved to the internal one.
+func (p *Processor) convertTargetUeId(
+	afID string,
+	tgtUe *nef_models.TargetUeId,
+) (*models.TargetUeInformation, *models.ProblemDetails) {
+	switch {
+	case tgtUe.AnyUeInd:
+		return &models.TargetUeInformation{AnyUe: true}, nil
+	case tgtUe.Gpsi != "":
+		return &models.TargetUeInformation{Gpsis: []string{tgtUe.Gpsi}}, nil
+	case tgtUe.ExterGroupId != "":
+		interGroupId, pd := p.resolveInterGroupId(afID, tgtUe.ExterGroupId)
+		if pd != nil {
+			return nil, pd
+		}
+		return &models.TargetUeInformation{IntGroupIds: []string{interGroupId}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func convertAnalyticsEventFilter(
+	filter *nef_models.AnalyticsEventFilter,
+) (*models.NwdafAnalyticsInfoEventFilter, *models.ProblemDetails) {
+	networkArea, pd := convertLocationArea(filter.LocArea)
+	if pd != nil {
+		return nil, pd
+	}
+	eventFilter := &models.NwdafAnalyticsInfoEventFilter{
+		NetworkArea: networkArea,
+		AppIds:      filter.AppIds,
+		NwPerfTypes: filter.NwPerfTypes,
+		QosRequ:     filter.QosReq,
+		ExcepIds:    filter.ExcepIds,
+		ExptAnaType: filter.ExptAnaType,
+		ExptUeBehav: filter.ExptUeBehav,
+	}
+	if filter.Dnn != "" {
+		eventFilter.Dnns = []string{filter.Dnn}
+	}
+	if filter.Snssai != nil {
+		eventFilter.Snssais = []models.Snssai{*filter.Snssai}
+	}
+	return eventFilter, nil
+}
+
+// convertLocationArea returns the network area of the location area, the
+// geographic areas and civic addresses cannot be mapped by the NEF.
+func convertLocationArea(locArea *models.LocationArea5G) (*models.NetworkAreaInfo, *models.ProblemDetails) {
+	switch {
+	case locArea == nil:
+		return nil, nil
+	case locArea.NwAreaInfo == nil:
+		return nil, openapi.ProblemDetailsMalformedReqSyntax("Only the nwAreaInfo of locArea is supported")
+	default:
+		return locArea.NwAreaInfo, nil
+	}
+}
+
+func convertNwdafEventNotifications(
+	nwdafNotifs []models.NwdafEventsSubscriptionEventNotification,
+) []nef_models.AnalyticsEventNotif {
+	var eventNotifs []nef_models.AnalyticsEventNotif
+	for i := range nwdafNotifs {
+		nwdafNotif := &nwdafNotifs[i]
+		event, ok := analyticsEventOfNwdafEvent(nwdafNotif.Event)
+		if !ok {
+			continue
+		}
+		eventNotifs = append(eventNotifs, nef_models.AnalyticsEventNotif{
+			AnalyEvent:      event,
+			Expiry:          nwdafNotif.Expiry,
+			TimeStamp:       nwdafNotif.TimeStampGen,
+			UeMobilityInfos: nwdafNotif.UeMobs,
+			UeCommInfos:     nwdafNotif.UeComms,
+			AbnormalInfos:   removeAbnormalBehaviourSupis(nwdafNotif.AbnorBehavrs),
+			NwPerfInfos:     nwdafNotif.NwPerfs,
+			QosSustainInfos: nwdafNotif.QosSustainInfos,
+		})
+	}
+	return eventNotifs
+}
+
+func analyticsEventOfNwdafEvent(nwdafEvent models.NwdafEvent) (nef_models.AnalyticsEvent, bool) {
+	for event, e := range anaExpoNwdafEvents {
+		if e == nwdafEvent {
+			return event, true
+		}
+	}
+	return "", false
+}
+
+// removeAbnormalBehaviourSupis removes the SUPIs, which are not exposed
+// outside of the 5GC, from the abnormal behaviour analytics.
+func removeAbnormalBehaviourSupis(infos []models.AbnormalBehaviour) []models.AbnormalBehaviour {
+	var exposed []models.AbnormalBehaviour
+	for _, info := range infos {
+		info.Supis = nil
+		exposed = append(exposed, info)
+	}
+	return exposed
+}
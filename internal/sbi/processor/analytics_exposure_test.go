@@ -0,0 +1,217 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestAnalyticsExposureSubscription(t *testing.T) {
+	initNRFDiscStub(models.NrfNfManagementNfType_NWDAF, models.ServiceName_NNWDAF_EVENTSSUBSCRIPTION,
+		"127.0.0.23", "http://127.0.0.23:8000")
+
+	anaSub := nef_models.AnalyticsExposureSubsc{
+		AnalyEventsSubs: []nef_models.AnalyticsEventSubsc{
+			{
+				AnalyEvent: nef_models.AnalyticsEvent_UE_MOBILITY,
+				TgtUe:      &nef_models.TargetUeId{Gpsi: "msisdn-0900000000"},
+			},
+		},
+		NotifUri: "http://127.0.0.104:8000/analytics/notify",
+		NotifId:  "ana-notif1",
+	}
+	anaSubInvalid := anaSub
+	anaSubInvalid.AnalyEventsSubs = []nef_models.AnalyticsEventSubsc{
+		{AnalyEvent: nef_models.AnalyticsEvent_UE_MOBILITY},
+	}
+
+	nwdafSubMock := gock.New("http://127.0.0.23:8000/nnwdaf-eventssubscription/v1").
+		Post("/subscriptions").
+		BodyString(`"event":"UE_MOBILITY".*"gpsis":\["msisdn-0900000000"\]`+
+			`.*"notificationURI":".*/nnef-callback/v1/notification/nwdaf/.*"`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.23:8000/nnwdaf-eventssubscription/v1/subscriptions/nwdaf1").
+		JSON(models.NnwdafEventsSubscription{})
+
+	testCases := []struct {
+		description    string
+		anaSub         *nef_models.AnalyticsExposureSubsc
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: UE mobility without the target UE",
+			anaSub:         &anaSubInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC2: UE mobility of a GPSI, subscribed at NWDAF",
+			anaSub:         &anaSub,
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	var location string
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			sub := *tc.anaSub
+			nefApp.Processor().PostAnalyticsExposureSubscription(c, "af4", &sub)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			if httpRecorder.Code == http.StatusCreated {
+				location = httpRecorder.Header().Get("Location")
+			}
+		})
+	}
+	require.True(t, nwdafSubMock.Done())
+
+	af := nefApp.Context().GetAf("af4")
+	require.NotNil(t, af)
+	require.Len(t, af.AnaSubs, 1)
+	afSub, ok := af.AnaSubs["1"]
+	require.True(t, ok)
+	require.Equal(t, "nwdaf1", afSub.NwdafSubID)
+	require.Equal(t, nefApp.Config().ServiceUri(factory.ServiceAnaExpo)+"/af4/subscriptions/1", location)
+
+	// The analytics of the NWDAF are relayed to the AF without the SUPIs
+	afMock := gock.New("http://127.0.0.104:8000").
+		Post("/analytics/notify").
+		BodyString(`"notifId":"ana-notif1".*"analyEvent":"ABNORMAL_BEHAVIOR".*"excepId":"UNEXPECTED_UE_LOCATION"`).
+		Reply(http.StatusNoContent)
+
+	now := time.Now()
+	nwdafNotifs := []models.NnwdafEventsSubscriptionNotification{
+		{
+			SubscriptionId: "nwdaf1",
+			NotifCorrId:    afSub.NotifCorreID,
+			EventNotifications: []models.NwdafEventsSubscriptionEventNotification{
+				{
+					Event:        models.NwdafEvent_ABNORMAL_BEHAVIOUR,
+					TimeStampGen: &now,
+					AbnorBehavrs: []models.AbnormalBehaviour{
+						{
+							Supis: []string{"imsi-208930000000001"},
+							Excep: &models.Exception{ExcepId: models.ExceptionId_UNEXPECTED_UE_LOCATION},
+						},
+					},
+				},
+			},
+		},
+	}
+	eventNotifs := convertNwdafEventNotifications(nwdafNotifs[0].EventNotifications)
+	require.Len(t, eventNotifs, 1)
+	require.Nil(t, eventNotifs[0].AbnormalInfos[0].Supis)
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().NwdafEventNotification(c, afSub.NotifCorreID, nwdafNotifs)
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, afMock.Done())
+
+	// The replacement modifies the NWDAF subscription in place
+	nwdafUpdateMock := gock.New("http://127.0.0.23:8000/nnwdaf-eventssubscription/v1").
+		Put("/subscriptions/nwdaf1").
+		BodyString(`"event":"UE_COMMUNICATION".*"anyUe":true`).
+		Reply(http.StatusOK).
+		JSON(models.NnwdafEventsSubscription{})
+
+	replaced := anaSub
+	replaced.AnalyEventsSubs = []nef_models.AnalyticsEventSubsc{
+		{
+			AnalyEvent: nef_models.AnalyticsEvent_UE_COMM,
+			TgtUe:      &nef_models.TargetUeId{AnyUeInd: true},
+		},
+	}
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PutIndividualAnalyticsExposureSubscription(c, "af4", "1", &replaced)
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.True(t, nwdafUpdateMock.Done())
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().GetIndividualAnalyticsExposureSubscription(c, "af4", "1")
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	assertJSONBodyEqual(t, &replaced, httpRecorder.Body.Bytes())
+
+	nwdafDeleteMock := gock.New("http://127.0.0.23:8000/nnwdaf-eventssubscription/v1").
+		Delete("/subscriptions/nwdaf1").
+		Reply(http.StatusNoContent)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().DeleteIndividualAnalyticsExposureSubscription(c, "af4", "1")
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, nwdafDeleteMock.Done())
+	require.Empty(t, af.AnaSubs)
+}
+
+func TestFetchAnalytics(t *testing.T) {
+	initNRFDiscStub(models.NrfNfManagementNfType_NWDAF, models.ServiceName_NNWDAF_ANALYTICSINFO,
+		"127.0.0.23", "http://127.0.0.23:8000")
+
+	nwdafMock := gock.New("http://127.0.0.23:8000/nnwdaf-analyticsinfo/v1").
+		Get("/analytics").
+		MatchParam("event-id", "NETWORK_PERFORMANCE").
+		Reply(http.StatusOK).
+		JSON(models.NwdafAnalyticsInfoAnalyticsData{
+			NwPerfs: []models.NetworkPerfInfo{
+				{NwPerfType: models.NetworkPerfType_NUM_OF_UE, AbsoluteNum: 10},
+			},
+		})
+
+	testCases := []struct {
+		description    string
+		anaReq         *nef_models.AnalyticsRequest
+		expectedStatus int
+		expectedData   *nef_models.AnalyticsData
+	}{
+		{
+			description: "TC1: Service experience is not exposed",
+			anaReq: &nef_models.AnalyticsRequest{
+				AnalyEvent: "SERVICE_EXPERIENCE",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description: "TC2: Network performance fetched from NWDAF",
+			anaReq: &nef_models.AnalyticsRequest{
+				AnalyEvent: nef_models.AnalyticsEvent_NETWORK_PERFORMANCE,
+				AnalyEventFilter: &nef_models.AnalyticsEventFilter{
+					NwPerfTypes: []models.NetworkPerfType{models.NetworkPerfType_NUM_OF_UE},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedData: &nef_models.AnalyticsData{
+				NwPerfInfos: []models.NetworkPerfInfo{
+					{NwPerfType: models.NetworkPerfType_NUM_OF_UE, AbsoluteNum: 10},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Processor().FetchAnalytics(c, "af4", tc.anaReq)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			if tc.expectedData != nil {
+				assertJSONBodyEqual(t, tc.expectedData, httpRecorder.Body.Bytes())
+			}
+		})
+	}
+	require.True(t, nwdafMock.Done())
+}
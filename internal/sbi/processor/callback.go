@@ -1,11 +1,7 @@
 package processor
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
@@ -29,22 +25,134 @@ func (p *Processor) SmfNotification(
 		return
 	}
 
+	// The AF and the SMF are contacted without holding the AF lock, a slow AF
+	// must not block the other requests on it
 	af.Mu.RLock()
-	defer af.Mu.RUnlock()
+	notifDest := sub.TiSub.NotificationDestination
+	afAckInd := sub.TiSub.AfAckInd
+	var notifs []*models.EventNotification
+	for i := range eeNotif.EventNotifs {
+		smfEvent := &eeNotif.EventNotifs[i]
+		if smfEvent.Event != models.SmfEvent_UP_PATH_CH {
+			sub.Log.Debugf("Ignore SMF event[%s]", smfEvent.Event)
+			continue
+		}
+		notifs = append(notifs, p.convertSmfEventToEventNotification(sub, smfEvent))
+	}
+	af.Mu.RUnlock()
+
+	upPathChgNotifier := p.Notifier().UpPathChgNotifier
+	for _, notif := range notifs {
+		ackInfo, err := upPathChgNotifier.NotifyAf(notifDest, notif)
+		if err != nil {
+			sub.Log.Errorf("Failed to notify AF of UP path change: %+v", err)
+			continue
+		}
 
-	// TODO: Notify AF
+		if !afAckInd || eeNotif.AckUri == "" {
+			continue
+		}
+		if ackInfo == nil {
+			// The AF will acknowledge later via the AfAckUri
+			af.Mu.Lock()
+			sub.PendingAckUri = eeNotif.AckUri
+			af.Persist()
+			af.Mu.Unlock()
+			continue
+		}
+		p.ackSmfNotification(sub, eeNotif.AckUri, ackInfo)
+	}
 
-	c.JSON(http.StatusOK, nil)
+	c.Status(http.StatusNoContent)
 }
-<<<<<<< Updated upstream
-=======
 
-func (p *Processor) AsSessionQosNotification(
+// AfAckNotification relays the AF acknowledgement of an UP path change
+// notification to the SMF.
+// 3GPP TS 29.522 Traffic Influence API release 17 version 17.6.0
+// Resource structure: {afAckUri}
+// Request: AfAckInfo, Response: 204
+func (p *Processor) AfAckNotification(
+	c *gin.Context,
+	notifID string,
+	ackInfo *models.AfAckInfo,
+) {
+	logger.TrafInfluLog.Infof("AfAckNotification - NotifId[%s]", notifID)
+
+	af, sub := p.Context().FindAfSub(notifID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	ackUri := sub.PendingAckUri
+	if ackUri == "" {
+		af.Mu.Unlock()
+		pd := openapi.ProblemDetailsDataNotFound("No pending UP path change notification")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	sub.PendingAckUri = ""
+	af.Persist()
+	af.Mu.Unlock()
+
+	p.ackSmfNotification(sub, ackUri, ackInfo)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (p *Processor) ackSmfNotification(
+	sub *context.AfSubscription,
+	ackUri string,
+	ackInfo *models.AfAckInfo,
+) {
+	ack := &models.AckOfNotify{
+		NotifId:   sub.NotifCorreID,
+		AckResult: ackInfo.AckResult,
+		Gpsi:      ackInfo.Gpsi,
+	}
+	if err := p.Notifier().UpPathChgNotifier.AckSmf(ackUri, ack); err != nil {
+		sub.Log.Errorf("Failed to relay AF acknowledgement to SMF: %+v", err)
+	}
+}
+
+func (p *Processor) convertSmfEventToEventNotification(
+	sub *context.AfSubscription,
+	smfEvent *models.SmfEventExposureEventNotification,
+) *models.EventNotification {
+	notif := &models.EventNotification{
+		AfTransId:          sub.TiSub.AfTransId,
+		SubscribedEvent:    models.SubscribedEvent_UP_PATH_CHANGE,
+		DnaiChgType:        smfEvent.DnaiChgType,
+		SourceDnai:         smfEvent.SourceDnai,
+		TargetDnai:         smfEvent.TargetDnai,
+		SourceTrafficRoute: smfEvent.SourceTraRouting,
+		TargetTrafficRoute: smfEvent.TargetTraRouting,
+		Gpsi:               smfEvent.Gpsi,
+		SrcUeIpv4Addr:      smfEvent.SourceUeIpv4Addr,
+		SrcUeIpv6Prefix:    smfEvent.SourceUeIpv6Prefix,
+		TgtUeIpv4Addr:      smfEvent.TargetUeIpv4Addr,
+		TgtUeIpv6Prefix:    smfEvent.TargetUeIpv6Prefix,
+		UeMac:              smfEvent.UeMac,
+	}
+	if notif.Gpsi == "" {
+		notif.Gpsi = sub.TiSub.Gpsi
+	}
+	if sub.TiSub.AfAckInd {
+		notif.AfAckUri = p.genAfAckUri(sub.NotifCorreID)
+	}
+	return notif
+}
+
+func (p *Processor) AsSessionQosEventNotification(
 	c *gin.Context,
 	corrID string,
-	ascUpdate *models.AppSessionContextUpdateData,
+	evsNotif *models.PcfPolicyAuthorizationEventsNotification,
 ) {
-	logger.TrafInfluLog.Infof("AsSessionQosNotification - CorrID[%s]", corrID)
+	logger.TrafInfluLog.Infof("AsSessionQosEventNotification - CorrID[%s]", corrID)
 
 	af, sub := p.Context().FindAfQosSubscriptionByCorrID(corrID)
 	if sub == nil {
@@ -54,56 +162,219 @@ func (p *Processor) AsSessionQosNotification(
 		return
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.Mu.RLock()
+	notifUri := sub.QosSub.NotificationDestination
+	af.Mu.RUnlock()
 
-	if ascUpdate != nil {
-		sub.LastUpdate = ascUpdate
+	notif := &models.UserPlaneNotificationData{
+		Transaction:  p.genAsSessionQosURI(af.AfID, sub.SubscriptionID),
+		EventReports: convertPcfEventsToUserPlaneEventReports(evsNotif),
 	}
-
-	if err := p.forwardAsSessionQosNotification(sub, corrID, ascUpdate); err != nil {
-		logger.TrafInfluLog.Warnf("Failed to forward QoS notification to AF: %v", err)
+	if len(notif.EventReports) == 0 {
+		sub.Log.Debugf("No QoS event to relay to AF")
+	} else if err := p.Notifier().QosNotifier.NotifyAf(notifUri, notif); err != nil {
+		sub.Log.Warnf("Failed to relay QoS notification to AF: %+v", err)
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
-func (p *Processor) forwardAsSessionQosNotification(
-	sub *context.AfQosSubscription,
+func (p *Processor) AsSessionQosTermination(
+	c *gin.Context,
 	corrID string,
-	ascUpdate *models.AppSessionContextUpdateData,
-) error {
-	if sub == nil || sub.Payload == nil || sub.Payload.AscReqData == nil {
-		return fmt.Errorf("subscription or request data missing for forwarding")
+	termInfo *models.TerminationInfo,
+) {
+	logger.TrafInfluLog.Infof("AsSessionQosTermination - CorrID[%s], Cause[%s]", corrID, termInfo.TermCause)
+
+	af, sub := p.Context().FindAfQosSubscriptionByCorrID(corrID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("QoS subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	notifUri, pcfUri, appSessID := sub.QosSub.NotificationDestination, sub.PcfUri, sub.AppSessID
+	af.Mu.RUnlock()
+
+	notif := &models.UserPlaneNotificationData{
+		Transaction: p.genAsSessionQosURI(af.AfID, sub.SubscriptionID),
+		EventReports: []models.UserPlaneEventReport{
+			{Event: models.UserPlaneEvent_SESSION_TERMINATION},
+		},
+	}
+	if err := p.Notifier().QosNotifier.NotifyAf(notifUri, notif); err != nil {
+		sub.Log.Warnf("Failed to relay QoS session termination to AF: %+v", err)
+	}
+
+	// TS 29.514 clause 4.2.5.2, the app session is removed on request of the PCF
+	if _, pd, err := p.Consumer().DeleteAppSession(pcfUri, appSessID); pd != nil {
+		sub.Log.Warnf("Delete app session failed: %s", pd.Detail)
+	} else if err != nil {
+		sub.Log.Warnf("Delete app session failed: %+v", err)
 	}
 
-	dest := sub.Payload.AscReqData.NotifUri
-	if dest == "" {
-		return fmt.Errorf("notification URI missing in ascReqData")
+	af.Mu.Lock()
+	// The AF may have deleted the subscription meanwhile
+	if af.QosSubs[sub.SubscriptionID] == sub {
+		af.DeleteQosSubscription(sub.SubscriptionID)
 	}
+	af.Mu.Unlock()
+
+	c.Status(http.StatusNoContent)
+}
 
-	body, err := json.Marshal(ascUpdate)
-	if err != nil {
-		return fmt.Errorf("marshal notification: %w", err)
+// convertPcfEventsToUserPlaneEventReports maps the Npcf_PolicyAuthorization events
+// to the user plane events of TS 29.122 clause 5.14.2.1.5, events without a
+// counterpart are dropped.
+func convertPcfEventsToUserPlaneEventReports(
+	evsNotif *models.PcfPolicyAuthorizationEventsNotification,
+) []models.UserPlaneEventReport {
+	var reports []models.UserPlaneEventReport
+	for _, evNotif := range evsNotif.EvNotifs {
+		switch evNotif.Event {
+		case models.PcfPolicyAuthorizationAfEvent_QOS_NOTIF:
+			for _, qnc := range evsNotif.QncReports {
+				report := models.UserPlaneEventReport{
+					Event:   models.UserPlaneEvent_QOS_GUARANTEED,
+					FlowIds: flowIdsOf(qnc.Flows),
+				}
+				if qnc.NotifType == models.QosNotifType_NOT_GUARANTEED {
+					report.Event = models.UserPlaneEvent_QOS_NOT_GUARANTEED
+				}
+				reports = append(reports, report)
+			}
+		case models.PcfPolicyAuthorizationAfEvent_USAGE_REPORT:
+			reports = append(reports, models.UserPlaneEventReport{
+				Event:            models.UserPlaneEvent_USAGE_REPORT,
+				AccumulatedUsage: evsNotif.UsgRep,
+			})
+		case models.PcfPolicyAuthorizationAfEvent_FAILED_RESOURCES_ALLOCATION:
+			reports = append(reports, models.UserPlaneEventReport{
+				Event:   models.UserPlaneEvent_FAILED_RESOURCES_ALLOCATION,
+				FlowIds: flowIdsOf(evNotif.Flows),
+			})
+		case models.PcfPolicyAuthorizationAfEvent_SUCCESSFUL_RESOURCES_ALLOCATION:
+			reports = append(reports, models.UserPlaneEventReport{
+				Event:   models.UserPlaneEvent_SUCCESSFUL_RESOURCES_ALLOCATION,
+				FlowIds: flowIdsOf(evNotif.Flows),
+			})
+		case models.PcfPolicyAuthorizationAfEvent_QOS_MONITORING:
+			for _, qosMonRep := range evsNotif.QosMonReports {
+				reports = append(reports, models.UserPlaneEventReport{
+					Event:   models.UserPlaneEvent_QOS_MONITORING,
+					FlowIds: flowIdsOf(qosMonRep.Flows),
+					QosMonReports: []models.QosMonitoringReport{
+						{
+							UlDelays: qosMonRep.UlDelays,
+							DlDelays: qosMonRep.DlDelays,
+							RtDelays: qosMonRep.RtDelays,
+							Pdmf:     qosMonRep.Pdmf,
+						},
+					},
+				})
+			}
+		case models.PcfPolicyAuthorizationAfEvent_ACCESS_TYPE_CHANGE:
+			reports = append(reports, models.UserPlaneEventReport{
+				Event:   models.UserPlaneEvent_ACCESS_TYPE_CHANGE,
+				RatType: evsNotif.RatType,
+			})
+		case models.PcfPolicyAuthorizationAfEvent_PLMN_CHG:
+			reports = append(reports, models.UserPlaneEventReport{
+				Event:  models.UserPlaneEvent_PLMN_CHG,
+				PlmnId: evsNotif.PlmnId,
+			})
+		default:
+			logger.TrafInfluLog.Debugf("Ignore PCF event[%s]", evNotif.Event)
+		}
 	}
+	return reports
+}
 
-	req, err := http.NewRequest(http.MethodPost, dest, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+func flowIdsOf(flows []models.Flows) []int32 {
+	var flowIds []int32
+	for _, flow := range flows {
+		flowIds = append(flowIds, flow.FNums...)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Correlation-Id", corrID)
+	return flowIds
+}
+
+// PfdChangeReports relays the PFDs an SMF reports it failed to deploy to the
+// AFs which provisioned them
+func (p *Processor) PfdChangeReports(c *gin.Context, reports []models.PfdChangeReport) {
+	logger.PFDManageLog.Infof("PfdChangeReports - %d report(s)", len(reports))
+
+	p.RelayPfdChangeReports(reports)
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("call AF notification endpoint: %w", err)
+	c.Status(http.StatusNoContent)
+}
+
+// RelayPfdChangeReports notifies the PfdReports to the notificationDestination
+// of the PFD transactions of the applications
+// 3GPP TS 29.122 release 17
+// Request/Response: 5.11.3.5.3.1
+func (p *Processor) RelayPfdChangeReports(reports []models.PfdChangeReport) {
+	type pfdTransKey struct {
+		afID    string
+		transID string
+	}
+	transReports := make(map[pfdTransKey]map[models.FailureCode][]string)
+	for _, report := range reports {
+		failureCode := convertPfdErrorToFailureCode(report.PfdError)
+		for _, appID := range report.ApplicationId {
+			afID, transID, ok := p.Context().IsAppIDExisted(appID)
+			if !ok {
+				logger.PFDManageLog.Debugf("appID[%s] is not provisioned by an AF, skip its report", appID)
+				continue
+			}
+			key := pfdTransKey{afID: afID, transID: transID}
+			if _, ok = transReports[key]; !ok {
+				transReports[key] = make(map[models.FailureCode][]string)
+			}
+			transReports[key][failureCode] = append(transReports[key][failureCode], appID)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("AF notification endpoint returned %d", resp.StatusCode)
+	for key, failedAppIDs := range transReports {
+		af := p.Context().GetAf(key.afID)
+		if af == nil {
+			continue
+		}
+		af.Mu.RLock()
+		var notifUri string
+		if afPfdTr, ok := af.PfdTrans[key.transID]; ok {
+			notifUri = afPfdTr.NotificationDestination
+		}
+		af.Mu.RUnlock()
+		if notifUri == "" {
+			logger.PFDManageLog.Infof("No notificationDestination in PFD transaction[%s] of AF[%s], drop the reports",
+				key.transID, key.afID)
+			continue
+		}
+
+		pfdReports := make([]models.PfdReport, 0, len(failedAppIDs))
+		for failureCode, appIDs := range failedAppIDs {
+			pfdReports = append(pfdReports, models.PfdReport{
+				ExternalAppIds: appIDs,
+				FailureCode:    failureCode,
+			})
+		}
+		if err := p.Notifier().PfdMngNotifier.NotifyAf(notifUri, pfdReports); err != nil {
+			logger.PFDManageLog.Warnf("Failed to notify AF[%s] of PFD reports: %+v", key.afID, err)
+		}
+	}
+}
+
+func convertPfdErrorToFailureCode(pfdError *models.ProblemDetails) models.FailureCode {
+	switch {
+	case pfdError == nil:
+		return models.FailureCode_OTHER_REASON
+	case pfdError.Cause == "INSUFFICIENT_RESOURCES":
+		return models.FailureCode_RESOURCE_LIMITATION
+	case pfdError.Status >= http.StatusInternalServerError:
+		return models.FailureCode_MALFUNCTION
+	default:
+		return models.FailureCode_OTHER_REASON
 	}
-	return nil
 }
->>>>>>> Stashed changes
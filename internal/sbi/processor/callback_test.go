@@ -0,0 +1,183 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestSmfNotification(t *testing.T) {
+	// Keep the NRF stubs registered in TestMain, only the mocks set up
+	// here are checked.
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	tiSubNoAck := tiSub1ForAf1
+	tiSubNoAck.NotificationDestination = "http://127.0.0.100:8000/af/notify"
+	tiSubNoAck.DnaiChgType = models.DnaiChangeType_EARLY_LATE
+	afSub1 := af1.NewSub(nefCtx.NewCorreID(), &tiSubNoAck)
+	af1.Subs[afSub1.SubID] = afSub1
+
+	tiSubAck := tiSubNoAck
+	tiSubAck.AfAckInd = true
+	afSub2 := af1.NewSub(nefCtx.NewCorreID(), &tiSubAck)
+	af1.Subs[afSub2.SubID] = afSub2
+	nefCtx.AddAf(af1)
+	af1.Mu.Unlock()
+
+	upPathChg := models.SmfEventExposureEventNotification{
+		Event:       models.SmfEvent_UP_PATH_CH,
+		DnaiChgType: models.DnaiChangeType_EARLY_LATE,
+		SourceDnai:  "mec1",
+		TargetDnai:  "mec2",
+	}
+	ackResult := &models.AfResultInfo{
+		AfStatus: models.AfResultStatus_SUCCESS,
+	}
+
+	testCases := []struct {
+		description     string
+		notifID         string
+		initStubs       func() []gock.Mock
+		expectedStatus  int
+		expectedPending string
+	}{
+		{
+			description: "TC1: Forward UP path change without AF acknowledgement",
+			notifID:     afSub1.NotifCorreID,
+			initStubs: func() []gock.Mock {
+				afMock := gock.New("http://127.0.0.100:8000").
+					Post("/af/notify").
+					MatchType("json").
+					JSON(models.EventNotification{
+						SubscribedEvent: models.SubscribedEvent_UP_PATH_CHANGE,
+						DnaiChgType:     models.DnaiChangeType_EARLY_LATE,
+						SourceDnai:      "mec1",
+						TargetDnai:      "mec2",
+					}).
+					Reply(http.StatusNoContent).Mock
+				return []gock.Mock{afMock}
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description: "TC2: Relay synchronous AF acknowledgement to SMF",
+			notifID:     afSub2.NotifCorreID,
+			initStubs: func() []gock.Mock {
+				afMock := gock.New("http://127.0.0.100:8000").
+					Post("/af/notify").
+					Reply(http.StatusOK).
+					JSON(models.AfAckInfo{AckResult: ackResult}).Mock
+				smfMock := gock.New("http://127.0.0.2:8000").
+					Post("/smf/ack").
+					MatchType("json").
+					JSON(models.AckOfNotify{NotifId: afSub2.NotifCorreID, AckResult: ackResult}).
+					Reply(http.StatusNoContent).Mock
+				return []gock.Mock{afMock, smfMock}
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description: "TC3: Keep SMF ackUri until the AF acknowledges",
+			notifID:     afSub2.NotifCorreID,
+			initStubs: func() []gock.Mock {
+				afMock := gock.New("http://127.0.0.100:8000").
+					Post("/af/notify").
+					Reply(http.StatusNoContent).Mock
+				return []gock.Mock{afMock}
+			},
+			expectedStatus:  http.StatusNoContent,
+			expectedPending: "http://127.0.0.2:8000/smf/ack",
+		},
+		{
+			description:     "TC4: Unknown notification ID",
+			notifID:         strconv.Itoa(100),
+			initStubs:       func() []gock.Mock { return nil },
+			expectedStatus:  http.StatusNotFound,
+			expectedPending: "http://127.0.0.2:8000/smf/ack",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			mocks := tc.initStubs()
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Processor().SmfNotification(c, &models.NsmfEventExposureNotification{
+				NotifId:     tc.notifID,
+				EventNotifs: []models.SmfEventExposureEventNotification{upPathChg},
+				AckUri:      "http://127.0.0.2:8000/smf/ack",
+			})
+			c.Writer.WriteHeaderNow()
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			for _, mock := range mocks {
+				require.True(t, mock.Done())
+			}
+			require.Equal(t, tc.expectedPending, afSub2.PendingAckUri)
+		})
+	}
+
+	// Asynchronous acknowledgement of TC3
+	smfMock := gock.New("http://127.0.0.2:8000").
+		Post("/smf/ack").
+		MatchType("json").
+		JSON(models.AckOfNotify{NotifId: afSub2.NotifCorreID, AckResult: ackResult}).
+		Reply(http.StatusNoContent).Mock
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().AfAckNotification(c, afSub2.NotifCorreID, &models.AfAckInfo{AckResult: ackResult})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, smfMock.Done())
+	require.Empty(t, afSub2.PendingAckUri)
+
+	nefCtx.DeleteAf(af1.AfID)
+	nefCtx.ResetCorreID()
+}
+
+func TestPfdChangeReports(t *testing.T) {
+	nefCtx := nefApp.Context()
+	af := nefCtx.NewAf("af1")
+	af.Mu.Lock()
+	afPfdTr := af.NewPfdTrans()
+	afPfdTr.NotificationDestination = "http://127.0.0.100:8000/pfd/notify"
+	afPfdTr.AddExtAppID("app1")
+	afPfdTr.AddExtAppID("app2")
+	af.PfdTrans[afPfdTr.TransID] = afPfdTr
+	nefCtx.AddAf(af)
+	af.Mu.Unlock()
+	defer nefCtx.DeleteAf(af.AfID)
+
+	afMock := gock.New("http://127.0.0.100:8000").
+		Post("/pfd/notify").
+		MatchType("json").
+		JSON([]models.PfdReport{
+			{
+				ExternalAppIds: []string{"app1", "app2"},
+				FailureCode:    models.FailureCode_RESOURCE_LIMITATION,
+			},
+		}).
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PfdChangeReports(c, []models.PfdChangeReport{
+		{
+			ApplicationId: []string{"app1", "app2", "app3"},
+			PfdError: &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Cause:  "INSUFFICIENT_RESOURCES",
+			},
+		},
+	})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, afMock.Done())
+}
@@ -0,0 +1,174 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+)
+
+const (
+	capifApiVersion           = "v1"
+	capifPublishMaxRetryDelay = 5 * time.Minute
+)
+
+// capifApiDescriptions are the northbound APIs the NEF may publish to CAPIF
+var capifApiDescriptions = map[string]string{
+	factory.ServiceTraffInflu:   "3GPP TS 29.522 TrafficInfluence API",
+	factory.ServicePfdMng:       "3GPP TS 29.122 PfdManagement API",
+	factory.ServiceAsSessionQos: "3GPP TS 29.122 AsSessionWithQoS API",
+	factory.ServiceMonEvt:       "3GPP TS 29.122 MonitoringEvent API",
+	factory.ServiceAnaExpo:      "3GPP TS 29.522 AnalyticsExposure API",
+	factory.ServiceDevTrig:      "3GPP TS 29.122 DeviceTriggering API",
+	factory.ServiceNidd:         "3GPP TS 29.122 NIDD API",
+}
+
+// PublishServiceApis publishes the northbound APIs of the service list to the
+// CAPIF core function. The APIs published before by the APF are updated, and
+// unpublished when they are no longer in the service list.
+func (p *Processor) PublishServiceApis() error {
+	published, pd, err := p.Consumer().GetServiceApis()
+	switch {
+	case pd != nil:
+		return fmt.Errorf("get published service APIs: %s", pd.Detail)
+	case err != nil:
+		return fmt.Errorf("get published service APIs: %w", err)
+	}
+
+	publishedApiIds := make(map[string]string)
+	for _, desc := range published {
+		publishedApiIds[desc.ApiName] = desc.ApiId
+	}
+
+	var apiNames []string
+	for _, srv := range p.Config().ServiceList() {
+		if _, ok := capifApiDescriptions[srv.ServiceName]; !ok {
+			continue
+		}
+		apiNames = append(apiNames, srv.ServiceName)
+
+		desc := p.genServiceApiDescription(srv)
+		var rsp *nef_models.ServiceApiDescription
+		if apiId, ok := publishedApiIds[srv.ServiceName]; ok {
+			rsp, pd, err = p.Consumer().UpdateServiceApi(apiId, desc)
+		} else {
+			rsp, pd, err = p.Consumer().PublishServiceApi(desc)
+		}
+		switch {
+		case pd != nil:
+			return fmt.Errorf("publish %s: %s", srv.ServiceName, pd.Detail)
+		case err != nil:
+			return fmt.Errorf("publish %s: %w", srv.ServiceName, err)
+		}
+		p.Context().SetCapifApiId(srv.ServiceName, rsp.ApiId)
+	}
+
+	for apiName, apiId := range publishedApiIds {
+		if slices.Contains(apiNames, apiName) {
+			continue
+		}
+		pd, err = p.Consumer().UnpublishServiceApi(apiId)
+		switch {
+		case pd != nil:
+			logger.CapifLog.Warnf("Unpublish %s[%s] failed: %s", apiName, apiId, pd.Detail)
+		case err != nil:
+			logger.CapifLog.Warnf("Unpublish %s[%s] failed: %+v", apiName, apiId, err)
+		}
+	}
+	return nil
+}
+
+// PublishServiceApisWithRetry publishes the northbound APIs to the CAPIF core
+// function, retrying with a delay doubled on each failure until it succeeds or
+// ctx is done. The service list is only read at start, the NEF has no
+// configuration reload: a changed service list is published on the next start.
+func (p *Processor) PublishServiceApisWithRetry(ctx context.Context, retryDelay time.Duration) error {
+	for {
+		err := p.PublishServiceApis()
+		if err == nil {
+			return nil
+		}
+		logger.CapifLog.Warnf("Publish service APIs failed, retry in %s: %+v", retryDelay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay):
+		}
+		retryDelay = min(2*retryDelay, capifPublishMaxRetryDelay)
+	}
+}
+
+// UnpublishServiceApis withdraws the APIs published to the CAPIF core function
+func (p *Processor) UnpublishServiceApis() {
+	for apiName, apiId := range p.Context().CapifApiIds() {
+		pd, err := p.Consumer().UnpublishServiceApi(apiId)
+		switch {
+		case pd != nil:
+			logger.CapifLog.Warnf("Unpublish %s[%s] failed: %s", apiName, apiId, pd.Detail)
+		case err != nil:
+			logger.CapifLog.Warnf("Unpublish %s[%s] failed: %+v", apiName, apiId, err)
+		default:
+			logger.CapifLog.Infof("Unpublish %s[%s]", apiName, apiId)
+		}
+		p.Context().DeleteCapifApiId(apiName)
+	}
+}
+
+// LogApiInvocation reports an invocation of a published API to the CAPIF core
+// function
+func (p *Processor) LogApiInvocation(apiName, apiInvokerId string, invocation *nef_models.CapifLog) {
+	apiId := p.Context().CapifApiId(apiName)
+	if apiId == "" {
+		logger.CapifLog.Debugf("%s is not published, skip the invocation log", apiName)
+		return
+	}
+	invocation.ApiName = apiName
+	invocation.ApiId = apiId
+	invocation.ApiVersion = capifApiVersion
+
+	pd, err := p.Consumer().LogApiInvocations(&nef_models.InvocationLog{
+		AefId:        p.Config().CapifAefId(),
+		ApiInvokerId: apiInvokerId,
+		Logs:         []nef_models.CapifLog{*invocation},
+	})
+	switch {
+	case pd != nil:
+		logger.CapifLog.Warnf("Log invocation of %s failed: %s", apiName, pd.Detail)
+	case err != nil:
+		logger.CapifLog.Warnf("Log invocation of %s failed: %+v", apiName, err)
+	}
+}
+
+func (p *Processor) genServiceApiDescription(srv factory.Service) *nef_models.ServiceApiDescription {
+	securityMethods := []string{nef_models.CapifSecurityMethod_PKI}
+	if p.Config().NorthboundTokenVerifier().Enable {
+		securityMethods = []string{nef_models.CapifSecurityMethod_OAUTH}
+	}
+
+	return &nef_models.ServiceApiDescription{
+		ApiName: srv.ServiceName,
+		AefProfiles: []nef_models.AefProfile{
+			{
+				AefId:           p.Config().CapifAefId(),
+				Versions:        []nef_models.CapifVersion{{ApiVersion: capifApiVersion}},
+				Protocol:        nef_models.CapifProtocol_HTTP_2,
+				DataFormat:      nef_models.CapifDataFormat_JSON,
+				SecurityMethods: securityMethods,
+				InterfaceDescriptions: []nef_models.InterfaceDescription{
+					{
+						Ipv4Addr:        p.Config().SbiRegisterIP(),
+						Port:            p.Config().SbiPort(),
+						SecurityMethods: securityMethods,
+					},
+				},
+			},
+		},
+		Description:  capifApiDescriptions[srv.ServiceName],
+		ApiSuppFeats: srv.SuppFeat,
+	}
+}
@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestPublishServiceApis(t *testing.T) {
+	cfg := nefApp.Config().Configuration
+	origServiceList := cfg.ServiceList
+	cfg.ServiceList = []factory.Service{
+		{ServiceName: factory.ServiceNefPfd},
+		{ServiceName: factory.ServiceAsSessionQos, SuppFeat: "1"},
+		{ServiceName: factory.ServiceMonEvt},
+	}
+	cfg.Capif = &factory.Capif{
+		Enable: true,
+		Uri:    "http://127.0.0.20:8000",
+		ApfId:  "apf1",
+		AefId:  "aef1",
+	}
+	defer func() {
+		cfg.ServiceList = origServiceList
+		cfg.Capif = nil
+	}()
+
+	serviceApisUri := "http://127.0.0.20:8000/published-apis/v1/apf1"
+	getMock := gock.New(serviceApisUri).
+		Get("/service-apis").
+		Reply(http.StatusOK).
+		JSON([]nef_models.ServiceApiDescription{
+			{ApiName: factory.ServiceMonEvt, ApiId: "api-monevt"},
+			{ApiName: factory.ServiceTraffInflu, ApiId: "api-ti"},
+		}).Mock
+	postMock := gock.New(serviceApisUri).
+		Post("/service-apis").
+		BodyString(`"apiName":"3gpp-as-session-with-qos","aefProfiles":\[\{"aefId":"aef1",`+
+			`"versions":\[\{"apiVersion":"v1"\}\],"protocol":"HTTP_2","dataFormat":"JSON",`+
+			`"securityMethods":\["PKI"\],"interfaceDescriptions":\[\{"ipv4Addr":"127.0.0.5","port":8000`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", serviceApisUri+"/service-apis/api-qos").
+		JSON(nef_models.ServiceApiDescription{ApiName: factory.ServiceAsSessionQos}).Mock
+	putMock := gock.New(serviceApisUri).
+		Put("/service-apis/api-monevt").
+		Reply(http.StatusOK).
+		JSON(nef_models.ServiceApiDescription{ApiName: factory.ServiceMonEvt, ApiId: "api-monevt"}).Mock
+	deleteTiMock := gock.New(serviceApisUri).
+		Delete("/service-apis/api-ti").
+		Reply(http.StatusNoContent).Mock
+
+	require.NoError(t, nefApp.Processor().PublishServiceApis())
+	require.True(t, getMock.Done())
+	require.True(t, postMock.Done())
+	require.True(t, putMock.Done())
+	require.True(t, deleteTiMock.Done())
+	require.Equal(t, map[string]string{
+		factory.ServiceAsSessionQos: "api-qos",
+		factory.ServiceMonEvt:       "api-monevt",
+	}, nefApp.Context().CapifApiIds())
+
+	logMock := gock.New("http://127.0.0.20:8000/api-invocation-logs/v1/aef1").
+		Post("/logs").
+		BodyString(`"aefId":"aef1","apiInvokerId":"af1","logs":\[\{"apiName":"3gpp-monitoring-event",` +
+			`"apiId":"api-monevt","apiVersion":"v1","result":"201"`).
+		Reply(http.StatusCreated).Mock
+	nefApp.Processor().LogApiInvocation(factory.ServiceMonEvt, "af1", &nef_models.CapifLog{
+		ResultCode:   "201",
+		Protocol:     nef_models.CapifProtocol_HTTP_2,
+		Operation:    http.MethodPost,
+		ResourceName: "/3gpp-monitoring-event/v1/:scsAsID/subscriptions",
+	})
+	require.True(t, logMock.Done())
+
+	deleteQosMock := gock.New(serviceApisUri).
+		Delete("/service-apis/api-qos").
+		Reply(http.StatusNoContent).Mock
+	deleteMonEvtMock := gock.New(serviceApisUri).
+		Delete("/service-apis/api-monevt").
+		Reply(http.StatusNoContent).Mock
+	nefApp.Processor().UnpublishServiceApis()
+	require.True(t, deleteQosMock.Done())
+	require.True(t, deleteMonEvtMock.Done())
+	require.Empty(t, nefApp.Context().CapifApiIds())
+}
+
+func TestPublishServiceApisWithRetry(t *testing.T) {
+	cfg := nefApp.Config().Configuration
+	origServiceList := cfg.ServiceList
+	cfg.ServiceList = []factory.Service{{ServiceName: factory.ServiceMonEvt}}
+	cfg.Capif = &factory.Capif{
+		Enable: true,
+		Uri:    "http://127.0.0.20:8000",
+		ApfId:  "apf1",
+		AefId:  "aef1",
+	}
+	defer func() {
+		cfg.ServiceList = origServiceList
+		cfg.Capif = nil
+		nefApp.Context().DeleteCapifApiId(factory.ServiceMonEvt)
+	}()
+
+	// The publication is retried while the CAPIF core function is unavailable
+	serviceApisUri := "http://127.0.0.20:8000/published-apis/v1/apf1"
+	failMock := gock.New(serviceApisUri).
+		Get("/service-apis").
+		Times(2).
+		Reply(http.StatusServiceUnavailable).
+		JSON(models.ProblemDetails{Status: http.StatusServiceUnavailable}).Mock
+	getMock := gock.New(serviceApisUri).
+		Get("/service-apis").
+		Reply(http.StatusOK).
+		JSON([]nef_models.ServiceApiDescription{}).Mock
+	postMock := gock.New(serviceApisUri).
+		Post("/service-apis").
+		Reply(http.StatusCreated).
+		SetHeader("Location", serviceApisUri+"/service-apis/api-monevt").
+		JSON(nef_models.ServiceApiDescription{ApiName: factory.ServiceMonEvt}).Mock
+
+	err := nefApp.Processor().PublishServiceApisWithRetry(context.Background(), 10*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, failMock.Done())
+	require.True(t, getMock.Done())
+	require.True(t, postMock.Done())
+	require.Equal(t, "api-monevt", nefApp.Context().CapifApiId(factory.ServiceMonEvt))
+
+	// The retries stop once the NEF shuts down
+	failMock = gock.New(serviceApisUri).
+		Get("/service-apis").
+		Reply(http.StatusServiceUnavailable).
+		JSON(models.ProblemDetails{Status: http.StatusServiceUnavailable}).Mock
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = nefApp.Processor().PublishServiceApisWithRetry(ctx, time.Minute)
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, failMock.Done())
+}
@@ -0,0 +1,384 @@
+package processor
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeviceTriggeringTransactions Read all device triggering transactions for a given SCS/AS
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.7.1
+// Request/Response  : 5.7.3.2.3.1
+func (p *Processor) GetDeviceTriggeringTransactions(
+	c *gin.Context,
+	scsAsID string,
+) {
+	logger.DevTrigLog.Infof("GetDeviceTriggeringTransactions - scsAsID[%s]", scsAsID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var devTrigs []nef_models.DeviceTriggering
+	for _, devTr := range af.DevTrigs {
+		if devTr.DevTrig == nil {
+			continue
+		}
+		devTrigs = append(devTrigs, *devTr.DevTrig)
+	}
+	c.JSON(http.StatusOK, &devTrigs)
+}
+
+// PostDeviceTriggeringTransaction Create a new device triggering transaction
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.7.1
+// Request/Response  : 5.7.3.2.3.2
+func (p *Processor) PostDeviceTriggeringTransaction(
+	c *gin.Context,
+	scsAsID string,
+	devTrig *nef_models.DeviceTriggering,
+) {
+	logger.DevTrigLog.Infof("PostDeviceTriggeringTransaction - scsAsID[%s]", scsAsID)
+
+	problemDetails := validateDeviceTriggering(devTrig)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(scsAsID)
+	if af == nil {
+		af = nefCtx.NewAf(scsAsID)
+		if af == nil {
+			pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	correID := nefCtx.NewCorreID()
+	devTr := af.NewDevTrig(correID, devTrig)
+	if devTr == nil {
+		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	trigUri, submitted, pd, err := p.Consumer().SubmitDeviceTrigger(p.convertDeviceTriggering(devTr))
+	switch {
+	case pd != nil:
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	case err != nil:
+		pd = openapi.ProblemDetailsSystemFailure("Query to SMS-SF failed")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	devTr.SmsSfTrigUri = trigUri
+	devTrig.Self = p.genDevTrigURI(scsAsID, devTr.TransID)
+	devTrig.DeliveryResult = deliveryResultOfSubmission(submitted)
+
+	af.DevTrigs[devTr.TransID] = devTr
+	af.Log.Infoln("Device triggering transaction is added")
+
+	nefCtx.AddAf(af)
+
+	c.Header("Location", devTrig.Self)
+	c.JSON(http.StatusCreated, devTrig)
+}
+
+// GetIndividualDeviceTriggeringTransaction Read a device triggering transaction
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.7.1
+// Request/Response  : 5.7.3.3.3.1
+func (p *Processor) GetIndividualDeviceTriggeringTransaction(
+	c *gin.Context,
+	scsAsID, transID string,
+) {
+	logger.DevTrigLog.Infof("GetIndividualDeviceTriggeringTransaction - scsAsID[%s], transID[%s]",
+		scsAsID, transID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	devTr, ok := af.DevTrigs[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	c.JSON(http.StatusOK, devTr.DevTrig)
+}
+
+// PutIndividualDeviceTriggeringTransaction Replace a device triggering transaction
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.7.1
+// Request/Response  : 5.7.3.3.3.2
+func (p *Processor) PutIndividualDeviceTriggeringTransaction(
+	c *gin.Context,
+	scsAsID, transID string,
+	devTrig *nef_models.DeviceTriggering,
+) {
+	logger.DevTrigLog.Infof("PutIndividualDeviceTriggeringTransaction - scsAsID[%s], transID[%s]",
+		scsAsID, transID)
+
+	problemDetails := validateDeviceTriggering(devTrig)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	devTr, ok := af.DevTrigs[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	// Only the trigger still pending at the SMS-SF can be replaced, and for the
+	// same UE
+	switch {
+	case devTr.DevTrig == nil || devTr.DevTrig.DeliveryResult != nef_models.DeliveryResult_TRIGGERED:
+		pd := openapi.ProblemDetailsForbidden("Trigger is no longer pending", "MODIFICATION_NOT_ALLOWED")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	case devTrig.ExternalId != devTr.DevTrig.ExternalId || devTrig.Msisdn != devTr.DevTrig.Msisdn:
+		pd := openapi.ProblemDetailsForbidden("UE of the trigger cannot be changed", "MODIFICATION_NOT_ALLOWED")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	// The trigger pending at the SMS-SF is replaced, the old one is kept if
+	// the replacement is rejected
+	newDevTr := &context.AfDevTrigTransaction{
+		TransID:      devTr.TransID,
+		DevTrig:      devTrig,
+		NotifCorreID: devTr.NotifCorreID,
+		SmsSfTrigUri: devTr.SmsSfTrigUri,
+		Log:          devTr.Log,
+	}
+	replaced, pd, err := p.Consumer().ReplaceDeviceTrigger(devTr.SmsSfTrigUri, p.convertDeviceTriggering(newDevTr))
+	switch {
+	case pd != nil:
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	case err != nil:
+		pd = openapi.ProblemDetailsSystemFailure("Query to SMS-SF failed")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	devTrig.Self = p.genDevTrigURI(scsAsID, transID)
+	devTrig.DeliveryResult = deliveryResultOfSubmission(replaced)
+
+	af.DevTrigs[transID] = newDevTr
+	af.Persist()
+
+	c.JSON(http.StatusOK, devTrig)
+}
+
+// DeleteIndividualDeviceTriggeringTransaction Delete a device triggering transaction
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.7.1
+// Request/Response  : 5.7.3.3.3.4
+func (p *Processor) DeleteIndividualDeviceTriggeringTransaction(
+	c *gin.Context,
+	scsAsID, transID string,
+) {
+	logger.DevTrigLog.Infof("DeleteIndividualDeviceTriggeringTransaction - scsAsID[%s], transID[%s]",
+		scsAsID, transID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	devTr, ok := af.DevTrigs[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	// Only the trigger still pending at the SMS-SF needs to be recalled
+	if devTr.DevTrig == nil || devTr.DevTrig.DeliveryResult == nef_models.DeliveryResult_TRIGGERED {
+		pd, err := p.Consumer().RecallDeviceTrigger(devTr.SmsSfTrigUri)
+		switch {
+		case pd != nil:
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		case err != nil:
+			pd = openapi.ProblemDetailsSystemFailure("Query to SMS-SF failed")
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+	af.DeleteDevTrig(transID)
+	c.Status(http.StatusNoContent)
+}
+
+// SmsSfDeliveryReport relays the delivery report of a device trigger to the AF.
+// Resource structure: {notifUri} of the SMS-SF device trigger
+// Request: SmsSfDeliveryReport, Response: 204
+func (p *Processor) SmsSfDeliveryReport(
+	c *gin.Context,
+	corrID string,
+	report *nef_models.SmsSfDeliveryReport,
+) {
+	logger.DevTrigLog.Infof("SmsSfDeliveryReport - corrID[%s]", corrID)
+
+	if report.Result == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing result")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	af, devTr := p.Context().FindAfDevTrig(corrID)
+	if devTr == nil || devTr.DevTrig == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	devTr.DevTrig.DeliveryResult = report.Result
+	af.Persist()
+	notifDest := devTr.DevTrig.NotificationDestination
+	notif := &nef_models.DeviceTriggeringDeliveryReportNotification{
+		Transaction: devTr.DevTrig.Self,
+		Result:      report.Result,
+	}
+	af.Mu.Unlock()
+	devTr.Log.Infof("Device trigger delivery result: %s", report.Result)
+
+	// The AF is notified without holding the AF lock
+	if err := p.Notifier().DevTrigNotifier.NotifyAf(notifDest, notif); err != nil {
+		devTr.Log.Errorf("Failed to notify AF of the delivery report: %+v", err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func validateDeviceTriggering(devTrig *nef_models.DeviceTriggering) *models.ProblemDetails {
+	if (devTrig.ExternalId == "") == (devTrig.Msisdn == "") {
+		return openapi.ProblemDetailsMalformedReqSyntax("Either externalId or msisdn shall be provided")
+	}
+	if devTrig.NotificationDestination == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notificationDestination")
+	}
+	if devTrig.ApplicationPortId < 0 || devTrig.ApplicationPortId > 65535 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid applicationPortId")
+	}
+	if devTrig.AppSrcPortId < 0 || devTrig.AppSrcPortId > 65535 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid appSrcPortId")
+	}
+	if devTrig.TriggerPayload == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing triggerPayload")
+	}
+	if _, err := base64.StdEncoding.DecodeString(devTrig.TriggerPayload); err != nil {
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid triggerPayload: " + err.Error())
+	}
+	if devTrig.ValidityPeriod <= 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing validityPeriod")
+	}
+	switch devTrig.Priority {
+	case nef_models.Priority_NO_PRIORITY, nef_models.Priority_PRIORITY:
+	default:
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid priority: " + string(devTrig.Priority))
+	}
+	return nil
+}
+
+// deliveryResultOfSubmission returns the delivery result known when the
+// trigger is submitted, TRIGGERED when the SMS-SF reports none.
+func deliveryResultOfSubmission(submitted *nef_models.SmsSfDeviceTrigger) nef_models.DeliveryResult {
+	if submitted == nil || submitted.DeliveryResult == "" {
+		return nef_models.DeliveryResult_TRIGGERED
+	}
+	return submitted.DeliveryResult
+}
+
+func (p *Processor) genDevTrigURI(
+	scsAsID, transactionId string,
+) string {
+	// E.g. https://localhost:29505/3gpp-device-triggering/v1/{scsAsId}/transactions/{transactionId}
+	return p.Config().ServiceUri(factory.ServiceDevTrig) + "/" + scsAsID + "/transactions/" + transactionId
+}
+
+func (p *Processor) genSmsSfNotificationUri(notifCorreID string) string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/smssf/" + notifCorreID
+}
+
+func (p *Processor) convertDeviceTriggering(devTr *context.AfDevTrigTransaction) *nef_models.SmsSfDeviceTrigger {
+	devTrig := devTr.DevTrig
+	return &nef_models.SmsSfDeviceTrigger{
+		ExternalId:        devTrig.ExternalId,
+		Msisdn:            devTrig.Msisdn,
+		ApplicationPortId: devTrig.ApplicationPortId,
+		AppSrcPortId:      devTrig.AppSrcPortId,
+		TriggerPayload:    devTrig.TriggerPayload,
+		ValidityPeriod:    devTrig.ValidityPeriod,
+		Priority:          devTrig.Priority,
+		NotifUri:          p.genSmsSfNotificationUri(devTr.NotifCorreID),
+	}
+}
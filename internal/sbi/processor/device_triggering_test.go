@@ -0,0 +1,181 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// smsSfStub is an SMS-SF serving the device trigger stand-in API
+type smsSfStub struct {
+	mu       sync.Mutex
+	triggers map[string]nef_models.SmsSfDeviceTrigger
+	recalled []string
+}
+
+func (s *smsSfStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/device-triggers":
+		var trig nef_models.SmsSfDeviceTrigger
+		if err := json.NewDecoder(r.Body).Decode(&trig); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.triggers["trig1"] = trig
+		w.Header().Set("Location", "http://"+r.Host+"/device-triggers/trig1")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&trig)
+	case r.Method == http.MethodPut && r.URL.Path == "/device-triggers/trig1":
+		var trig nef_models.SmsSfDeviceTrigger
+		if err := json.NewDecoder(r.Body).Decode(&trig); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.triggers["trig1"] = trig
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&trig)
+	case r.Method == http.MethodDelete && r.URL.Path == "/device-triggers/trig1":
+		s.recalled = append(s.recalled, "trig1")
+		delete(s.triggers, "trig1")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestDeviceTriggeringTransaction(t *testing.T) {
+	stub := &smsSfStub{triggers: make(map[string]nef_models.SmsSfDeviceTrigger)}
+	smsSf := httptest.NewServer(stub)
+	defer smsSf.Close()
+	// The SMS-SF stub is reached through the network, gock only mocks the AF
+	gock.EnableNetworking()
+	defer gock.DisableNetworking()
+
+	cfg := nefApp.Config()
+	cfg.Configuration.DeviceTriggering = &factory.DeviceTriggering{SmsSfUri: smsSf.URL}
+	defer func() {
+		cfg.Configuration.DeviceTriggering = nil
+	}()
+
+	devTrig := nef_models.DeviceTriggering{
+		ExternalId:              "123@nef.free5gc.org",
+		ApplicationPortId:       1000,
+		NotificationDestination: "http://127.0.0.105:8000/devtrig/notify",
+		TriggerPayload:          "d2FrZSB1cA==",
+		ValidityPeriod:          300,
+		Priority:                nef_models.Priority_NO_PRIORITY,
+	}
+	devTrigInvalid := devTrig
+	devTrigInvalid.Msisdn = "886900000000"
+
+	testCases := []struct {
+		description    string
+		devTrig        *nef_models.DeviceTriggering
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Both externalId and msisdn",
+			devTrig:        &devTrigInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC2: Trigger of an external ID, submitted to SMS-SF",
+			devTrig:        &devTrig,
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	var location string
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			trig := *tc.devTrig
+			nefApp.Processor().PostDeviceTriggeringTransaction(c, "af5", &trig)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			if httpRecorder.Code == http.StatusCreated {
+				location = httpRecorder.Header().Get("Location")
+			}
+		})
+	}
+
+	af := nefApp.Context().GetAf("af5")
+	require.NotNil(t, af)
+	require.Len(t, af.DevTrigs, 1)
+	devTr, ok := af.DevTrigs["1"]
+	require.True(t, ok)
+	require.Equal(t, smsSf.URL+"/device-triggers/trig1", devTr.SmsSfTrigUri)
+	require.Equal(t, nef_models.DeliveryResult_TRIGGERED, devTr.DevTrig.DeliveryResult)
+	require.Equal(t, nefApp.Config().ServiceUri(factory.ServiceDevTrig)+"/af5/transactions/1", location)
+	require.Equal(t, nefApp.Config().ServiceUri(factory.ServiceNefCallback)+
+		"/notification/smssf/"+devTr.NotifCorreID, stub.triggers["trig1"].NotifUri)
+
+	// The replacement is submitted to the SMS-SF in place
+	replaced := devTrig
+	replaced.TriggerPayload = "d2FrZSB1cCBub3c="
+	replaced.Priority = nef_models.Priority_PRIORITY
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PutIndividualDeviceTriggeringTransaction(c, "af5", "1", &replaced)
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.Equal(t, nef_models.Priority_PRIORITY, stub.triggers["trig1"].Priority)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().GetIndividualDeviceTriggeringTransaction(c, "af5", "1")
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	assertJSONBodyEqual(t, &replaced, httpRecorder.Body.Bytes())
+
+	// The UE of the trigger cannot be changed
+	otherUe := replaced
+	otherUe.ExternalId = "456@nef.free5gc.org"
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PutIndividualDeviceTriggeringTransaction(c, "af5", "1", &otherUe)
+	require.Equal(t, http.StatusForbidden, httpRecorder.Code)
+	require.Equal(t, replaced.ExternalId, stub.triggers["trig1"].ExternalId)
+
+	// The delivery report of the SMS-SF is forwarded to the AF
+	afMock := gock.New("http://127.0.0.105:8000").
+		Post("/devtrig/notify").
+		BodyString(`"transaction":".*/af5/transactions/1".*"result":"SUCCESS"`).
+		Reply(http.StatusNoContent)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().SmsSfDeliveryReport(c, devTr.NotifCorreID,
+		&nef_models.SmsSfDeliveryReport{Result: nef_models.DeliveryResult_SUCCESS})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, afMock.Done())
+	require.Equal(t, nef_models.DeliveryResult_SUCCESS, af.DevTrigs["1"].DevTrig.DeliveryResult)
+
+	// The delivered trigger cannot be replaced
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PutIndividualDeviceTriggeringTransaction(c, "af5", "1", &replaced)
+	require.Equal(t, http.StatusForbidden, httpRecorder.Code)
+	require.Equal(t, nef_models.DeliveryResult_SUCCESS, af.DevTrigs["1"].DevTrig.DeliveryResult)
+
+	// The delivered trigger is not recalled from the SMS-SF
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().DeleteIndividualDeviceTriggeringTransaction(c, "af5", "1")
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.Empty(t, stub.recalled)
+	require.Empty(t, af.DevTrigs)
+}
@@ -0,0 +1,425 @@
+package processor
+
+import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+// evtExpoAfEvents are the Nnef_EventExposure events collected from the AFs,
+// the AF events share their names
+var evtExpoAfEvents = map[models.NefEvent]models.AfEventExposureAfEvent{
+	models.NefEvent_SVC_EXPERIENCE:           models.AfEventExposureAfEvent_SVC_EXPERIENCE,
+	models.NefEvent_UE_COMM:                  models.AfEventExposureAfEvent_UE_COMM,
+	models.NefEvent_EXCEPTIONS:               models.AfEventExposureAfEvent_EXCEPTIONS,
+	models.NefEvent_USER_DATA_CONGESTION:     models.AfEventExposureAfEvent_USER_DATA_CONGESTION,
+	models.NefEvent_DISPERSION:               models.AfEventExposureAfEvent_DISPERSION,
+	models.NefEvent_COLLECTIVE_BEHAVIOUR:     models.AfEventExposureAfEvent_COLLECTIVE_BEHAVIOUR,
+	models.NefEvent_MS_QOE_METRICS:           models.AfEventExposureAfEvent_MS_QOE_METRICS,
+	models.NefEvent_MS_CONSUMPTION:           models.AfEventExposureAfEvent_MS_CONSUMPTION,
+	models.NefEvent_MS_NET_ASSIST_INVOCATION: models.AfEventExposureAfEvent_MS_NET_ASSIST_INVOCATION,
+	models.NefEvent_MS_DYN_POLICY_INVOCATION: models.AfEventExposureAfEvent_MS_DYN_POLICY_INVOCATION,
+	models.NefEvent_MS_ACCESS_ACTIVITY:       models.AfEventExposureAfEvent_MS_ACCESS_ACTIVITY,
+}
+
+// PostNefEventExposureSubscription Create a subscription to the events of the AFs
+// 3GPP TS 29.591 release 17 version 17.6.0
+// Resource structure: 6.1.3.1
+// Request/Response  : 6.1.3.2.3.1
+func (p *Processor) PostNefEventExposureSubscription(c *gin.Context, eeSubsc *models.NefEventExposureSubsc) {
+	logger.EvtExpoLog.Infof("PostNefEventExposureSubscription - notifId[%s]", eeSubsc.NotifId)
+
+	if pd := validateNefEventExposureSubsc(eeSubsc); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServiceNefEvtExpo, eeSubsc.SuppFeat)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	eeSubsc.SuppFeat = suppFeat
+
+	nefCtx := p.Context()
+	sub := nefCtx.NewEeSub()
+	sub.EeSub = eeSubsc
+
+	notifs, pd := p.subscribeAfEvents(sub, nil)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	nefCtx.AddEeSub(sub)
+
+	// The events reported immediately are returned, not kept in the subscription
+	rsp := *eeSubsc
+	rsp.EventNotifs = notifs
+	c.Header("Location", p.genNefEvtExpoSubURI(sub.SubID))
+	c.JSON(http.StatusCreated, &rsp)
+}
+
+// GetNefEventExposureSubscription Read a subscription to the events of the AFs
+// 3GPP TS 29.591 release 17 version 17.6.0
+// Resource structure: 6.1.3.1
+// Request/Response  : 6.1.3.3.3.1
+func (p *Processor) GetNefEventExposureSubscription(c *gin.Context, subID string) {
+	logger.EvtExpoLog.Infof("GetNefEventExposureSubscription - subID[%s]", subID)
+
+	sub := p.Context().GetEeSub(subID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	sub.Mu.RLock()
+	defer sub.Mu.RUnlock()
+	c.JSON(http.StatusOK, sub.EeSub)
+}
+
+// PutNefEventExposureSubscription Replace a subscription to the events of the AFs
+// 3GPP TS 29.591 release 17 version 17.6.0
+// Resource structure: 6.1.3.1
+// Request/Response  : 6.1.3.3.3.2
+func (p *Processor) PutNefEventExposureSubscription(
+	c *gin.Context,
+	subID string,
+	eeSubsc *models.NefEventExposureSubsc,
+) {
+	logger.EvtExpoLog.Infof("PutNefEventExposureSubscription - subID[%s]", subID)
+
+	if pd := validateNefEventExposureSubsc(eeSubsc); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	sub := p.Context().GetEeSub(subID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	sub.Mu.Lock()
+	defer sub.Mu.Unlock()
+
+	// The features are negotiated at creation only
+	eeSubsc.SuppFeat = sub.EeSub.SuppFeat
+	newSub := &context.EeSubscription{
+		SubID:  sub.SubID,
+		EeSub:  eeSubsc,
+		AfSubs: make(map[string]string),
+		Log:    sub.Log,
+	}
+	notifs, pd := p.subscribeAfEvents(newSub, sub)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	// The AFs which no longer serve the applications are unsubscribed
+	for afID, afSubUri := range sub.AfSubs {
+		if _, ok := newSub.AfSubs[afID]; !ok {
+			p.unsubscribeAfEvents(sub, afID, afSubUri)
+		}
+	}
+
+	sub.EeSub = eeSubsc
+	sub.AfSubs = newSub.AfSubs
+	sub.Persist()
+
+	rsp := *eeSubsc
+	rsp.EventNotifs = notifs
+	c.JSON(http.StatusOK, &rsp)
+}
+
+// DeleteNefEventExposureSubscription Delete a subscription to the events of the AFs
+// 3GPP TS 29.591 release 17 version 17.6.0
+// Resource structure: 6.1.3.1
+// Request/Response  : 6.1.3.3.3.3
+func (p *Processor) DeleteNefEventExposureSubscription(c *gin.Context, subID string) {
+	logger.EvtExpoLog.Infof("DeleteNefEventExposureSubscription - subID[%s]", subID)
+
+	nefCtx := p.Context()
+	sub := nefCtx.GetEeSub(subID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	sub.Mu.Lock()
+	defer sub.Mu.Unlock()
+
+	for afID, afSubUri := range sub.AfSubs {
+		p.unsubscribeAfEvents(sub, afID, afSubUri)
+	}
+	nefCtx.DeleteEeSub(subID)
+	c.Status(http.StatusNoContent)
+}
+
+// AfEventNotification relays the Naf_EventExposure notifications to the
+// consumer NF of the Nnef_EventExposure subscription.
+// 3GPP TS 29.517 release 17
+// Resource structure: {notifUri}
+// Request: AfEventExposureNotif, Response: 204
+func (p *Processor) AfEventNotification(c *gin.Context, corrID string, afNotif *models.AfEventExposureNotif) {
+	logger.EvtExpoLog.Infof("AfEventNotification - corrID[%s]", corrID)
+
+	sub := p.Context().GetEeSub(corrID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	notifs := convertAfEventNotifications(afNotif.EventNotifs)
+	if len(notifs) == 0 {
+		sub.Log.Debugln("No AF event to relay")
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	sub.Mu.RLock()
+	notif := &models.NefEventExposureNotif{
+		NotifId:     sub.EeSub.NotifId,
+		EventNotifs: notifs,
+	}
+	notifUri := sub.EeSub.NotifUri
+	sub.Mu.RUnlock()
+
+	if err := p.Notifier().EvtExpoNotifier.NotifyNf(notifUri, notif); err != nil {
+		sub.Log.Errorf("Failed to notify the consumer NF of AF events: %+v", err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func validateNefEventExposureSubsc(eeSubsc *models.NefEventExposureSubsc) *models.ProblemDetails {
+	if eeSubsc.NotifUri == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notifUri")
+	}
+	if eeSubsc.NotifId == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notifId")
+	}
+	if len(eeSubsc.EventsSubs) == 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing eventsSubs")
+	}
+	for _, evtSubs := range eeSubsc.EventsSubs {
+		if _, ok := evtExpoAfEvents[evtSubs.Event]; !ok {
+			return openapi.ProblemDetailsMalformedReqSyntax("Unsupported event: " + string(evtSubs.Event))
+		}
+	}
+	return nil
+}
+
+// subscribeAfEvents subscribes to the events of sub at the AFs serving its
+// applications. The subscriptions of prevSub, the subscription being replaced
+// if any, are replaced instead of created. When it fails nothing new is left
+// subscribed at the AFs and the replaced AF subscriptions are given back the
+// data of prevSub. The events returned are those reported immediately.
+func (p *Processor) subscribeAfEvents(
+	sub *context.EeSubscription,
+	prevSub *context.EeSubscription,
+) ([]models.NefEventNotification, *models.ProblemDetails) {
+	afs := p.Config().EventExposureAfs(nefEventExposureAppIds(sub.EeSub))
+	if len(afs) == 0 {
+		return nil, openapi.ProblemDetailsDataNotFound("No AF reports the events of the applications")
+	}
+
+	var prevAfSubs map[string]string
+	if prevSub != nil {
+		prevAfSubs = prevSub.AfSubs
+	}
+	afSubsc := p.convertNefEventExposureSubscToAfEventExposureSubsc(sub)
+	var notifs []models.NefEventNotification
+	var replaced []string
+	for _, af := range afs {
+		var afRsp *models.AfEventExposureSubsc
+		var pd *models.ProblemDetails
+		var err error
+		if afSubUri, ok := prevAfSubs[af.AfId]; ok {
+			afRsp, pd, err = p.Consumer().ReplaceAfEventSubscription(afSubUri, afSubsc)
+			sub.AfSubs[af.AfId] = afSubUri
+			if pd == nil && err == nil {
+				replaced = append(replaced, af.AfId)
+			}
+		} else {
+			var location string
+			location, afRsp, pd, err = p.Consumer().CreateAfEventSubscription(af.Uri, afSubsc)
+			if pd == nil && err == nil {
+				sub.AfSubs[af.AfId] = location
+			}
+		}
+		if pd == nil && err != nil {
+			pd = &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Detail: "Query to AF failed",
+			}
+		}
+		if pd != nil {
+			sub.Log.Warnf("Subscribe to the events of AF[%s] failed: %s", af.AfId, pd.Detail)
+			for afID, afSubUri := range sub.AfSubs {
+				if _, ok := prevAfSubs[afID]; !ok {
+					p.unsubscribeAfEvents(sub, afID, afSubUri)
+				}
+			}
+			if len(replaced) > 0 {
+				p.restoreAfEventSubscriptions(prevSub, replaced)
+			}
+			return nil, pd
+		}
+		notifs = append(notifs, convertAfEventNotifications(afRsp.EventNotifs)...)
+	}
+	return notifs, nil
+}
+
+// restoreAfEventSubscriptions gives the AF subscriptions of afIDs back the
+// data of sub after a failed replacement.
+func (p *Processor) restoreAfEventSubscriptions(sub *context.EeSubscription, afIDs []string) {
+	afSubsc := p.convertNefEventExposureSubscToAfEventExposureSubsc(sub)
+	for _, afID := range afIDs {
+		_, pd, err := p.Consumer().ReplaceAfEventSubscription(sub.AfSubs[afID], afSubsc)
+		switch {
+		case pd != nil:
+			sub.Log.Warnf("Restore the subscription at AF[%s] failed: %s", afID, pd.Detail)
+		case err != nil:
+			sub.Log.Warnf("Restore the subscription at AF[%s] failed: %+v", afID, err)
+		}
+	}
+}
+
+func (p *Processor) unsubscribeAfEvents(sub *context.EeSubscription, afID, afSubUri string) {
+	pd, err := p.Consumer().DeleteAfEventSubscription(afSubUri)
+	switch {
+	case pd != nil && pd.Status != http.StatusNotFound:
+		sub.Log.Warnf("Unsubscribe from the events of AF[%s] failed: %s", afID, pd.Detail)
+	case err != nil:
+		sub.Log.Warnf("Unsubscribe from the events of AF[%s] failed: %+v", afID, err)
+	}
+}
+
+// nefEventExposureAppIds returns the applications targeted by the events, none
+// when one of the events targets any application
+func nefEventExposureAppIds(eeSubsc *models.NefEventExposureSubsc) []string {
+	var appIDs []string
+	for _, evtSubs := range eeSubsc.EventsSubs {
+		if evtSubs.EventFilter == nil || len(evtSubs.EventFilter.AppIds) == 0 {
+			return nil
+		}
+		appIDs = append(appIDs, evtSubs.EventFilter.AppIds...)
+	}
+	return appIDs
+}
+
+func (p *Processor) convertNefEventExposureSubscToAfEventExposureSubsc(
+	sub *context.EeSubscription,
+) *models.AfEventExposureSubsc {
+	eeSubsc := sub.EeSub
+	afSubsc := &models.AfEventExposureSubsc{
+		DataAccProfId: eeSubsc.DataAccProfId,
+		EventsRepInfo: eeSubsc.EventsRepInfo,
+		NotifUri:      p.genAfEeNotificationUri(sub.SubID),
+		NotifId:       sub.SubID,
+	}
+	if afSubsc.EventsRepInfo == nil {
+		afSubsc.EventsRepInfo = &models.ReportingInformation{}
+	}
+	for _, evtSubs := range eeSubsc.EventsSubs {
+		afSubsc.EventsSubs = append(afSubsc.EventsSubs, models.EventsSubs{
+			Event:       evtExpoAfEvents[evtSubs.Event],
+			EventFilter: convertNefEventFilterToAfEventFilter(evtSubs.EventFilter),
+		})
+	}
+	return afSubsc
+}
+
+func convertNefEventFilterToAfEventFilter(filter *models.NefEventFilter) *models.AfEventExposureEventFilter {
+	if filter == nil {
+		return &models.AfEventExposureEventFilter{AnyUeInd: true}
+	}
+	afFilter := &models.AfEventExposureEventFilter{
+		AppIds:    filter.AppIds,
+		CollAttrs: filter.CollAttrs,
+	}
+	if filter.TgtUe != nil {
+		afFilter.Supis = filter.TgtUe.Supis
+		afFilter.InterGroupIds = filter.TgtUe.InterGroupIds
+		afFilter.AnyUeInd = filter.TgtUe.AnyUeId
+	}
+	if filter.LocArea != nil {
+		afFilter.LocArea = &models.LocationArea5G{NwAreaInfo: filter.LocArea}
+	}
+	return afFilter
+}
+
+func convertAfEventNotifications(afNotifs []models.AfEventExposureAfEventNotification) []models.NefEventNotification {
+	var notifs []models.NefEventNotification
+	for i := range afNotifs {
+		if notif := convertAfEventNotification(&afNotifs[i]); notif != nil {
+			notifs = append(notifs, *notif)
+		}
+	}
+	return notifs
+}
+
+// convertAfEventNotification converts the AF event to the NEF event of the same
+// name, nil for the events which are not collected from the AFs
+func convertAfEventNotification(afNotif *models.AfEventExposureAfEventNotification) *models.NefEventNotification {
+	notif := &models.NefEventNotification{
+		Event:     models.NefEvent(afNotif.Event),
+		TimeStamp: afNotif.TimeStamp,
+	}
+	if _, ok := evtExpoAfEvents[notif.Event]; !ok {
+		return nil
+	}
+
+	for _, svcExprc := range afNotif.SvcExprcInfos {
+		notif.SvcExprcInfos = append(notif.SvcExprcInfos, models.NefEventExposureServiceExperienceInfo{
+			AppId:          svcExprc.AppId,
+			Supis:          svcExprc.Supis,
+			SvcExpPerFlows: svcExprc.SvcExpPerFlows,
+		})
+	}
+	for _, ueComm := range afNotif.UeCommInfos {
+		notif.UeCommInfos = append(notif.UeCommInfos, models.UeCommunicationInfo{
+			Supi:         ueComm.Supi,
+			InterGroupId: ueComm.InterGroupId,
+			AppId:        ueComm.AppId,
+			Comms:        ueComm.Comms,
+		})
+	}
+	notif.ExcepInfos = afNotif.ExcepInfos
+	notif.CongestionInfos = afNotif.CongestionInfos
+	notif.DispersionInfos = afNotif.DispersionInfos
+	notif.CollBhvrInfs = afNotif.CollBhvrInfs
+	notif.MsQoeMetrInfos = afNotif.MsQoeMetrInfos
+	notif.MsConsumpInfos = afNotif.MsConsumpInfos
+	notif.MsNetAssInvInfos = afNotif.MsNetAssInvInfos
+	notif.MsDynPlyInvInfos = afNotif.MsDynPlyInvInfos
+	notif.MsAccActInfos = afNotif.MsAccActInfos
+	return notif
+}
+
+func (p *Processor) genNefEvtExpoSubURI(subID string) string {
+	// E.g. https://localhost:29505/nnef-eventexposure/v1/subscriptions/{subscriptionId}
+	return p.Config().ServiceUri(factory.ServiceNefEvtExpo) + "/subscriptions/" + subID
+}
+
+func (p *Processor) genAfEeNotificationUri(notifCorreID string) string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/af-ee/" + notifCorreID
+}
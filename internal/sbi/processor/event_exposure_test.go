@@ -0,0 +1,202 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestNefEventExposureSubscription(t *testing.T) {
+	cfg := nefApp.Config().Configuration
+	cfg.EventExposure = &factory.EventExposure{
+		Afs: []factory.EventExposureAf{
+			{AfId: "af1", Uri: "http://127.0.0.101:8000", AppIds: []string{"app1"}},
+			{AfId: "af2", Uri: "http://127.0.0.102:8000", AppIds: []string{"app2"}},
+		},
+	}
+	defer func() {
+		cfg.EventExposure = nil
+	}()
+
+	eeSubsc := models.NefEventExposureSubsc{
+		EventsSubs: []models.NefEventSubs{
+			{
+				Event: models.NefEvent_SVC_EXPERIENCE,
+				EventFilter: &models.NefEventFilter{
+					TgtUe:  &models.NefEventExposureTargetUeIdentification{AnyUeId: true},
+					AppIds: []string{"app1"},
+				},
+			},
+		},
+		NotifUri: "http://127.0.0.22:8000/nwdaf/notify",
+		NotifId:  "nwdaf-notif1",
+	}
+	eeSubscInvalid := eeSubsc
+	eeSubscInvalid.EventsSubs = []models.NefEventSubs{{Event: models.NefEvent_UE_MOBILITY}}
+
+	af1SubMock := gock.New("http://127.0.0.101:8000/naf-eventexposure/v1").
+		Post("/subscriptions").
+		BodyString(`"event":"SVC_EXPERIENCE".*"anyUeInd":true.*"appIds":\["app1"\]`+
+			`.*"notifUri":".*/nnef-callback/v1/notification/af-ee/.*"`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.101:8000/naf-eventexposure/v1/subscriptions/afsub1").
+		JSON(models.AfEventExposureSubsc{})
+
+	testCases := []struct {
+		description    string
+		eeSubsc        *models.NefEventExposureSubsc
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Event not collected from the AFs",
+			eeSubsc:        &eeSubscInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC2: Service experience of app1, subscribed at af1 only",
+			eeSubsc:        &eeSubsc,
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	var location string
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			subsc := *tc.eeSubsc
+			nefApp.Processor().PostNefEventExposureSubscription(c, &subsc)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			if httpRecorder.Code == http.StatusCreated {
+				location = httpRecorder.Header().Get("Location")
+			}
+		})
+	}
+	require.True(t, af1SubMock.Done())
+
+	subsPrefix := nefApp.Processor().genNefEvtExpoSubURI("")
+	require.True(t, strings.HasPrefix(location, subsPrefix))
+	subID := strings.TrimPrefix(location, subsPrefix)
+	sub := nefApp.Context().GetEeSub(subID)
+	require.NotNil(t, sub)
+	require.Equal(t, map[string]string{
+		"af1": "http://127.0.0.101:8000/naf-eventexposure/v1/subscriptions/afsub1",
+	}, sub.AfSubs)
+
+	// The events of the AF are relayed to the NWDAF
+	nwdafMock := gock.New("http://127.0.0.22:8000").
+		Post("/nwdaf/notify").
+		BodyString(`"notifId":"nwdaf-notif1".*"event":"SVC_EXPERIENCE"` +
+			`.*"appId":"app1".*"supis":\["imsi-208930000000001"\]`).
+		Reply(http.StatusNoContent)
+
+	now := time.Now()
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().AfEventNotification(c, subID, &models.AfEventExposureNotif{
+		NotifId: subID,
+		EventNotifs: []models.AfEventExposureAfEventNotification{
+			{
+				Event:     models.AfEventExposureAfEvent_SVC_EXPERIENCE,
+				TimeStamp: &now,
+				SvcExprcInfos: []models.ServiceExperienceInfoPerApp{
+					{
+						AppId: "app1",
+						Gpsis: []string{"msisdn-0900000000"},
+						Supis: []string{"imsi-208930000000001"},
+					},
+				},
+			},
+		},
+	})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, nwdafMock.Done())
+
+	// A failed replacement gives af1 back the previous subscription
+	af1ReplaceMock := gock.New("http://127.0.0.101:8000/naf-eventexposure/v1").
+		Put("/subscriptions/afsub1").
+		BodyString(`"event":"UE_COMM"`).
+		Reply(http.StatusOK).
+		JSON(models.AfEventExposureSubsc{})
+	af2FailMock := gock.New("http://127.0.0.102:8000/naf-eventexposure/v1").
+		Post("/subscriptions").
+		Reply(http.StatusForbidden).
+		JSON(models.ProblemDetails{Status: http.StatusForbidden, Cause: "NOT_ALLOWED"})
+	af1RestoreMock := gock.New("http://127.0.0.101:8000/naf-eventexposure/v1").
+		Put("/subscriptions/afsub1").
+		BodyString(`"event":"SVC_EXPERIENCE"`).
+		Reply(http.StatusOK).
+		JSON(models.AfEventExposureSubsc{})
+
+	failed := eeSubsc
+	failed.EventsSubs = []models.NefEventSubs{
+		{
+			Event:       models.NefEvent_UE_COMM,
+			EventFilter: &models.NefEventFilter{AppIds: []string{"app1", "app2"}},
+		},
+	}
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PutNefEventExposureSubscription(c, subID, &failed)
+	require.Equal(t, http.StatusForbidden, httpRecorder.Code)
+	require.True(t, af1ReplaceMock.Done())
+	require.True(t, af2FailMock.Done())
+	require.True(t, af1RestoreMock.Done())
+	require.Equal(t, models.NefEvent_SVC_EXPERIENCE, sub.EeSub.EventsSubs[0].Event)
+
+	// Moving to app2 subscribes at af2 and unsubscribes from af1
+	af2SubMock := gock.New("http://127.0.0.102:8000/naf-eventexposure/v1").
+		Post("/subscriptions").
+		BodyString(`"appIds":\["app2"\]`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.102:8000/naf-eventexposure/v1/subscriptions/afsub2").
+		JSON(models.AfEventExposureSubsc{})
+	af1UnsubMock := gock.New("http://127.0.0.101:8000/naf-eventexposure/v1").
+		Delete("/subscriptions/afsub1").
+		Reply(http.StatusNoContent)
+
+	replaced := eeSubsc
+	replaced.EventsSubs = []models.NefEventSubs{
+		{
+			Event:       models.NefEvent_UE_COMM,
+			EventFilter: &models.NefEventFilter{AppIds: []string{"app2"}},
+		},
+	}
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PutNefEventExposureSubscription(c, subID, &replaced)
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.True(t, af2SubMock.Done())
+	require.True(t, af1UnsubMock.Done())
+	require.Equal(t, map[string]string{
+		"af2": "http://127.0.0.102:8000/naf-eventexposure/v1/subscriptions/afsub2",
+	}, sub.AfSubs)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().GetNefEventExposureSubscription(c, subID)
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	assertJSONBodyEqual(t, &replaced, httpRecorder.Body.Bytes())
+
+	af2UnsubMock := gock.New("http://127.0.0.102:8000/naf-eventexposure/v1").
+		Delete("/subscriptions/afsub2").
+		Reply(http.StatusNoContent)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().DeleteNefEventExposureSubscription(c, subID)
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, af2UnsubMock.Done())
+	require.Nil(t, nefApp.Context().GetEeSub(subID))
+}
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"net/http"
+
+	"github.com/free5gc/openapi/models"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// initNRFDiscStub lets the NRF discover a single instance of the NF type
+// offering the service at the API prefix
+func initNRFDiscStub(
+	nfType models.NrfNfManagementNfType,
+	serviceName models.ServiceName,
+	ipv4Addr string,
+	apiPrefix string,
+) {
+	searchResult := &models.SearchResult{
+		ValidityPeriod: 100,
+		NfInstances: []models.NrfNfDiscoveryNfProfile{
+			{
+				NfInstanceId:  "nef-unit-testing",
+				NfType:        nfType,
+				NfStatus:      "REGISTERED",
+				Ipv4Addresses: []string{ipv4Addr},
+				NfServices: []models.NrfNfDiscoveryNfService{
+					{
+						ServiceInstanceId: "1",
+						ServiceName:       serviceName,
+						Versions: []models.NfServiceVersion{
+							{
+								ApiVersionInUri: "v1",
+								ApiFullVersion:  "1.0.0",
+							},
+						},
+						Scheme:          "http",
+						NfServiceStatus: "REGISTERED",
+						IpEndPoints: []models.IpEndPoint{
+							{
+								Ipv4Address: ipv4Addr,
+								Transport:   "TCP",
+								Port:        8000,
+							},
+						},
+						ApiPrefix: apiPrefix,
+					},
+				},
+			},
+		},
+	}
+
+	gock.New("http://127.0.0.10:8000/nnrf-disc/v1").
+		Get("/nf-instances").
+		MatchParam("target-nf-type", string(nfType)).
+		MatchParam("requester-nf-type", "NEF").
+		MatchParam("service-names", string(serviceName)).
+		Reply(http.StatusOK).
+		JSON(searchResult)
+}
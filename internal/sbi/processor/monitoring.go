@@ -0,0 +1,769 @@
+package processor
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	gpsiExtIdPrefix      = "extid-"
+	gpsiMsisdnPrefix     = "msisdn-"
+	ueIdExtGroupIdPrefix = "extgroupid-"
+
+	// The NEF puts a single monitoring configuration in every Nudm_EE subscription
+	monEvtUdmReferenceId = "1"
+)
+
+// GetMonitoringEventSubscriptions Read all monitoring event subscriptions for a given AF
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.3.1
+// Request/Response  : 5.3.3.2.3.1
+func (p *Processor) GetMonitoringEventSubscriptions(
+	c *gin.Context,
+	afID string,
+) {
+	logger.MonEvtLog.Infof("GetMonitoringEventSubscriptions - afID[%s]", afID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var monSubs []nef_models.MonitoringEventSubscription
+	for _, sub := range af.MonSubs {
+		if sub.MonSub == nil {
+			continue
+		}
+		monSubs = append(monSubs, *sub.MonSub)
+	}
+	c.JSON(http.StatusOK, &monSubs)
+}
+
+// PostMonitoringEventSubscription Create a new monitoring event subscription
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.3.1
+// Request/Response  : 5.3.3.2.3.2
+func (p *Processor) PostMonitoringEventSubscription(
+	c *gin.Context,
+	afID string,
+	monSub *nef_models.MonitoringEventSubscription,
+) {
+	logger.MonEvtLog.Infof("PostMonitoringEventSubscription - afID[%s]", afID)
+
+	problemDetails := validateMonitoringEventSubscription(monSub)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(afID)
+	if af == nil {
+		af = nefCtx.NewAf(afID)
+		if af == nil {
+			pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	correID := nefCtx.NewCorreID()
+	afSub := af.NewMonSub(correID, monSub)
+	if afSub == nil {
+		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	reports, pd := p.subscribeMonitoringEvent(afID, afSub)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	// TS 29.122 clause 5.3.3.2.4: a one-time request answered immediately
+	// does not create a subscription resource
+	if monSub.MaximumNumberOfReports == 1 && len(reports) > 0 {
+		monSub.MonitoringEventReport = &reports[0]
+		afSub.Log.Infoln("One-time monitoring request is answered immediately")
+		c.JSON(http.StatusOK, monSub)
+		return
+	}
+	if len(reports) > 0 {
+		monSub.MonitoringEventReport = &reports[0]
+		afSub.NumReports = 1
+	}
+
+	monSub.Self = p.genMonEvtSubURI(afID, afSub.SubID)
+	af.MonSubs[afSub.SubID] = afSub
+	p.armMonSubExpiry(afID, afSub)
+	af.Log.Infoln("Monitoring event subscription is added")
+
+	nefCtx.AddAf(af)
+
+	c.Header("Location", monSub.Self)
+	c.JSON(http.StatusCreated, monSub)
+}
+
+// GetIndividualMonitoringEventSubscription Read a monitoring event subscription
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.3.1
+// Request/Response  : 5.3.3.3.3.1
+func (p *Processor) GetIndividualMonitoringEventSubscription(
+	c *gin.Context,
+	afID, subID string,
+) {
+	logger.MonEvtLog.Infof("GetIndividualMonitoringEventSubscription - afID[%s], subID[%s]", afID, subID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	sub, ok := af.MonSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	c.JSON(http.StatusOK, sub.MonSub)
+}
+
+// PutIndividualMonitoringEventSubscription Replace a monitoring event subscription
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.3.1
+// Request/Response  : 5.3.3.3.3.2
+func (p *Processor) PutIndividualMonitoringEventSubscription(
+	c *gin.Context,
+	afID, subID string,
+	monSub *nef_models.MonitoringEventSubscription,
+) {
+	logger.MonEvtLog.Infof("PutIndividualMonitoringEventSubscription - afID[%s], subID[%s]", afID, subID)
+
+	problemDetails := validateMonitoringEventSubscription(monSub)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub, ok := af.MonSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	// Subscribe with the new parameters first, so that the old southbound
+	// subscription is kept if the replacement is rejected
+	newSub := &context.AfMonSubscription{
+		SubID:        sub.SubID,
+		MonSub:       monSub,
+		NotifCorreID: sub.NotifCorreID,
+		Log:          sub.Log,
+	}
+	reports, pd := p.subscribeMonitoringEvent(afID, newSub)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	if pd = p.unsubscribeMonitoringEvent(sub); pd != nil {
+		sub.Log.Warnf("Failed to remove the replaced southbound subscription: %s", pd.Detail)
+	}
+
+	if len(reports) > 0 {
+		monSub.MonitoringEventReport = &reports[0]
+		newSub.NumReports = 1
+	}
+	monSub.Self = p.genMonEvtSubURI(afID, subID)
+	af.MonSubs[subID] = newSub
+	p.armMonSubExpiry(afID, newSub)
+	af.Persist()
+
+	c.JSON(http.StatusOK, monSub)
+}
+
+// DeleteIndividualMonitoringEventSubscription Delete a monitoring event subscription
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.3.1
+// Request/Response  : 5.3.3.3.3.3
+func (p *Processor) DeleteIndividualMonitoringEventSubscription(
+	c *gin.Context,
+	afID, subID string,
+) {
+	logger.MonEvtLog.Infof("DeleteIndividualMonitoringEventSubscription - afID[%s], subID[%s]", afID, subID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub, ok := af.MonSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	if pd := p.unsubscribeMonitoringEvent(sub); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	af.DeleteMonSub(subID)
+	c.Status(http.StatusNoContent)
+}
+
+// UdmEeNotification relays the Nudm_EE monitoring reports to the AF.
+// 3GPP TS 29.503 release 17 version 17.6.0
+// Resource structure: {callbackReference}
+// Request: array(MonitoringReport), Response: 204
+func (p *Processor) UdmEeNotification(
+	c *gin.Context,
+	corrID string,
+	udmReports []models.UdmEeMonitoringReport,
+) {
+	logger.MonEvtLog.Infof("UdmEeNotification - corrID[%s]", corrID)
+
+	var reports []nef_models.MonitoringEventReport
+	for i := range udmReports {
+		if report := convertUdmEeReportToMonitoringEventReport(&udmReports[i]); report != nil {
+			reports = append(reports, *report)
+		}
+	}
+	p.notifyMonitoringEventReports(c, corrID, reports)
+}
+
+// AmfEventNotification relays the Namf_EventExposure reports to the AF.
+// 3GPP TS 29.518 release 17 version 17.6.0
+// Resource structure: {eventNotifyUri}
+// Request: AmfEventNotification, Response: 204
+func (p *Processor) AmfEventNotification(
+	c *gin.Context,
+	corrID string,
+	amfNotif *models.AmfEventNotification,
+) {
+	logger.MonEvtLog.Infof("AmfEventNotification - corrID[%s]", corrID)
+
+	var reports []nef_models.MonitoringEventReport
+	for i := range amfNotif.ReportList {
+		if report := convertAmfEventReportToMonitoringEventReport(&amfNotif.ReportList[i]); report != nil {
+			reports = append(reports, *report)
+		}
+	}
+	p.notifyMonitoringEventReports(c, corrID, reports)
+}
+
+func (p *Processor) notifyMonitoringEventReports(
+	c *gin.Context,
+	corrID string,
+	reports []nef_models.MonitoringEventReport,
+) {
+	af, sub := p.Context().FindAfMonSub(corrID)
+	if sub == nil || sub.MonSub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	if len(reports) == 0 {
+		sub.Log.Debugln("No monitoring event report to relay")
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	af.Mu.Lock()
+	// Removed by the AF or on its limits meanwhile
+	if af.MonSubs[sub.SubID] != sub {
+		af.Mu.Unlock()
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	// The maximum number of reports of a group applies to each of its UEs,
+	// only UDM can count those
+	monSub := sub.MonSub
+	reachedMax := false
+	if maxReports := monSub.MaximumNumberOfReports; maxReports > 0 && monSub.ExternalGroupId == "" {
+		if remaining := maxReports - sub.NumReports; int32(len(reports)) >= remaining {
+			reports = reports[:remaining]
+			reachedMax = true
+		}
+		sub.NumReports += int32(len(reports))
+	}
+	notif := &nef_models.MonitoringNotification{
+		Subscription:           monSub.Self,
+		MonitoringEventReports: reports,
+	}
+	notifUri := monSub.NotificationDestination
+	if reachedMax {
+		// UDM and AMF end their subscriptions on the same limit
+		af.DeleteMonSub(sub.SubID)
+		sub.Log.Infoln("Maximum number of reports is reached, subscription is removed")
+	} else {
+		af.Persist()
+	}
+	af.Mu.Unlock()
+
+	if err := p.Notifier().MonEvtNotifier.NotifyAf(notifUri, notif); err != nil {
+		sub.Log.Errorf("Failed to notify AF of monitoring event: %+v", err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// armMonSubExpiry removes the monitoring event subscription once its
+// monitorExpireTime is reached. The caller must hold af.Mu.
+func (p *Processor) armMonSubExpiry(afID string, sub *context.AfMonSubscription) {
+	if sub.MonSub == nil || sub.MonSub.MonitorExpireTime == nil {
+		return
+	}
+	time.AfterFunc(time.Until(*sub.MonSub.MonitorExpireTime), func() {
+		p.expireMonSub(afID, sub)
+	})
+}
+
+// armRestoredMonSubExpiries arms the expiry of the monitoring event
+// subscriptions restored from the store.
+func (p *Processor) armRestoredMonSubExpiries() {
+	for _, af := range p.Context().Afs() {
+		af.Mu.RLock()
+		for _, sub := range af.MonSubs {
+			p.armMonSubExpiry(af.AfID, sub)
+		}
+		af.Mu.RUnlock()
+	}
+}
+
+// expireMonSub removes the expired monitoring event subscription, UDM and AMF
+// end their subscriptions on the same expiry. The subscription replaced or
+// deleted meanwhile is left alone.
+func (p *Processor) expireMonSub(afID string, sub *context.AfMonSubscription) {
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	if af.MonSubs[sub.SubID] != sub || time.Now().Before(*sub.MonSub.MonitorExpireTime) {
+		return
+	}
+	af.DeleteMonSub(sub.SubID)
+	sub.Log.Infoln("Monitoring event subscription is expired")
+}
+
+func validateMonitoringEventSubscription(
+	monSub *nef_models.MonitoringEventSubscription,
+) *models.ProblemDetails {
+	if monSub.NotificationDestination == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notificationDestination")
+	}
+
+	// TS29.122: One of "externalId", "msisdn" or "externalGroupId" shall be included.
+	if monSub.ExternalId == "" &&
+		monSub.Msisdn == "" &&
+		monSub.ExternalGroupId == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing one of externalId, msisdn or externalGroupId")
+	}
+
+	switch monSub.MonitoringType {
+	case nef_models.MonitoringType_LOSS_OF_CONNECTIVITY,
+		nef_models.MonitoringType_LOCATION_REPORTING,
+		nef_models.MonitoringType_ROAMING_STATUS:
+	case nef_models.MonitoringType_UE_REACHABILITY:
+		if monSub.ReachabilityType != nef_models.ReachabilityType_SMS &&
+			monSub.ReachabilityType != nef_models.ReachabilityType_DATA {
+			return openapi.ProblemDetailsMalformedReqSyntax("Missing or invalid reachabilityType")
+		}
+	case "":
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing monitoringType")
+	default:
+		return openapi.ProblemDetailsMalformedReqSyntax(
+			"Unsupported monitoringType: " + string(monSub.MonitoringType))
+	}
+	return nil
+}
+
+// subscribeMonitoringEvent creates the southbound subscription serving sub.
+// Location reporting is served by the AMF, the other monitoring types by the
+// UDM. The reports returned are those available at subscription time.
+func (p *Processor) subscribeMonitoringEvent(
+	afID string,
+	sub *context.AfMonSubscription,
+) ([]nef_models.MonitoringEventReport, *models.ProblemDetails) {
+	var reports []nef_models.MonitoringEventReport
+
+	if sub.MonSub.MonitoringType == nef_models.MonitoringType_LOCATION_REPORTING {
+		amfSub, pd := p.convertMonitoringEventSubscriptionToAmfEventSubscription(afID, sub)
+		if pd != nil {
+			return nil, pd
+		}
+
+		created, pd, err := p.Consumer().CreateAmfEventSubscription(amfSub)
+		switch {
+		case pd != nil:
+			return nil, pd
+		case err != nil:
+			return nil, &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Detail: "Query to AMF failed",
+			}
+		}
+		sub.AmfSubID = created.SubscriptionId
+		for i := range created.ReportList {
+			if report := convertAmfEventReportToMonitoringEventReport(&created.ReportList[i]); report != nil {
+				reports = append(reports, *report)
+			}
+		}
+		return reports, nil
+	}
+
+	sub.UeIdentity = monEvtUeIdentity(sub.MonSub)
+	eeSub := p.convertMonitoringEventSubscriptionToUdmEeSubscription(afID, sub)
+	created, pd, err := p.Consumer().CreateEeSubscription(sub.UeIdentity, eeSub)
+	switch {
+	case pd != nil:
+		return nil, pd
+	case err != nil:
+		return nil, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Detail: "Query to UDM failed",
+		}
+	}
+	if created.EeSubscription != nil {
+		sub.UdmEeSubID = created.EeSubscription.SubscriptionId
+	}
+	for i := range created.EventReports {
+		if report := convertUdmEeReportToMonitoringEventReport(&created.EventReports[i]); report != nil {
+			reports = append(reports, *report)
+		}
+	}
+	return reports, nil
+}
+
+func (p *Processor) unsubscribeMonitoringEvent(sub *context.AfMonSubscription) *models.ProblemDetails {
+	switch {
+	case sub.AmfSubID != "":
+		pd, err := p.Consumer().DeleteAmfEventSubscription(sub.AmfSubID)
+		switch {
+		case pd != nil:
+			return pd
+		case err != nil:
+			return &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Detail: "Query to AMF failed",
+			}
+		}
+	case sub.UdmEeSubID != "":
+		pd, err := p.Consumer().DeleteEeSubscription(sub.UeIdentity, sub.UdmEeSubID)
+		switch {
+		case pd != nil:
+			return pd
+		case err != nil:
+			return &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Detail: "Query to UDM failed",
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Processor) genMonEvtSubURI(
+	afID, subscriptionId string,
+) string {
+	// E.g. https://localhost:29505/3gpp-monitoring-event/v1/{afId}/subscriptions/{subscriptionId}
+	return p.Config().ServiceUri(factory.ServiceMonEvt) + "/" + afID + "/subscriptions/" + subscriptionId
+}
+
+func (p *Processor) genUdmEeNotificationUri(notifCorreID string) string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/udm-ee/" + notifCorreID
+}
+
+func (p *Processor) genAmfEeNotificationUri(notifCorreID string) string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/amf-ee/" + notifCorreID
+}
+
+// monEvtUeIdentity returns the Nudm_EE ueIdentity of the targeted UE or group.
+func monEvtUeIdentity(monSub *nef_models.MonitoringEventSubscription) string {
+	switch {
+	case monSub.ExternalId != "":
+		return gpsiExtIdPrefix + monSub.ExternalId
+	case monSub.Msisdn != "":
+		return gpsiMsisdnPrefix + monSub.Msisdn
+	default:
+		return ueIdExtGroupIdPrefix + monSub.ExternalGroupId
+	}
+}
+
+func (p *Processor) convertMonitoringEventSubscriptionToUdmEeSubscription(
+	afID string,
+	sub *context.AfMonSubscription,
+) *models.UdmEeEeSubscription {
+	monSub := sub.MonSub
+	monCfg := models.UdmEeMonitoringConfiguration{
+		MtcProviderInformation: monSub.MtcProviderId,
+		AfId:                   afID,
+	}
+
+	switch monSub.MonitoringType {
+	case nef_models.MonitoringType_LOSS_OF_CONNECTIVITY:
+		monCfg.EventType = models.UdmEeEventType_LOSS_OF_CONNECTIVITY
+		if monSub.MaximumDetectionTime > 0 {
+			monCfg.LossConnectivityCfg = &models.LossConnectivityCfg{
+				MaxDetectionTime: monSub.MaximumDetectionTime,
+			}
+		}
+	case nef_models.MonitoringType_UE_REACHABILITY:
+		if monSub.ReachabilityType == nef_models.ReachabilityType_SMS {
+			monCfg.EventType = models.UdmEeEventType_UE_REACHABILITY_FOR_SMS
+			monCfg.ReachabilityForSmsCfg = models.ReachabilityForSmsConfiguration_NAS
+		} else {
+			monCfg.EventType = models.UdmEeEventType_UE_REACHABILITY_FOR_DATA
+			monCfg.ReachabilityForDataCfg = &models.UdmEeReachabilityForDataConfiguration{
+				ReportCfg:   models.ReachabilityForDataReportConfig_DIRECT_REPORT,
+				MinInterval: monSub.MinimumReportInterval,
+			}
+			monCfg.MaximumLatency = monSub.MaximumLatency
+			monCfg.MaximumResponseTime = monSub.MaximumResponseTime
+			monCfg.SuggestedPacketNumDl = monSub.SuggestedNumberOfDlPackets
+			monCfg.IdleStatusInd = monSub.IdleStatusIndication
+		}
+	case nef_models.MonitoringType_ROAMING_STATUS:
+		monCfg.EventType = models.UdmEeEventType_ROAMING_STATUS
+	}
+
+	reportingOptions := &models.UdmEeReportingOptions{
+		ReportMode:      models.EventReportMode_ON_EVENT_DETECTION,
+		MaxNumOfReports: monSub.MaximumNumberOfReports,
+		Expiry:          monSub.MonitorExpireTime,
+		GuardTime:       monSub.GroupReportGuardTime,
+	}
+	if monSub.RepPeriod > 0 {
+		reportingOptions.ReportMode = models.EventReportMode_PERIODIC
+		reportingOptions.ReportPeriod = monSub.RepPeriod
+	}
+
+	return &models.UdmEeEeSubscription{
+		CallbackReference: p.genUdmEeNotificationUri(sub.NotifCorreID),
+		MonitoringConfigurations: map[string]models.UdmEeMonitoringConfiguration{
+			monEvtUdmReferenceId: monCfg,
+		},
+		ReportingOptions:    reportingOptions,
+		NotifyCorrelationId: sub.NotifCorreID,
+	}
+}
+
+func (p *Processor) convertMonitoringEventSubscriptionToAmfEventSubscription(
+	afID string,
+	sub *context.AfMonSubscription,
+) (*models.AmfCreateEventSubscription, *models.ProblemDetails) {
+	monSub := sub.MonSub
+	amfSub := &models.AmfEventSubscription{
+		EventList: []models.AmfEvent{
+			{
+				Type:          models.AmfEventType_LOCATION_REPORT,
+				ImmediateFlag: true,
+				MinInterval:   monSub.MinimumReportInterval,
+			},
+		},
+		EventNotifyUri:      p.genAmfEeNotificationUri(sub.NotifCorreID),
+		NotifyCorrelationId: sub.NotifCorreID,
+		NfId:                p.Context().NfInstID(),
+		SourceNfType:        models.NrfNfManagementNfType_NEF,
+		Options: &models.AmfEventMode{
+			Trigger:    models.AmfEventTrigger_CONTINUOUS,
+			MaxReports: monSub.MaximumNumberOfReports,
+			Expiry:     monSub.MonitorExpireTime,
+		},
+	}
+	switch {
+	case monSub.MaximumNumberOfReports == 1:
+		amfSub.Options.Trigger = models.AmfEventTrigger_ONE_TIME
+	case monSub.RepPeriod > 0:
+		amfSub.Options.Trigger = models.AmfEventTrigger_PERIODIC
+		amfSub.Options.RepPeriod = monSub.RepPeriod
+	}
+
+	if monSub.ExternalId != "" || monSub.Msisdn != "" {
+		amfSub.Gpsi = monEvtUeIdentity(monSub)
+	} else {
+		interGroupId, pd := p.resolveInterGroupId(afID, monSub.ExternalGroupId)
+		if pd != nil {
+			return nil, pd
+		}
+		amfSub.GroupId = interGroupId
+	}
+
+	return &models.AmfCreateEventSubscription{
+		Subscription: amfSub,
+	}, nil
+}
+
+func convertUdmEeReportToMonitoringEventReport(
+	udmReport *models.UdmEeMonitoringReport,
+) *nef_models.MonitoringEventReport {
+	report := &nef_models.MonitoringEventReport{
+		EventTime: udmReport.TimeStamp,
+	}
+	setMonitoringEventReportGpsi(report, udmReport.Gpsi)
+
+	switch udmReport.EventType {
+	case models.UdmEeEventType_LOSS_OF_CONNECTIVITY:
+		report.MonitoringType = nef_models.MonitoringType_LOSS_OF_CONNECTIVITY
+		if udmReport.Report != nil {
+			report.LossOfConnectReason = convertLossOfConnectReason(udmReport.Report.LossOfConnectReason)
+		}
+	case models.UdmEeEventType_UE_REACHABILITY_FOR_DATA:
+		report.MonitoringType = nef_models.MonitoringType_UE_REACHABILITY
+		report.ReachabilityType = nef_models.ReachabilityType_DATA
+		if udmReport.ReachabilityReport != nil {
+			report.MaxUEAvailabilityTime = udmReport.ReachabilityReport.MaxAvailabilityTime
+		}
+	case models.UdmEeEventType_UE_REACHABILITY_FOR_SMS:
+		report.MonitoringType = nef_models.MonitoringType_UE_REACHABILITY
+		report.ReachabilityType = nef_models.ReachabilityType_SMS
+		if udmReport.ReachabilityForSmsReport != nil {
+			report.MaxUEAvailabilityTime = udmReport.ReachabilityForSmsReport.MaxAvailabilityTime
+		}
+	case models.UdmEeEventType_ROAMING_STATUS:
+		report.MonitoringType = nef_models.MonitoringType_ROAMING_STATUS
+		if udmReport.Report != nil {
+			roaming := udmReport.Report.Roaming
+			report.RoamingStatus = &roaming
+			report.PlmnId = udmReport.Report.NewServingPlmn
+		}
+	case models.UdmEeEventType_LOCATION_REPORTING:
+		report.MonitoringType = nef_models.MonitoringType_LOCATION_REPORTING
+		if udmReport.Report != nil {
+			report.LocationInfo = convertUserLocationToLocationInfo(udmReport.Report.Location)
+		}
+	default:
+		return nil
+	}
+	return report
+}
+
+func convertAmfEventReportToMonitoringEventReport(
+	amfReport *models.AmfEventReport,
+) *nef_models.MonitoringEventReport {
+	if amfReport.Type != models.AmfEventType_LOCATION_REPORT {
+		return nil
+	}
+	report := &nef_models.MonitoringEventReport{
+		MonitoringType: nef_models.MonitoringType_LOCATION_REPORTING,
+		LocationInfo:   convertUserLocationToLocationInfo(amfReport.Location),
+		EventTime:      amfReport.TimeStamp,
+	}
+	setMonitoringEventReportGpsi(report, amfReport.Gpsi)
+	return report
+}
+
+func setMonitoringEventReportGpsi(report *nef_models.MonitoringEventReport, gpsi string) {
+	switch {
+	case strings.HasPrefix(gpsi, gpsiExtIdPrefix):
+		report.ExternalId = strings.TrimPrefix(gpsi, gpsiExtIdPrefix)
+	case strings.HasPrefix(gpsi, gpsiMsisdnPrefix):
+		report.Msisdn = strings.TrimPrefix(gpsi, gpsiMsisdnPrefix)
+	}
+}
+
+func convertLossOfConnectReason(reason models.LossOfConnectivityReason) *int32 {
+	var v int32
+	switch reason {
+	case models.LossOfConnectivityReason_DEREGISTERED:
+		v = nef_models.LossOfConnectReason_UE_DETACHED
+	case models.LossOfConnectivityReason_MAX_DETECTION_TIME_EXPIRED:
+		v = nef_models.LossOfConnectReason_MAX_DETECTION_TIME_EXPIRED
+	case models.LossOfConnectivityReason_PURGED:
+		v = nef_models.LossOfConnectReason_UE_PURGED
+	default:
+		return nil
+	}
+	return &v
+}
+
+func convertUserLocationToLocationInfo(loc *models.UserLocation) *nef_models.LocationInfo {
+	switch {
+	case loc == nil:
+		return nil
+	case loc.NrLocation != nil:
+		info := &nef_models.LocationInfo{
+			AgeOfLocationInfo: loc.NrLocation.AgeOfLocationInformation,
+		}
+		if loc.NrLocation.Ncgi != nil {
+			info.CellId = loc.NrLocation.Ncgi.NrCellId
+		}
+		if loc.NrLocation.Tai != nil {
+			info.TrackingAreaId = loc.NrLocation.Tai.Tac
+		}
+		return info
+	case loc.EutraLocation != nil:
+		info := &nef_models.LocationInfo{
+			AgeOfLocationInfo: loc.EutraLocation.AgeOfLocationInformation,
+		}
+		if loc.EutraLocation.Ecgi != nil {
+			info.CellId = loc.EutraLocation.Ecgi.EutraCellId
+		}
+		if loc.EutraLocation.Tai != nil {
+			info.TrackingAreaId = loc.EutraLocation.Tai.Tac
+		}
+		return info
+	default:
+		return nil
+	}
+}
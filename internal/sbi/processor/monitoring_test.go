@@ -0,0 +1,238 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var (
+	monSub1ForAf1 = nef_models.MonitoringEventSubscription{
+		ExternalId:              "ue1@nef.free5gc.org",
+		NotificationDestination: "http://127.0.0.100:8000/monitoring/notify",
+		MonitoringType:          nef_models.MonitoringType_LOSS_OF_CONNECTIVITY,
+		MaximumDetectionTime:    60,
+	}
+
+	monSub2ForAf1 = nef_models.MonitoringEventSubscription{
+		Msisdn:                  "0900000000",
+		NotificationDestination: "http://127.0.0.100:8000/monitoring/notify",
+		MonitoringType:          nef_models.MonitoringType_LOCATION_REPORTING,
+		LocationType:            nef_models.LocationType_CURRENT_LOCATION,
+		MaximumNumberOfReports:  1,
+	}
+)
+
+func TestMonitoringEventSubscription(t *testing.T) {
+	// Keep the NRF stubs registered in TestMain, only the mocks set up
+	// here are checked.
+	initNRFDiscStub(models.NrfNfManagementNfType_UDM, models.ServiceName_NUDM_EE, "127.0.0.3", "http://127.0.0.3:8000")
+	initNRFDiscStub(models.NrfNfManagementNfType_AMF, models.ServiceName_NAMF_EVTS, "127.0.0.18", "http://127.0.0.18:8000")
+
+	eeMock := gock.New("http://127.0.0.3:8000/nudm-ee/v1").
+		Post("/extid-ue1@nef.free5gc.org/ee-subscriptions").
+		BodyString(`"eventType":"LOSS_OF_CONNECTIVITY".*"maxDetectionTime":60`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.3:8000/nudm-ee/v1/extid-ue1@nef.free5gc.org/ee-subscriptions/ee1").
+		JSON(models.UdmEeCreatedEeSubscription{
+			EeSubscription: &models.UdmEeEeSubscription{SubscriptionId: "ee1"},
+		}).Mock
+	amfMock := gock.New("http://127.0.0.18:8000/namf-evts/v1").
+		Post("/subscriptions").
+		BodyString(`"type":"LOCATION_REPORT".*"gpsi":"msisdn-0900000000"`).
+		Reply(http.StatusCreated).
+		JSON(models.AmfCreatedEventSubscription{
+			SubscriptionId: "amf1",
+			ReportList: []models.AmfEventReport{
+				{
+					Type: models.AmfEventType_LOCATION_REPORT,
+					Gpsi: "msisdn-0900000000",
+					Location: &models.UserLocation{
+						NrLocation: &models.NrLocation{
+							Tai:  &models.Tai{Tac: "000001"},
+							Ncgi: &models.Ncgi{NrCellId: "000000010"},
+						},
+					},
+				},
+			},
+		}).Mock
+
+	monSubInvalid := monSub1ForAf1
+	monSubInvalid.NotificationDestination = ""
+
+	testCases := []struct {
+		description    string
+		monSub         *nef_models.MonitoringEventSubscription
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Loss of connectivity, subscribed at UDM",
+			monSub:         &monSub1ForAf1,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC2: Missing notificationDestination",
+			monSub:         &monSubInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC3: One-time location request, answered by AMF immediately",
+			monSub:         &monSub2ForAf1,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	nefCtx := nefApp.Context()
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			monSub := *tc.monSub
+			nefApp.Processor().PostMonitoringEventSubscription(c, "af1", &monSub)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+		})
+	}
+	require.True(t, eeMock.Done())
+	require.True(t, amfMock.Done())
+
+	// Only the UDM subscription creates a resource
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	require.Len(t, af.MonSubs, 1)
+	afSub, ok := af.MonSubs["1"]
+	require.True(t, ok)
+	require.Equal(t, "ee1", afSub.UdmEeSubID)
+	require.Equal(t, "extid-ue1@nef.free5gc.org", afSub.UeIdentity)
+
+	// Reports from the UDM are relayed to the AF
+	afMock := gock.New("http://127.0.0.100:8000").
+		Post("/monitoring/notify").
+		BodyString(`"subscription":".*/3gpp-monitoring-event/v1/af1/subscriptions/1".*` +
+			`"externalId":"ue1@nef.free5gc.org".*"lossOfConnectReason":2.*"monitoringType":"LOSS_OF_CONNECTIVITY"`).
+		Reply(http.StatusNoContent).Mock
+
+	now := time.Now()
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().UdmEeNotification(c, afSub.NotifCorreID, []models.UdmEeMonitoringReport{
+		{
+			ReferenceId: 1,
+			EventType:   models.UdmEeEventType_LOSS_OF_CONNECTIVITY,
+			Gpsi:        "extid-ue1@nef.free5gc.org",
+			TimeStamp:   &now,
+			Report: &models.UdmEeReport{
+				LossOfConnectReason: models.LossOfConnectivityReason_MAX_DETECTION_TIME_EXPIRED,
+			},
+		},
+	})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, afMock.Done())
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().UdmEeNotification(c, "unknown", nil)
+	require.Equal(t, http.StatusNotFound, httpRecorder.Code)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().GetMonitoringEventSubscriptions(c, "af1")
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	expectedSub := monSub1ForAf1
+	expectedSub.Self = nefApp.Config().ServiceUri(factory.ServiceMonEvt) + "/af1/subscriptions/1"
+	assertJSONBodyEqual(t, &[]nef_models.MonitoringEventSubscription{expectedSub}, httpRecorder.Body.Bytes())
+
+	deleteMock := gock.New("http://127.0.0.3:8000/nudm-ee/v1").
+		Delete("/extid-ue1@nef.free5gc.org/ee-subscriptions/ee1").
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().DeleteIndividualMonitoringEventSubscription(c, "af1", "1")
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, deleteMock.Done())
+	require.Empty(t, af.MonSubs)
+
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
+func TestMonitoringEventSubscriptionLimits(t *testing.T) {
+	initNRFDiscStub(models.NrfNfManagementNfType_UDM, models.ServiceName_NUDM_EE, "127.0.0.3", "http://127.0.0.3:8000")
+
+	nefCtx := nefApp.Context()
+	postMonSub := func(monSub nef_models.MonitoringEventSubscription, eeSubID string) {
+		eeMock := gock.New("http://127.0.0.3:8000/nudm-ee/v1").
+			Post("/extid-ue1@nef.free5gc.org/ee-subscriptions").
+			Reply(http.StatusCreated).
+			JSON(models.UdmEeCreatedEeSubscription{
+				EeSubscription: &models.UdmEeEeSubscription{SubscriptionId: eeSubID},
+			}).Mock
+
+		httpRecorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(httpRecorder)
+		nefApp.Processor().PostMonitoringEventSubscription(c, "af7", &monSub)
+		require.Equal(t, http.StatusCreated, httpRecorder.Code)
+		require.True(t, eeMock.Done())
+	}
+	notifyUdmEeReports := func(corrID string, num int) {
+		var reports []models.UdmEeMonitoringReport
+		for i := 0; i < num; i++ {
+			reports = append(reports, models.UdmEeMonitoringReport{
+				ReferenceId: 1,
+				EventType:   models.UdmEeEventType_LOSS_OF_CONNECTIVITY,
+				Report: &models.UdmEeReport{
+					LossOfConnectReason: models.LossOfConnectivityReason_MAX_DETECTION_TIME_EXPIRED,
+				},
+			})
+		}
+		httpRecorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(httpRecorder)
+		nefApp.Processor().UdmEeNotification(c, corrID, reports)
+		c.Writer.WriteHeaderNow()
+		require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	}
+
+	// The subscription is removed once the maximum number of reports is relayed
+	monSub := monSub1ForAf1
+	monSub.MaximumNumberOfReports = 3
+	postMonSub(monSub, "ee1")
+	af := nefCtx.GetAf("af7")
+	require.NotNil(t, af)
+	corrID := af.MonSubs["1"].NotifCorreID
+
+	afMock := gock.New("http://127.0.0.100:8000").
+		Post("/monitoring/notify").
+		Times(2).
+		Reply(http.StatusNoContent)
+	notifyUdmEeReports(corrID, 2)
+	require.Contains(t, af.MonSubs, "1")
+	// Only the last report allowed is relayed
+	notifyUdmEeReports(corrID, 2)
+	require.True(t, afMock.Done())
+	require.NotContains(t, af.MonSubs, "1")
+
+	// The subscription is removed once its monitorExpireTime is reached
+	expiry := time.Now().Add(100 * time.Millisecond)
+	monSub = monSub1ForAf1
+	monSub.MonitorExpireTime = &expiry
+	postMonSub(monSub, "ee2")
+	require.Eventually(t, func() bool {
+		af.Mu.RLock()
+		defer af.Mu.RUnlock()
+		return len(af.MonSubs) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	nefCtx.DeleteAf("af7")
+	nefCtx.ResetCorreID()
+}
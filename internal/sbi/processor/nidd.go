@@ -0,0 +1,798 @@
+package processor
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+// GetNiddConfigurations Read all NIDD configurations for a given SCS/AS
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.2.3.1
+func (p *Processor) GetNiddConfigurations(
+	c *gin.Context,
+	scsAsID string,
+) {
+	logger.NiddLog.Infof("GetNiddConfigurations - scsAsID[%s]", scsAsID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var niddConfs []nef_models.NiddConfiguration
+	for _, conf := range af.NiddConfs {
+		if conf.NiddConf == nil {
+			continue
+		}
+		niddConfs = append(niddConfs, *niddConfigurationWithDlTransfers(conf))
+	}
+	c.JSON(http.StatusOK, &niddConfs)
+}
+
+// PostNiddConfiguration Create a new NIDD configuration
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.2.3.2
+func (p *Processor) PostNiddConfiguration(
+	c *gin.Context,
+	scsAsID string,
+	niddConf *nef_models.NiddConfiguration,
+) {
+	logger.NiddLog.Infof("PostNiddConfiguration - scsAsID[%s]", scsAsID)
+
+	problemDetails := validateNiddConfiguration(niddConf)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(scsAsID)
+	if af == nil {
+		af = nefCtx.NewAf(scsAsID)
+		if af == nil {
+			pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	gpsi := niddGpsi(niddConf.ExternalId, niddConf.Msisdn)
+	if af.FindNiddConf(gpsi) != nil {
+		pd := openapi.ProblemDetailsForbidden("NIDD configuration of the UE already exists",
+			"NIDD_CONFIGURATION_ALREADY_EXISTS")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	conf := af.NewNiddConf(niddConf, gpsi)
+	if conf == nil {
+		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	niddConf.Self = p.genNiddConfURI(scsAsID, conf.ConfigID)
+	niddConf.Status = nef_models.NiddStatus_ACTIVE
+	// The downlink data is delivered through the downlink data deliveries
+	niddConf.NiddDownlinkDataTransfers = nil
+
+	af.NiddConfs[conf.ConfigID] = conf
+	af.Log.Infoln("NIDD configuration is added")
+
+	nefCtx.AddAf(af)
+
+	c.Header("Location", niddConf.Self)
+	c.JSON(http.StatusCreated, niddConf)
+}
+
+// GetIndividualNiddConfiguration Read a NIDD configuration
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.3.3.1
+func (p *Processor) GetIndividualNiddConfiguration(
+	c *gin.Context,
+	scsAsID, configID string,
+) {
+	logger.NiddLog.Infof("GetIndividualNiddConfiguration - scsAsID[%s], configID[%s]", scsAsID, configID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	conf, ok := af.NiddConfs[configID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("NIDD configuration is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	c.JSON(http.StatusOK, niddConfigurationWithDlTransfers(conf))
+}
+
+// PatchIndividualNiddConfiguration Modify a NIDD configuration. The maximum
+// packet size applies to the NIDD PDU sessions established afterwards.
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.3.3.3
+func (p *Processor) PatchIndividualNiddConfiguration(
+	c *gin.Context,
+	scsAsID, configID string,
+	niddConfPatch *nef_models.NiddConfigurationPatch,
+) {
+	logger.NiddLog.Infof("PatchIndividualNiddConfiguration - scsAsID[%s], configID[%s]", scsAsID, configID)
+
+	problemDetails := validateNiddConfigurationPatch(niddConfPatch)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	conf, ok := af.NiddConfs[configID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("NIDD configuration is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	niddConf := conf.NiddConf
+	if niddConfPatch.Duration != nil {
+		niddConf.Duration = niddConfPatch.Duration
+	}
+	if niddConfPatch.PdnEstablishmentOption != "" {
+		niddConf.PdnEstablishmentOption = niddConfPatch.PdnEstablishmentOption
+	}
+	if niddConfPatch.NotificationDestination != "" {
+		niddConf.NotificationDestination = niddConfPatch.NotificationDestination
+	}
+	if niddConfPatch.MaximumPacketSize != 0 {
+		niddConf.MaximumPacketSize = niddConfPatch.MaximumPacketSize
+	}
+	af.Persist()
+	conf.Log.Infoln("NIDD configuration is modified")
+
+	c.JSON(http.StatusOK, niddConfigurationWithDlTransfers(conf))
+}
+
+// DeleteIndividualNiddConfiguration Delete a NIDD configuration, the buffered
+// downlink data is discarded
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.3.3.4
+func (p *Processor) DeleteIndividualNiddConfiguration(
+	c *gin.Context,
+	scsAsID, configID string,
+) {
+	logger.NiddLog.Infof("DeleteIndividualNiddConfiguration - scsAsID[%s], configID[%s]", scsAsID, configID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	if _, ok := af.NiddConfs[configID]; !ok {
+		pd := openapi.ProblemDetailsDataNotFound("NIDD configuration is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	af.DeleteNiddConf(configID)
+	c.Status(http.StatusNoContent)
+}
+
+// GetNiddDownlinkDataDeliveries Read all buffered downlink data deliveries of a
+// NIDD configuration
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.4.3.1
+func (p *Processor) GetNiddDownlinkDataDeliveries(
+	c *gin.Context,
+	scsAsID, configID string,
+) {
+	logger.NiddLog.Infof("GetNiddDownlinkDataDeliveries - scsAsID[%s], configID[%s]", scsAsID, configID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	conf, ok := af.NiddConfs[configID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("NIDD configuration is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	c.JSON(http.StatusOK, niddConfigurationWithDlTransfers(conf).NiddDownlinkDataTransfers)
+}
+
+// PostNiddDownlinkDataDelivery Deliver downlink data to the UE, the data is
+// buffered when the UE cannot be reached
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.4.3.2
+func (p *Processor) PostNiddDownlinkDataDelivery(
+	c *gin.Context,
+	scsAsID, configID string,
+	dlTransfer *nef_models.NiddDownlinkDataTransfer,
+) {
+	logger.NiddLog.Infof("PostNiddDownlinkDataDelivery - scsAsID[%s], configID[%s]", scsAsID, configID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	conf, ok := af.NiddConfs[configID]
+	if !ok || conf.NiddConf == nil {
+		af.Mu.RUnlock()
+		pd := openapi.ProblemDetailsDataNotFound("NIDD configuration is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	niddConf, gpsi := *conf.NiddConf, conf.Gpsi
+	af.Mu.RUnlock()
+
+	problemDetails := validateNiddDownlinkDataTransfer(&niddConf, dlTransfer)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	if pd := p.deliverNiddDlTransfer(scsAsID, gpsi, &niddConf, dlTransfer); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	if dlTransfer.DeliveryStatus == nef_models.DeliveryStatus_SUCCESS {
+		c.JSON(http.StatusOK, dlTransfer)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	// The configuration may have been deleted during the delivery
+	if af.NiddConfs[configID] != conf {
+		pd := openapi.ProblemDetailsDataNotFound("NIDD configuration is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	// The UE cannot be reached, the data is buffered until it can
+	dl := conf.NewDlTransfer(dlTransfer)
+	dlTransfer.Self = p.genNiddDlTransferURI(scsAsID, configID, dl.DlID)
+	conf.DlTransfers[dl.DlID] = dl
+	p.armNiddDlTransferExpiry(scsAsID, configID, dl)
+	af.Persist()
+
+	c.Header("Location", dlTransfer.Self)
+	c.JSON(http.StatusCreated, dlTransfer)
+}
+
+// GetIndividualNiddDownlinkDataDelivery Read a buffered downlink data delivery
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.5.3.1
+func (p *Processor) GetIndividualNiddDownlinkDataDelivery(
+	c *gin.Context,
+	scsAsID, configID, dlID string,
+) {
+	logger.NiddLog.Infof("GetIndividualNiddDownlinkDataDelivery - scsAsID[%s], configID[%s], dlID[%s]",
+		scsAsID, configID, dlID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	dl, pd := findNiddDlTransfer(af, configID, dlID)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	c.JSON(http.StatusOK, dl.DlTransfer)
+}
+
+// PutIndividualNiddDownlinkDataDelivery Replace the data of a buffered
+// downlink data delivery
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.5.3.2
+func (p *Processor) PutIndividualNiddDownlinkDataDelivery(
+	c *gin.Context,
+	scsAsID, configID, dlID string,
+	dlTransfer *nef_models.NiddDownlinkDataTransfer,
+) {
+	logger.NiddLog.Infof("PutIndividualNiddDownlinkDataDelivery - scsAsID[%s], configID[%s], dlID[%s]",
+		scsAsID, configID, dlID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	dl, pd := findNiddDlTransfer(af, configID, dlID)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	conf := af.NiddConfs[configID]
+	problemDetails := validateNiddDownlinkDataTransfer(conf.NiddConf, dlTransfer)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+
+	// The replacement stays buffered, the UE reachability has not changed
+	dlTransfer.Self = dl.DlTransfer.Self
+	dlTransfer.DeliveryStatus = dl.DlTransfer.DeliveryStatus
+	dlTransfer.RequestedRetransmissionTime = dl.DlTransfer.RequestedRetransmissionTime
+	dl.DlTransfer = dlTransfer
+	dl.Expiry = nil
+	if dlTransfer.MaximumLatency > 0 {
+		expiry := time.Now().Add(time.Duration(dlTransfer.MaximumLatency) * time.Second)
+		dl.Expiry = &expiry
+		p.armNiddDlTransferExpiry(scsAsID, configID, dl)
+	}
+	af.Persist()
+
+	c.JSON(http.StatusOK, dlTransfer)
+}
+
+// DeleteIndividualNiddDownlinkDataDelivery Cancel a buffered downlink data
+// delivery
+// 3GPP TS 29.122 Release 17 version 17.6.0
+// Resource structure: 5.6.1
+// Request/Response  : 5.6.3.5.3.4
+func (p *Processor) DeleteIndividualNiddDownlinkDataDelivery(
+	c *gin.Context,
+	scsAsID, configID, dlID string,
+) {
+	logger.NiddLog.Infof("DeleteIndividualNiddDownlinkDataDelivery - scsAsID[%s], configID[%s], dlID[%s]",
+		scsAsID, configID, dlID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	if _, pd := findNiddDlTransfer(af, configID, dlID); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	delete(af.NiddConfs[configID].DlTransfers, dlID)
+	af.Persist()
+	c.Status(http.StatusNoContent)
+}
+
+// deliverNiddDlTransfer delivers the downlink data to the UE through the SMF of
+// its NIDD PDU session and sets the delivery status of dlTransfer. The data is
+// to be buffered when the status is BUFFERING or
+// BUFFERING_TEMPORARILY_NOT_REACHABLE. The caller must not hold af.Mu, the
+// SMF and AMF are queried.
+func (p *Processor) deliverNiddDlTransfer(
+	afID, gpsi string,
+	niddConf *nef_models.NiddConfiguration,
+	dlTransfer *nef_models.NiddDownlinkDataTransfer,
+) *models.ProblemDetails {
+	smCtx := p.Context().FindSmContext(afID, gpsi)
+	if smCtx == nil {
+		if niddPdnEstablishmentOption(niddConf, dlTransfer) == nef_models.PdnEstablishmentOptions_INDICATE_ERROR {
+			return openapi.ProblemDetailsDataNotFound("NIDD PDU session of the UE is not established")
+		}
+		dlTransfer.DeliveryStatus = nef_models.DeliveryStatus_BUFFERING
+		return nil
+	}
+
+	smCtx.Mu.RLock()
+	dlNiddEndPoint, supi := smCtx.CreateData.DlNiddEndPoint, smCtx.CreateData.Supi
+	smCtx.Mu.RUnlock()
+
+	// Validated to be base64 encoded
+	data, _ := base64.StdEncoding.DecodeString(dlTransfer.Data)
+
+	// A UE not reachable is paged once before the data is buffered
+	for paged := false; ; paged = true {
+		deliverErr, pd, err := p.Consumer().DeliverNiddMtData(dlNiddEndPoint, data)
+		switch {
+		case pd != nil:
+			return pd
+		case err != nil:
+			return openapi.ProblemDetailsSystemFailure("Query to SMF failed")
+		case deliverErr == nil:
+			dlTransfer.DeliveryStatus = nef_models.DeliveryStatus_SUCCESS
+			dlTransfer.RequestedRetransmissionTime = nil
+			return nil
+		}
+
+		if !paged {
+			reachability, pd, err := p.Consumer().EnableUeReachability(supi)
+			if pd == nil && err == nil && reachability == models.UeReachability_REACHABLE {
+				continue
+			}
+		}
+
+		dlTransfer.DeliveryStatus = nef_models.DeliveryStatus_BUFFERING_TEMPORARILY_NOT_REACHABLE
+		dlTransfer.RequestedRetransmissionTime = nil
+		if deliverErr.MaxWaitingTime > 0 {
+			retransTime := time.Now().Add(time.Duration(deliverErr.MaxWaitingTime) * time.Second)
+			dlTransfer.RequestedRetransmissionTime = &retransTime
+		}
+		return nil
+	}
+}
+
+// flushNiddDlTransfers delivers the downlink data buffered for the UE now that
+// it can be reached, the AF is notified of the delivery status of each. The
+// data still not deliverable stays buffered.
+func (p *Processor) flushNiddDlTransfers(afID, gpsi string) {
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	conf := af.FindNiddConf(gpsi)
+	if conf == nil || conf.NiddConf == nil {
+		af.Mu.Unlock()
+		return
+	}
+	niddConf := *conf.NiddConf
+
+	// The data being delivered by another flush is left to it
+	var dls []*context.AfNiddDlTransfer
+	for _, dl := range conf.BufferedDlTransfers() {
+		if !dl.Delivering {
+			dl.Delivering = true
+			dls = append(dls, dl)
+		}
+	}
+	af.Mu.Unlock()
+
+	for _, dl := range dls {
+		p.flushNiddDlTransfer(af, conf, &niddConf, dl)
+	}
+}
+
+// flushNiddDlTransfer delivers a buffered downlink data marked as being
+// delivered, the data delivered, failed or expired is removed and the AF
+// notified.
+func (p *Processor) flushNiddDlTransfer(
+	af *context.AfData,
+	conf *context.AfNiddConfiguration,
+	niddConf *nef_models.NiddConfiguration,
+	dl *context.AfNiddDlTransfer,
+) {
+	af.Mu.RLock()
+	buffered := dl.DlTransfer
+	dlTransfer := *buffered
+	af.Mu.RUnlock()
+
+	if !niddDlTransferExpired(dl, time.Now()) {
+		if pd := p.deliverNiddDlTransfer(af.AfID, conf.Gpsi, niddConf, &dlTransfer); pd != nil {
+			conf.Log.Warnf("Buffered downlink data[%s] delivery failed: %s", dl.DlID, pd.Detail)
+			dlTransfer.DeliveryStatus = nef_models.DeliveryStatus_FAILURE
+		}
+	}
+
+	af.Mu.Lock()
+	dl.Delivering = false
+	// Cancelled or replaced by the AF during the delivery
+	if af.NiddConfs[conf.ConfigID] != conf || conf.DlTransfers[dl.DlID] != dl || dl.DlTransfer != buffered {
+		af.Mu.Unlock()
+		return
+	}
+
+	switch dlTransfer.DeliveryStatus {
+	case nef_models.DeliveryStatus_BUFFERING, nef_models.DeliveryStatus_BUFFERING_TEMPORARILY_NOT_REACHABLE:
+		if !niddDlTransferExpired(dl, time.Now()) {
+			dl.DlTransfer = &dlTransfer
+			af.Persist()
+			af.Mu.Unlock()
+			return
+		}
+		dlTransfer.DeliveryStatus = nef_models.DeliveryStatus_FAILURE_TIMEOUT
+	}
+	delete(conf.DlTransfers, dl.DlID)
+	af.Persist()
+	notifUri := niddConf.NotificationDestination
+	af.Mu.Unlock()
+
+	p.notifyNiddDeliveryStatus(conf, dl.DlID, notifUri, &dlTransfer)
+}
+
+// armNiddDlTransferExpiry reports the buffered downlink data with a
+// maximumLatency as FAILURE_TIMEOUT to the AF once it expires, whether or not
+// the UE can be reached by then. The caller must hold af.Mu.
+func (p *Processor) armNiddDlTransferExpiry(afID, configID string, dl *context.AfNiddDlTransfer) {
+	if dl.Expiry == nil {
+		return
+	}
+	dlID := dl.DlID
+	time.AfterFunc(time.Until(*dl.Expiry), func() {
+		p.expireNiddDlTransfer(afID, configID, dlID)
+	})
+}
+
+// armRestoredNiddDlTransferExpiries arms the expiry of the downlink data
+// restored from the store.
+func (p *Processor) armRestoredNiddDlTransferExpiries() {
+	for _, af := range p.Context().Afs() {
+		af.Mu.RLock()
+		for configID, conf := range af.NiddConfs {
+			for _, dl := range conf.DlTransfers {
+				p.armNiddDlTransferExpiry(af.AfID, configID, dl)
+			}
+		}
+		af.Mu.RUnlock()
+	}
+}
+
+// expireNiddDlTransfer removes the expired buffered downlink data and notifies
+// the AF. The data delivered, cancelled or given another maximumLatency
+// meanwhile is left alone, as is the data being delivered.
+func (p *Processor) expireNiddDlTransfer(afID, configID, dlID string) {
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	dl, pd := findNiddDlTransfer(af, configID, dlID)
+	if pd != nil || dl.Delivering || !niddDlTransferExpired(dl, time.Now()) {
+		af.Mu.Unlock()
+		return
+	}
+	conf := af.NiddConfs[configID]
+	delete(conf.DlTransfers, dlID)
+	af.Persist()
+	dlTransfer := *dl.DlTransfer
+	dlTransfer.DeliveryStatus = nef_models.DeliveryStatus_FAILURE_TIMEOUT
+	notifUri := conf.NiddConf.NotificationDestination
+	af.Mu.Unlock()
+
+	p.notifyNiddDeliveryStatus(conf, dlID, notifUri, &dlTransfer)
+}
+
+// notifyNiddDeliveryStatus notifies the AF of the final delivery status of a
+// buffered downlink data. The caller must not hold af.Mu.
+func (p *Processor) notifyNiddDeliveryStatus(
+	conf *context.AfNiddConfiguration,
+	dlID, notifUri string,
+	dlTransfer *nef_models.NiddDownlinkDataTransfer,
+) {
+	conf.Log.Infof("Buffered downlink data[%s] delivery status: %s", dlID, dlTransfer.DeliveryStatus)
+
+	notif := &nef_models.NiddDownlinkDataDeliveryStatusNotification{
+		NiddDownlinkDataTransfer:    dlTransfer.Self,
+		DeliveryStatus:              dlTransfer.DeliveryStatus,
+		RequestedRetransmissionTime: dlTransfer.RequestedRetransmissionTime,
+	}
+	if err := p.Notifier().NiddNotifier.NotifyDeliveryStatus(notifUri, notif); err != nil {
+		conf.Log.Errorf("Failed to notify AF of the delivery status: %+v", err)
+	}
+}
+
+func validateNiddConfiguration(niddConf *nef_models.NiddConfiguration) *models.ProblemDetails {
+	if niddConf.ExternalGroupId != "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("externalGroupId is not supported")
+	}
+	if (niddConf.ExternalId == "") == (niddConf.Msisdn == "") {
+		return openapi.ProblemDetailsMalformedReqSyntax("Either externalId or msisdn shall be provided")
+	}
+	if niddConf.NotificationDestination == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notificationDestination")
+	}
+	if niddConf.MaximumPacketSize < 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid maximumPacketSize")
+	}
+	return validatePdnEstablishmentOption(niddConf.PdnEstablishmentOption)
+}
+
+func validateNiddConfigurationPatch(niddConfPatch *nef_models.NiddConfigurationPatch) *models.ProblemDetails {
+	if niddConfPatch.MaximumPacketSize < 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid maximumPacketSize")
+	}
+	return validatePdnEstablishmentOption(niddConfPatch.PdnEstablishmentOption)
+}
+
+func validateNiddDownlinkDataTransfer(
+	niddConf *nef_models.NiddConfiguration,
+	dlTransfer *nef_models.NiddDownlinkDataTransfer,
+) *models.ProblemDetails {
+	if dlTransfer.Data == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing data")
+	}
+	data, err := base64.StdEncoding.DecodeString(dlTransfer.Data)
+	if err != nil {
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid data: " + err.Error())
+	}
+	if niddConf.MaximumPacketSize > 0 && len(data) > int(niddConf.MaximumPacketSize) {
+		return openapi.ProblemDetailsMalformedReqSyntax("Data exceeds the maximumPacketSize")
+	}
+	if dlTransfer.MaximumLatency < 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid maximumLatency")
+	}
+	return validatePdnEstablishmentOption(dlTransfer.PdnEstablishmentOption)
+}
+
+func validatePdnEstablishmentOption(option nef_models.PdnEstablishmentOptions) *models.ProblemDetails {
+	switch option {
+	case "", nef_models.PdnEstablishmentOptions_WAIT_FOR_UE, nef_models.PdnEstablishmentOptions_INDICATE_ERROR:
+		return nil
+	case nef_models.PdnEstablishmentOptions_SEND_TRIGGER:
+		return openapi.ProblemDetailsMalformedReqSyntax("pdnEstablishmentOption SEND_TRIGGER is not supported")
+	default:
+		return openapi.ProblemDetailsMalformedReqSyntax("Invalid pdnEstablishmentOption: " + string(option))
+	}
+}
+
+// niddPdnEstablishmentOption returns the handling of the downlink data when
+// the NIDD PDU session is not established, the option of the delivery
+// overrides the one of the configuration.
+func niddPdnEstablishmentOption(
+	niddConf *nef_models.NiddConfiguration,
+	dlTransfer *nef_models.NiddDownlinkDataTransfer,
+) nef_models.PdnEstablishmentOptions {
+	switch {
+	case dlTransfer.PdnEstablishmentOption != "":
+		return dlTransfer.PdnEstablishmentOption
+	case niddConf.PdnEstablishmentOption != "":
+		return niddConf.PdnEstablishmentOption
+	default:
+		return nef_models.PdnEstablishmentOptions_WAIT_FOR_UE
+	}
+}
+
+// niddGpsi returns the GPSI of the UE of the NIDD configuration.
+func niddGpsi(externalId, msisdn string) string {
+	if externalId != "" {
+		return gpsiExtIdPrefix + externalId
+	}
+	return gpsiMsisdnPrefix + msisdn
+}
+
+// niddConfigurationWithDlTransfers returns the NIDD configuration together
+// with its buffered downlink data deliveries.
+func niddConfigurationWithDlTransfers(conf *context.AfNiddConfiguration) *nef_models.NiddConfiguration {
+	niddConf := *conf.NiddConf
+	niddConf.NiddDownlinkDataTransfers = nil
+	for _, dl := range conf.BufferedDlTransfers() {
+		niddConf.NiddDownlinkDataTransfers = append(niddConf.NiddDownlinkDataTransfers, *dl.DlTransfer)
+	}
+	return &niddConf
+}
+
+// niddDlTransferExpired reports whether the maximumLatency of the buffered
+// downlink data has elapsed.
+func niddDlTransferExpired(dl *context.AfNiddDlTransfer, now time.Time) bool {
+	return dl.Expiry != nil && now.After(*dl.Expiry)
+}
+
+func findNiddDlTransfer(af *context.AfData, configID, dlID string) (*context.AfNiddDlTransfer, *models.ProblemDetails) {
+	conf, ok := af.NiddConfs[configID]
+	if !ok {
+		return nil, openapi.ProblemDetailsDataNotFound("NIDD configuration is not found")
+	}
+	dl, ok := conf.DlTransfers[dlID]
+	if !ok {
+		return nil, openapi.ProblemDetailsDataNotFound("Downlink data delivery is not found")
+	}
+	return dl, nil
+}
+
+func (p *Processor) genNiddConfURI(
+	scsAsID, configurationId string,
+) string {
+	// E.g. https://localhost:29505/3gpp-nidd/v1/{scsAsId}/configurations/{configurationId}
+	return p.Config().ServiceUri(factory.ServiceNidd) + "/" + scsAsID + "/configurations/" + configurationId
+}
+
+func (p *Processor) genNiddDlTransferURI(
+	scsAsID, configurationId, downlinkDataDeliveryId string,
+) string {
+	return p.genNiddConfURI(scsAsID, configurationId) + "/downlink-data-deliveries/" + downlinkDataDeliveryId
+}
+
+// niddUplinkDataNotification returns the notification of the MO data of the UE
+// of the NIDD configuration.
+func niddUplinkDataNotification(conf *context.AfNiddConfiguration, data []byte) *nef_models.NiddUplinkDataNotification {
+	notif := &nef_models.NiddUplinkDataNotification{
+		NiddConfiguration: conf.NiddConf.Self,
+		Data:              base64.StdEncoding.EncodeToString(data),
+	}
+	switch {
+	case strings.HasPrefix(conf.Gpsi, gpsiExtIdPrefix):
+		notif.ExternalId = strings.TrimPrefix(conf.Gpsi, gpsiExtIdPrefix)
+	case strings.HasPrefix(conf.Gpsi, gpsiMsisdnPrefix):
+		notif.Msisdn = strings.TrimPrefix(conf.Gpsi, gpsiMsisdnPrefix)
+	}
+	return notif
+}
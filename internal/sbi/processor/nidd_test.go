@@ -0,0 +1,224 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestNiddDownlinkDataDelivery(t *testing.T) {
+	initNRFDiscStub(models.NrfNfManagementNfType_AMF, models.ServiceName_NAMF_MT, "127.0.0.18", "http://127.0.0.18:8000")
+
+	niddConf := nef_models.NiddConfiguration{
+		ExternalId:              "456@nef.free5gc.org",
+		NotificationDestination: "http://127.0.0.106:8000/nidd/notify",
+		MaximumPacketSize:       64,
+	}
+	niddConfInvalid := niddConf
+	niddConfInvalid.Msisdn = "886900000000"
+
+	testCases := []struct {
+		description    string
+		niddConf       *nef_models.NiddConfiguration
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Both externalId and msisdn",
+			niddConf:       &niddConfInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC2: NIDD configuration of an external ID",
+			niddConf:       &niddConf,
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	var location string
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			conf := *tc.niddConf
+			nefApp.Processor().PostNiddConfiguration(c, "af6", &conf)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			if httpRecorder.Code == http.StatusCreated {
+				location = httpRecorder.Header().Get("Location")
+			}
+		})
+	}
+	require.Equal(t, nefApp.Config().ServiceUri(factory.ServiceNidd)+"/af6/configurations/1", location)
+
+	af := nefApp.Context().GetAf("af6")
+	require.NotNil(t, af)
+	bufferedDlTransfers := func() int {
+		af.Mu.RLock()
+		defer af.Mu.RUnlock()
+		return len(af.NiddConfs["1"].DlTransfers)
+	}
+
+	// Without NIDD PDU session the data is buffered
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostNiddDownlinkDataDelivery(c, "af6", "1",
+		&nef_models.NiddDownlinkDataTransfer{Data: "aGVsbG8="})
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.Equal(t, location+"/downlink-data-deliveries/1", httpRecorder.Header().Get("Location"))
+	require.Equal(t, 1, bufferedDlTransfers())
+	require.Equal(t, nef_models.DeliveryStatus_BUFFERING, af.NiddConfs["1"].DlTransfers["1"].DlTransfer.DeliveryStatus)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostNiddDownlinkDataDelivery(c, "af6", "1", &nef_models.NiddDownlinkDataTransfer{
+		Data:                   "aGVsbG8=",
+		PdnEstablishmentOption: nef_models.PdnEstablishmentOptions_INDICATE_ERROR,
+	})
+	require.Equal(t, http.StatusNotFound, httpRecorder.Code)
+
+	// The buffered data is delivered once the SMF creates the SM context
+	smfMock := gock.New("http://127.0.0.24:8000/nsmf-nidd/v1").
+		Post("/pdu-sessions/ref1/deliver").
+		Reply(http.StatusNoContent)
+	afMock := gock.New("http://127.0.0.106:8000").
+		Post("/nidd/notify").
+		BodyString(`"niddDownlinkDataTransfer":".*/af6/configurations/1/downlink-data-deliveries/1".*` +
+			`"deliveryStatus":"SUCCESS"`).
+		Reply(http.StatusNoContent)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostSmContext(c, &models.NefSmContextSmContextCreateData{
+		Supi:           "imsi-208930000000006",
+		PduSessionId:   1,
+		Dnn:            "nidd",
+		DlNiddEndPoint: "http://127.0.0.24:8000/nsmf-nidd/v1/pdu-sessions/ref1",
+		NiddInfo:       &models.NefSmContextNiddInformation{AfId: "af6", Gpsi: "extid-456@nef.free5gc.org"},
+	})
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	assertJSONBodyEqual(t, &models.NefSmContextSmContextCreatedData{
+		Supi:          "imsi-208930000000006",
+		PduSessionId:  1,
+		Dnn:           "nidd",
+		NefId:         nefApp.Context().NfInstID(),
+		MaxPacketSize: 64,
+	}, httpRecorder.Body.Bytes())
+	require.Eventually(t, func() bool {
+		return smfMock.Done() && afMock.Done() && bufferedDlTransfers() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	smCtx := nefApp.Context().FindSmContext("af6", "extid-456@nef.free5gc.org")
+	require.NotNil(t, smCtx)
+
+	// The notifications of the modified configuration reach the new destination
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchIndividualNiddConfiguration(c, "af6", "1", &nef_models.NiddConfigurationPatch{
+		NotificationDestination: "http://127.0.0.107:8000/nidd/notify",
+		MaximumPacketSize:       128,
+	})
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	patched := niddConf
+	patched.Self = location
+	patched.Status = nef_models.NiddStatus_ACTIVE
+	patched.NotificationDestination = "http://127.0.0.107:8000/nidd/notify"
+	patched.MaximumPacketSize = 128
+	assertJSONBodyEqual(t, &patched, httpRecorder.Body.Bytes())
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchIndividualNiddConfiguration(c, "af6", "1",
+		&nef_models.NiddConfigurationPatch{PdnEstablishmentOption: nef_models.PdnEstablishmentOptions_SEND_TRIGGER})
+	require.Equal(t, http.StatusBadRequest, httpRecorder.Code)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchIndividualNiddConfiguration(c, "af6", "2",
+		&nef_models.NiddConfigurationPatch{MaximumPacketSize: 128})
+	require.Equal(t, http.StatusNotFound, httpRecorder.Code)
+
+	// The UE still not reachable once paged, the data is buffered
+	smfMock = gock.New("http://127.0.0.24:8000/nsmf-nidd/v1").
+		Post("/pdu-sessions/ref1/deliver").
+		Reply(http.StatusGatewayTimeout).
+		JSON(models.DeliverError{Status: http.StatusGatewayTimeout, Cause: "UE_NOT_REACHABLE", MaxWaitingTime: 60})
+	amfMock := gock.New("http://127.0.0.18:8000/namf-mt/v1").
+		Put("/ue-contexts/imsi-208930000000006/ue-reachind").
+		Reply(http.StatusOK).
+		JSON(models.EnableUeReachabilityRspData{Reachability: models.UeReachability_UNREACHABLE})
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostNiddDownlinkDataDelivery(c, "af6", "1",
+		&nef_models.NiddDownlinkDataTransfer{Data: "d29ybGQ="})
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.True(t, smfMock.Done())
+	require.True(t, amfMock.Done())
+	dl := af.NiddConfs["1"].DlTransfers["2"]
+	require.NotNil(t, dl)
+	require.Equal(t, nef_models.DeliveryStatus_BUFFERING_TEMPORARILY_NOT_REACHABLE, dl.DlTransfer.DeliveryStatus)
+	require.NotNil(t, dl.DlTransfer.RequestedRetransmissionTime)
+
+	// The MO data is relayed to the AF, the UE is then reachable
+	afUlMock := gock.New("http://127.0.0.107:8000").
+		Post("/nidd/notify").
+		BodyString(`"niddConfiguration":".*/af6/configurations/1".*"externalId":"456@nef.free5gc.org".*` +
+			`"data":"cGluZw=="`).
+		Reply(http.StatusNoContent)
+	smfMock = gock.New("http://127.0.0.24:8000/nsmf-nidd/v1").
+		Post("/pdu-sessions/ref1/deliver").
+		Reply(http.StatusNoContent)
+	afMock = gock.New("http://127.0.0.107:8000").
+		Post("/nidd/notify").
+		BodyString(`"niddDownlinkDataTransfer":".*/downlink-data-deliveries/2".*"deliveryStatus":"SUCCESS"`).
+		Reply(http.StatusNoContent)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().DeliverSmContext(c, smCtx.SmContextID, &models.DeliverRequest{BinaryMtData: []byte("ping")})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, afUlMock.Done())
+	require.Eventually(t, func() bool {
+		return smfMock.Done() && afMock.Done() && bufferedDlTransfers() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().ReleaseSmContext(c, smCtx.SmContextID,
+		&models.NefSmContextSmContextReleaseData{Cause: models.NefSmContextReleaseCause_PDU_SESSION_RELEASED})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.Nil(t, nefApp.Context().GetSmContext(smCtx.SmContextID))
+
+	// The data buffered beyond its maximum latency is reported as timed out
+	afMock = gock.New("http://127.0.0.107:8000").
+		Post("/nidd/notify").
+		BodyString(`"niddDownlinkDataTransfer":".*/downlink-data-deliveries/3".*"deliveryStatus":"FAILURE_TIMEOUT"`).
+		Reply(http.StatusNoContent)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostNiddDownlinkDataDelivery(c, "af6", "1",
+		&nef_models.NiddDownlinkDataTransfer{Data: "aGVsbG8=", MaximumLatency: 1})
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.Equal(t, 1, bufferedDlTransfers())
+	require.Eventually(t, func() bool {
+		return afMock.Done() && bufferedDlTransfers() == 0
+	}, 3*time.Second, 10*time.Millisecond)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().DeleteIndividualNiddConfiguration(c, "af6", "1")
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.Empty(t, af.NiddConfs)
+}
@@ -3,6 +3,7 @@ package processor
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
@@ -67,7 +68,13 @@ func (p *Processor) PostPFDManagementTransactions(
 ) {
 	logger.PFDManageLog.Infof("PostPFDManagementTransactions - scsAsID[%s]", scsAsID)
 
-	// TODO: Authorize the AF
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServicePfdMng, pfdMng.SupportedFeatures)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	pfdMng.SupportedFeatures = suppFeat
 
 	nefCtx := p.Context()
 	if pd := validatePfdManagement(scsAsID, "-1", pfdMng, nefCtx); pd != nil {
@@ -81,6 +88,18 @@ func (p *Processor) PostPFDManagementTransactions(
 			return
 		}
 	}
+	p.validateAllowedDelays(pfdMng)
+	if len(pfdMng.PfdDatas) == 0 {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, util.METRICS_APP_PFDS_CREATION_ERR_MSG)
+		c.JSON(http.StatusInternalServerError, &pfdMng.PfdReports)
+		return
+	}
+	if len(pfdMng.PfdReports) > 0 && !suppFeatEnabled(suppFeat, suppFeatPfdPartialFailure) {
+		// Without partial failure support, the request is rejected as a whole
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, util.METRICS_APP_PFDS_CREATION_ERR_MSG)
+		c.JSON(http.StatusInternalServerError, &pfdMng.PfdReports)
+		return
+	}
 
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -100,6 +119,8 @@ func (p *Processor) PostPFDManagementTransactions(
 		c.JSON(int(pd.Status), pd)
 		return
 	}
+	afPfdTr.SuppFeat = suppFeat
+	afPfdTr.NotificationDestination = pfdMng.NotificationDestination
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
@@ -111,12 +132,13 @@ func (p *Processor) PostPFDManagementTransactions(
 			delete(pfdMng.PfdDatas, appID)
 			addPfdReport(pfdMng, pfdReport)
 		} else {
-			pfdData.Self = p.genPfdDataURI(scsAsID, afPfdTr.TransID, appID)
+			p.completePfdData(&pfdData, scsAsID, afPfdTr.TransID, appID)
 			pfdMng.PfdDatas[appID] = pfdData
 			pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 				ApplicationId: appID,
 				Pfds:          pfdDataForApp.Pfds,
 			})
+			pfdNotifyContext.SetAllowedDelay(appID, pfdData.AllowedDelay)
 		}
 	}
 	if len(pfdMng.PfdDatas) == 0 {
@@ -172,7 +194,7 @@ func (p *Processor) DeletePFDManagementTransactions(c *gin.Context, scsAsID stri
 				RemovalFlag:   true,
 			})
 		}
-		delete(af.PfdTrans, afPfdTr.TransID)
+		af.DeletePfdTrans(afPfdTr.TransID)
 		afPfdTr.Log.Infoln("PFD Management Transaction is deleted")
 	}
 
@@ -234,7 +256,13 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 	logger.PFDManageLog.Infof("PutIndividualPFDManagementTransaction - scsAsID[%s], transID[%s]",
 		scsAsID, transID)
 
-	// TODO: Authorize the AF
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServicePfdMng, pfdMng.SupportedFeatures)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	pfdMng.SupportedFeatures = suppFeat
 
 	nefCtx := p.Context()
 	if pd := validatePfdManagement(scsAsID, transID, pfdMng, nefCtx); pd != nil {
@@ -248,6 +276,18 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 			return
 		}
 	}
+	p.validateAllowedDelays(pfdMng)
+	if len(pfdMng.PfdDatas) == 0 {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, util.METRICS_APP_PFDS_CREATION_ERR_MSG)
+		c.JSON(http.StatusInternalServerError, &pfdMng.PfdReports)
+		return
+	}
+	if len(pfdMng.PfdReports) > 0 && !suppFeatEnabled(suppFeat, suppFeatPfdPartialFailure) {
+		// Without partial failure support, the request is rejected as a whole
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, util.METRICS_APP_PFDS_CREATION_ERR_MSG)
+		c.JSON(http.StatusInternalServerError, &pfdMng.PfdReports)
+		return
+	}
 
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -290,6 +330,8 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 		})
 	}
 
+	afPfdTr.SuppFeat = suppFeat
+	afPfdTr.NotificationDestination = pfdMng.NotificationDestination
 	afPfdTr.DeleteAllExtAppIDs()
 	for appID, pfdData := range pfdMng.PfdDatas {
 		afPfdTr.AddExtAppID(appID)
@@ -298,12 +340,13 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 			delete(pfdMng.PfdDatas, appID)
 			addPfdReport(pfdMng, pfdReport)
 		} else {
-			pfdData.Self = p.genPfdDataURI(scsAsID, afPfdTr.TransID, appID)
+			p.completePfdData(&pfdData, scsAsID, afPfdTr.TransID, appID)
 			pfdMng.PfdDatas[appID] = pfdData
 			pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 				ApplicationId: appID,
 				Pfds:          pfdDataForAppExt.Pfds,
 			})
+			pfdNotifyContext.SetAllowedDelay(appID, pfdData.AllowedDelay)
 		}
 	}
 	if len(pfdMng.PfdDatas) == 0 {
@@ -314,6 +357,7 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 		c.JSON(http.StatusInternalServerError, &pfdMng.PfdReports)
 		return
 	}
+	af.Persist()
 
 	pfdMng.Self = p.genPfdManagementURI(scsAsID, afPfdTr.TransID)
 
@@ -364,7 +408,7 @@ func (p *Processor) DeleteIndividualPFDManagementTransaction(
 			RemovalFlag:   true,
 		})
 	}
-	delete(af.PfdTrans, afPfdTr.TransID)
+	af.DeletePfdTrans(afPfdTr.TransID)
 	afPfdTr.Log.Infoln("PFD Management Transaction is deleted")
 
 	// TODO: Remove AfCtx if its subscriptions and transactions are both empty
@@ -427,7 +471,7 @@ func (p *Processor) GetIndividualApplicationPFDManagement(
 	}
 
 	pfdData := convertPdfDataForAppExtToPfdData(&pdfData.PfdDataForAppExt)
-	pfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
+	p.completePfdData(pfdData, scsAsID, transID, appID)
 
 	c.JSON(http.StatusOK, pfdData)
 }
@@ -478,6 +522,7 @@ func (p *Processor) DeleteIndividualApplicationPFDManagement(
 		return
 	}
 	afPfdTr.DeleteExtAppID(appID)
+	af.Persist()
 	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 		ApplicationId: appID,
 		RemovalFlag:   true,
@@ -502,8 +547,6 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 	logger.PFDManageLog.Infof("PutIndividualApplicationPFDManagement - scsAsID[%s], transID[%s], appID[%s]",
 		scsAsID, transID, appID)
 
-	// TODO: Authorize the AF
-
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -537,6 +580,11 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 		c.JSON(int(pd.Status), pd)
 		return
 	}
+	if pfdReport := p.validateAllowedDelay(appID, pfdData.AllowedDelay); pfdReport != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pfdReport.FailureCode)
+		c.JSON(http.StatusInternalServerError, pfdReport)
+		return
+	}
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
@@ -547,11 +595,12 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 		c.JSON(http.StatusInternalServerError, pfdReport)
 		return
 	}
-	pfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
+	p.completePfdData(pfdData, scsAsID, transID, appID)
 	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 		ApplicationId: appID,
 		Pfds:          pfdDataForApp.Pfds,
 	})
+	pfdNotifyContext.SetAllowedDelay(appID, pfdData.AllowedDelay)
 
 	c.JSON(http.StatusOK, pfdData)
 }
@@ -568,8 +617,6 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 	logger.PFDManageLog.Infof("PatchIndividualApplicationPFDManagement - scsAsID[%s], transID[%s], appID[%s]",
 		scsAsID, transID, appID)
 
-	// TODO: Authorize the AF
-
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -603,6 +650,13 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 		c.JSON(int(pd.Status), pd)
 		return
 	}
+	if pfdData.AllowedDelay != 0 {
+		if pfdReport := p.validateAllowedDelay(appID, pfdData.AllowedDelay); pfdReport != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pfdReport.FailureCode)
+			c.JSON(http.StatusInternalServerError, pfdReport)
+			return
+		}
+	}
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
@@ -637,11 +691,20 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 		c.JSON(http.StatusInternalServerError, pfdReport)
 		return
 	}
-	oldPfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
-	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-		ApplicationId: appID,
-		Pfds:          pfdDataForAppExt.Pfds,
-	})
+	p.completePfdData(oldPfdData, scsAsID, transID, appID)
+	if len(pfdDataForAppExt.Pfds) == 0 {
+		// All the PFDs of the application were removed
+		pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
+			ApplicationId: appID,
+			RemovalFlag:   true,
+		})
+	} else {
+		pfdNotifyContext.AddPartialNotification(appID, &models.PfdChangeNotification{
+			ApplicationId: appID,
+			Pfds:          pfdDataForAppExt.Pfds,
+		}, genPartialPfdChangeNotifications(appID, pfdData, oldPfdData))
+	}
+	pfdNotifyContext.SetAllowedDelay(appID, oldPfdData.AllowedDelay)
 
 	c.JSON(http.StatusOK, oldPfdData)
 }
@@ -652,8 +715,10 @@ func (p *Processor) buildPfdManagement(afID string, afPfdTr *nef_context.AfPfdTr
 	transID := afPfdTr.TransID
 	appIDs := afPfdTr.GetExtAppIDs()
 	pfdMng := &models.PfdManagement{
-		Self:     p.genPfdManagementURI(afID, transID),
-		PfdDatas: make(map[string]models.PfdData, len(appIDs)),
+		Self:                    p.genPfdManagementURI(afID, transID),
+		SupportedFeatures:       afPfdTr.SuppFeat,
+		NotificationDestination: afPfdTr.NotificationDestination,
+		PfdDatas:                make(map[string]models.PfdData, len(appIDs)),
 	}
 
 	data, pd, err := p.Consumer().AppDataPfdsGet(appIDs)
@@ -671,7 +736,7 @@ func (p *Processor) buildPfdManagement(afID string, afPfdTr *nef_context.AfPfdTr
 
 	for _, pfdDataForApp := range data {
 		pfdData := convertPdfDataForAppExtToPfdData(&pfdDataForApp)
-		pfdData.Self = p.genPfdDataURI(afID, transID, pfdData.ExternalAppId)
+		p.completePfdData(pfdData, afID, transID, pfdData.ExternalAppId)
 
 		pfdMng.PfdDatas[pfdData.ExternalAppId] = *pfdData
 	}
@@ -679,6 +744,9 @@ func (p *Processor) buildPfdManagement(afID string, afPfdTr *nef_context.AfPfdTr
 }
 
 func (p *Processor) storePfdDataToUDR(appID string, pfdDataForApp *models.PfdDataForAppExt) *models.PfdReport {
+	// The PFDs are valid in the caches of the PFDF consumers until then
+	cachingTime := time.Now().Add(time.Duration(p.Config().PfdCachingTime()) * time.Second)
+	pfdDataForApp.CachingTime = &cachingTime
 	_, pd, errAppData := p.Consumer().AppDataPfdsAppIdPut(appID, pfdDataForApp)
 
 	switch {
@@ -711,6 +779,9 @@ func (p *Processor) deletePfdDataFromUDR(appID string) *models.ProblemDetails {
 
 // The behavior of PATCH update is based on TS 29.250 v1.15.1 clause 4.4.1
 func patchModifyPfdData(oldPfdData, newPfdData *models.PfdData) *models.ProblemDetails {
+	if newPfdData.AllowedDelay != 0 {
+		oldPfdData.AllowedDelay = newPfdData.AllowedDelay
+	}
 	for pfdID, newPfd := range newPfdData.Pfds {
 		_, exist := oldPfdData.Pfds[pfdID]
 		if len(newPfd.FlowDescriptions) == 0 && len(newPfd.Urls) == 0 && len(newPfd.DomainNames) == 0 {
@@ -729,9 +800,50 @@ func patchModifyPfdData(oldPfdData, newPfdData *models.PfdData) *models.ProblemD
 	return nil
 }
 
+// genPartialPfdChangeNotifications builds the notifications of the PFDs changed
+// by the patch. TS 29.551: the PFDs added or updated are sent with partialFlag,
+// the PFDs removed are sent apart by their pfdId with both partialFlag and
+// removalFlag.
+func genPartialPfdChangeNotifications(
+	appID string, patch, patched *models.PfdData,
+) []models.PfdChangeNotification {
+	changed := models.PfdChangeNotification{
+		ApplicationId: appID,
+		PartialFlag:   true,
+	}
+	removed := models.PfdChangeNotification{
+		ApplicationId: appID,
+		PartialFlag:   true,
+		RemovalFlag:   true,
+	}
+	for pfdID := range patch.Pfds {
+		pfd, exist := patched.Pfds[pfdID]
+		if !exist {
+			removed.Pfds = append(removed.Pfds, models.PfdContent{PfdId: pfdID})
+			continue
+		}
+		changed.Pfds = append(changed.Pfds, models.PfdContent{
+			PfdId:            pfdID,
+			FlowDescriptions: pfd.FlowDescriptions,
+			Urls:             pfd.Urls,
+			DomainNames:      pfd.DomainNames,
+		})
+	}
+
+	var notifs []models.PfdChangeNotification
+	if len(changed.Pfds) > 0 {
+		notifs = append(notifs, changed)
+	}
+	if len(removed.Pfds) > 0 {
+		notifs = append(notifs, removed)
+	}
+	return notifs
+}
+
 func convertPfdDataToPfdDataForApp(pfdData *models.PfdData) *models.PfdDataForAppExt {
 	pfdDataForApp := &models.PfdDataForAppExt{
 		ApplicationId: pfdData.ExternalAppId,
+		AllowedDelay:  pfdData.AllowedDelay,
 	}
 	for _, pfd := range pfdData.Pfds {
 		var pfdContent models.PfdContent
@@ -748,6 +860,7 @@ func convertPdfDataForAppExtToPfdData(pfdDataForAppExt *models.PfdDataForAppExt)
 	pfdData := &models.PfdData{
 		ExternalAppId: pfdDataForAppExt.ApplicationId,
 		Pfds:          make(map[string]models.Pfd, len(pfdDataForAppExt.Pfds)),
+		AllowedDelay:  pfdDataForAppExt.AllowedDelay,
 	}
 	for _, pfdContent := range pfdDataForAppExt.Pfds {
 		var pfd models.Pfd
@@ -763,6 +876,7 @@ func convertPdfDataForAppExtToPfdData(pfdDataForAppExt *models.PfdDataForAppExt)
 func convertPdfDataForAppExtToPfdDataForApp(pfdDataForAppExt *models.PfdDataForAppExt) *models.PfdDataForApp {
 	pfdDataForApp := &models.PfdDataForApp{
 		ApplicationId: pfdDataForAppExt.ApplicationId,
+		CachingTime:   pfdDataForAppExt.CachingTime,
 	}
 	for _, pfdContent := range pfdDataForAppExt.Pfds {
 		var pfd models.PfdContent
@@ -778,6 +892,7 @@ func convertPdfDataForAppExtToPfdDataForApp(pfdDataForAppExt *models.PfdDataForA
 func convertPfdDataToPfdDataForAppExt(pfdData *models.PfdData) *models.PfdDataForAppExt {
 	pfdDataForAppExt := &models.PfdDataForAppExt{
 		ApplicationId: pfdData.ExternalAppId,
+		AllowedDelay:  pfdData.AllowedDelay,
 	}
 	for _, pfd := range pfdData.Pfds {
 		var pfdContent models.PfdContent
@@ -790,6 +905,36 @@ func convertPfdDataToPfdDataForAppExt(pfdData *models.PfdData) *models.PfdDataFo
 	return pfdDataForAppExt
 }
 
+// completePfdData fills the attributes of the PfdData set by the NEF
+func (p *Processor) completePfdData(pfdData *models.PfdData, afID, transID, appID string) {
+	pfdData.Self = p.genPfdDataURI(afID, transID, appID)
+	pfdData.CachingTime = p.Config().PfdCachingTime()
+}
+
+// validateAllowedDelays removes the applications whose allowedDelay is too
+// short from the PfdManagement and reports them
+func (p *Processor) validateAllowedDelays(pfdMng *models.PfdManagement) {
+	for appID, pfdData := range pfdMng.PfdDatas {
+		if pfdReport := p.validateAllowedDelay(appID, pfdData.AllowedDelay); pfdReport != nil {
+			delete(pfdMng.PfdDatas, appID)
+			addPfdReport(pfdMng, pfdReport)
+		}
+	}
+}
+
+// validateAllowedDelay returns a SHORT_DELAY PfdReport, with the caching time
+// of the NEF, when the allowedDelay is shorter than the NEF can meet
+func (p *Processor) validateAllowedDelay(appID string, allowedDelay int32) *models.PfdReport {
+	if allowedDelay == 0 || allowedDelay >= p.Config().PfdMinAllowedDelay() {
+		return nil
+	}
+	return &models.PfdReport{
+		ExternalAppIds: []string{appID},
+		FailureCode:    models.FailureCode_SHORT_DELAY,
+		CachingTime:    p.Config().PfdCachingTime(),
+	}
+}
+
 func (p *Processor) genPfdManagementURI(afID, transID string) string {
 	// E.g. https://localhost:29505/3gpp-pfd-management/v1/{afID}/transactions/{transID}
 	return fmt.Sprintf("%s/%s/transactions/%s",
@@ -861,6 +1006,7 @@ func validatePfdData(pfdData *models.PfdData, isPatch bool) *models.ProblemDetai
 func addPfdReport(pfdMng *models.PfdManagement, newReport *models.PfdReport) {
 	if oldReport, ok := pfdMng.PfdReports[string(newReport.FailureCode)]; ok {
 		oldReport.ExternalAppIds = append(oldReport.ExternalAppIds, newReport.ExternalAppIds...)
+		pfdMng.PfdReports[string(newReport.FailureCode)] = oldReport
 	} else {
 		pfdMng.PfdReports[string(newReport.FailureCode)] = *newReport
 	}
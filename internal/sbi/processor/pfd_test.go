@@ -38,7 +38,7 @@ func newTestApp(cfg *factory.Config, tlsKeyLogPath string) (*nefTestApp, error)
 	if nef.consumer, err = consumer.NewConsumer(nef); err != nil {
 		return nil, err
 	}
-	if nef.notifier, err = notifier.NewNotifier(); err != nil {
+	if nef.notifier, err = notifier.NewNotifier(nef.nefCtx.Store()); err != nil {
 		return nil, err
 	}
 	if nef.proc, err = NewProcessor(nef); err != nil {
@@ -138,7 +138,8 @@ func TestMain(m *testing.M) {
 				BindingIPv4:  "127.0.0.5",
 				Port:         8000,
 			},
-			NrfUri: "http://127.0.0.10:8000",
+			NrfUri:       "http://127.0.0.10:8000",
+			PcfSelection: factory.PcfSelectionNrf,
 			ServiceList: []factory.Service{
 				{
 					ServiceName: factory.ServiceNefPfd,
@@ -177,6 +178,7 @@ func TestGetPFDManagementTransactions(t *testing.T) {
 							"app1": {
 								ExternalAppId: "app1",
 								Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app1"),
+								CachingTime:   factory.NefDefaultPfdCachingTime,
 								Pfds: map[string]models.Pfd{
 									"pfd1": pfd1,
 									"pfd2": pfd2,
@@ -185,6 +187,7 @@ func TestGetPFDManagementTransactions(t *testing.T) {
 							"app2": {
 								ExternalAppId: "app2",
 								Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app2"),
+								CachingTime:   factory.NefDefaultPfdCachingTime,
 								Pfds: map[string]models.Pfd{
 									"pfd3": pfd3,
 								},
@@ -315,6 +318,7 @@ func TestPostPFDManagementTransactions(t *testing.T) {
 						"app1": {
 							ExternalAppId: "app1",
 							Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app1"),
+							CachingTime:   factory.NefDefaultPfdCachingTime,
 							Pfds: map[string]models.Pfd{
 								"pfd1": pfd1,
 								"pfd2": pfd2,
@@ -323,6 +327,7 @@ func TestPostPFDManagementTransactions(t *testing.T) {
 						"app2": {
 							ExternalAppId: "app2",
 							Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app2"),
+							CachingTime:   factory.NefDefaultPfdCachingTime,
 							Pfds: map[string]models.Pfd{
 								"pfd3": pfd3,
 							},
@@ -409,6 +414,7 @@ func TestGetIndividualPFDManagementTransaction(t *testing.T) {
 						"app1": {
 							ExternalAppId: "app1",
 							Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app1"),
+							CachingTime:   factory.NefDefaultPfdCachingTime,
 							Pfds: map[string]models.Pfd{
 								"pfd1": pfd1,
 								"pfd2": pfd2,
@@ -417,6 +423,7 @@ func TestGetIndividualPFDManagementTransaction(t *testing.T) {
 						"app2": {
 							ExternalAppId: "app2",
 							Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app2"),
+							CachingTime:   factory.NefDefaultPfdCachingTime,
 							Pfds: map[string]models.Pfd{
 								"pfd3": pfd3,
 							},
@@ -552,6 +559,7 @@ func TestPutIndividualPFDManagementTransaction(t *testing.T) {
 						"app1": {
 							ExternalAppId: "app1",
 							Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app1"),
+							CachingTime:   factory.NefDefaultPfdCachingTime,
 							Pfds: map[string]models.Pfd{
 								"pfd1": pfd1,
 								"pfd2": pfd2,
@@ -560,6 +568,7 @@ func TestPutIndividualPFDManagementTransaction(t *testing.T) {
 						"app2": {
 							ExternalAppId: "app2",
 							Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app2"),
+							CachingTime:   factory.NefDefaultPfdCachingTime,
 							Pfds: map[string]models.Pfd{
 								"pfd3": pfd3,
 							},
@@ -652,6 +661,7 @@ func TestGetIndividualApplicationPFDManagement(t *testing.T) {
 				Body: &models.PfdData{
 					ExternalAppId: "app1",
 					Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app1"),
+					CachingTime:   factory.NefDefaultPfdCachingTime,
 					Pfds: map[string]models.Pfd{
 						"pfd1": pfd1,
 						"pfd2": pfd2,
@@ -779,6 +789,7 @@ func TestPutIndividualApplicationPFDManagement(t *testing.T) {
 				Body: &models.PfdData{
 					ExternalAppId: "app1",
 					Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app1"),
+					CachingTime:   factory.NefDefaultPfdCachingTime,
 					Pfds: map[string]models.Pfd{
 						"pfd1": pfd1,
 						"pfd2": pfd2,
@@ -877,6 +888,7 @@ func TestPatchIndividualApplicationPFDManagement(t *testing.T) {
 				Body: &models.PfdData{
 					ExternalAppId: "app1",
 					Self:          nefApp.Processor().genPfdDataURI("af1", "1", "app1"),
+					CachingTime:   factory.NefDefaultPfdCachingTime,
 					Pfds: map[string]models.Pfd{
 						"pfd2": pfd2,
 					},
@@ -1400,3 +1412,74 @@ func initUDRDrPutPfdDataStub(statusCode int) {
 		Reply(statusCode).
 		JSON(pfdDataForApp1)
 }
+
+func TestGenPartialPfdChangeNotifications(t *testing.T) {
+	patch := &models.PfdData{
+		ExternalAppId: "app1",
+		Pfds: map[string]models.Pfd{
+			"pfd2": pfd2,
+			"pfd3": {PfdId: "pfd3"},
+		},
+	}
+	patched := &models.PfdData{
+		ExternalAppId: "app1",
+		Pfds: map[string]models.Pfd{
+			"pfd1": pfd1,
+			"pfd2": pfd2,
+		},
+	}
+
+	// The removed PFDs are sent apart, both flags set
+	require.Equal(t, []models.PfdChangeNotification{
+		{
+			ApplicationId: "app1",
+			PartialFlag:   true,
+			Pfds: []models.PfdContent{
+				{
+					PfdId:            "pfd2",
+					FlowDescriptions: pfd2.FlowDescriptions,
+					Urls:             pfd2.Urls,
+					DomainNames:      pfd2.DomainNames,
+				},
+			},
+		},
+		{
+			ApplicationId: "app1",
+			PartialFlag:   true,
+			RemovalFlag:   true,
+			Pfds:          []models.PfdContent{{PfdId: "pfd3"}},
+		},
+	}, genPartialPfdChangeNotifications("app1", patch, patched))
+
+	// Nothing removed, a single notification
+	delete(patch.Pfds, "pfd3")
+	notifs := genPartialPfdChangeNotifications("app1", patch, patched)
+	require.Len(t, notifs, 1)
+	require.False(t, notifs[0].RemovalFlag)
+}
+
+func TestValidateAllowedDelays(t *testing.T) {
+	cfg := nefApp.Config().Configuration
+	cfg.Pfd = &factory.Pfd{MinAllowedDelay: 10}
+	defer func() {
+		cfg.Pfd = nil
+	}()
+
+	pfdMng := &models.PfdManagement{
+		PfdDatas: map[string]models.PfdData{
+			"app1": {ExternalAppId: "app1", AllowedDelay: 5},
+			"app2": {ExternalAppId: "app2", AllowedDelay: 20},
+			"app3": {ExternalAppId: "app3"},
+			"app4": {ExternalAppId: "app4", AllowedDelay: 1},
+		},
+		PfdReports: map[string]models.PfdReport{},
+	}
+	nefApp.Processor().validateAllowedDelays(pfdMng)
+
+	require.Len(t, pfdMng.PfdDatas, 2)
+	require.Contains(t, pfdMng.PfdDatas, "app2")
+	require.Contains(t, pfdMng.PfdDatas, "app3")
+	report := pfdMng.PfdReports[string(models.FailureCode_SHORT_DELAY)]
+	require.ElementsMatch(t, []string{"app1", "app4"}, report.ExternalAppIds)
+	require.Equal(t, int32(factory.NefDefaultPfdCachingTime), report.CachingTime)
+}
@@ -16,10 +16,15 @@ import (
 // 3GPP TS 29.551 release 17 version 17.6.0
 // Resource structure: 5.3.1
 // Request/Response  : 5.3.2.3.1
-func (p *Processor) GetApplicationsPFD(c *gin.Context, appIDs []string) {
+func (p *Processor) GetApplicationsPFD(c *gin.Context, appIDs []string, suppFeat string) {
 	logger.PFDFLog.Infof("GetApplicationsPFD - appIDs: %v", appIDs)
 
-	// TODO: Support SupportedFeatures
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServiceNefPfd, suppFeat)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
 	pdfDataForAppExt, pd, errAppDataGet := p.Consumer().AppDataPfdsGet(appIDs)
 
 	switch {
@@ -40,7 +45,10 @@ func (p *Processor) GetApplicationsPFD(c *gin.Context, appIDs []string) {
 	var pfdDataForApp []models.PfdDataForApp
 
 	for _, dataForExt := range pdfDataForAppExt {
-		pfdDataForApp = append(pfdDataForApp, *convertPdfDataForAppExtToPfdDataForApp(&dataForExt))
+		dataForApp := convertPdfDataForAppExtToPfdDataForApp(&dataForExt)
+		dataForApp.CachingTimer = p.Config().PfdCachingTime()
+		dataForApp.SupportedFeatures = suppFeat
+		pfdDataForApp = append(pfdDataForApp, *dataForApp)
 	}
 
 	c.JSON(http.StatusOK, pfdDataForApp)
@@ -50,10 +58,15 @@ func (p *Processor) GetApplicationsPFD(c *gin.Context, appIDs []string) {
 // 3GPP TS 29.551 release 17 version 17.6.0
 // Resource structure: 5.3.1
 // Request/Response  : 5.3.3.3.1
-func (p *Processor) GetIndividualApplicationPFD(c *gin.Context, appID string) {
+func (p *Processor) GetIndividualApplicationPFD(c *gin.Context, appID, suppFeat string) {
 	logger.PFDFLog.Infof("GetIndividualApplicationPFD - appID[%s]", appID)
 
-	// TODO: Support SupportedFeatures
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServiceNefPfd, suppFeat)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
 	pdfDataRsp, pdfDataProblemDetails, errPdfData := p.Consumer().AppDataPfdsAppIdGet(appID)
 
 	switch {
@@ -72,6 +85,8 @@ func (p *Processor) GetIndividualApplicationPFD(c *gin.Context, appID string) {
 	}
 
 	pdfDataForApp := convertPdfDataForAppExtToPfdDataForApp(&pdfDataRsp.PfdDataForAppExt)
+	pdfDataForApp.CachingTimer = p.Config().PfdCachingTime()
+	pdfDataForApp.SupportedFeatures = suppFeat
 
 	c.JSON(http.StatusOK, pdfDataForApp)
 }
@@ -83,7 +98,6 @@ func (p *Processor) GetIndividualApplicationPFD(c *gin.Context, appID string) {
 func (p *Processor) PostPFDSubscriptions(c *gin.Context, pfdSubsc *models.PfdSubscription) {
 	logger.PFDFLog.Infof("PostPFDSubscriptions - appIDs: %v", pfdSubsc.ApplicationIds)
 
-	// TODO: Support SupportedFeatures
 	if len(pfdSubsc.NotifyUri) == 0 {
 		pd := openapi.ProblemDetailsDataNotFound("Absent of Notify URI")
 		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -91,6 +105,14 @@ func (p *Processor) PostPFDSubscriptions(c *gin.Context, pfdSubsc *models.PfdSub
 		return
 	}
 
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServiceNefPfd, pfdSubsc.SupportedFeatures)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	pfdSubsc.SupportedFeatures = suppFeat
+
 	subID := p.Notifier().PfdChangeNotifier.AddPfdSub(pfdSubsc)
 	hdrs := make(map[string][]string)
 	addLocationheader(hdrs, p.genPfdSubscriptionURI(subID))
@@ -120,6 +142,40 @@ func (p *Processor) DeleteIndividualPFDSubscription(c *gin.Context, subID string
 	c.Status(http.StatusNoContent)
 }
 
+// GetIndividualPFDSubscription Read a subscription to PFD change notifications.
+// 3GPP TS 29.551 release 17 version 17.6.0
+// Resource structure: 5.3.1
+func (p *Processor) GetIndividualPFDSubscription(c *gin.Context, subID string) {
+	logger.PFDFLog.Infof("GetIndividualPFDSubscription - subID[%s]", subID)
+
+	pfdSubsc, err := p.Notifier().PfdChangeNotifier.GetPfdSub(subID)
+	if err != nil {
+		pd := openapi.ProblemDetailsDataNotFound(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	c.JSON(http.StatusOK, pfdSubsc)
+}
+
+// PatchIndividualPFDSubscription Modify a subscription to PFD change notifications.
+// 3GPP TS 29.551 release 17 version 17.6.0
+// Resource structure: 5.3.1
+func (p *Processor) PatchIndividualPFDSubscription(c *gin.Context, subID string, pfdSubsc *models.PfdSubscription) {
+	logger.PFDFLog.Infof("PatchIndividualPFDSubscription - subID[%s]", subID)
+
+	patched, err := p.Notifier().PfdChangeNotifier.PatchPfdSub(subID, pfdSubsc)
+	if err != nil {
+		pd := openapi.ProblemDetailsDataNotFound(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	c.JSON(http.StatusOK, patched)
+}
+
 func (p *Processor) genPfdSubscriptionURI(subID string) string {
 	// E.g. "https://localhost:29505/nnef-pfdmanagement/v1/subscriptions/{subscriptionId}
 	return fmt.Sprintf("%s/subscriptions/%s", p.Config().ServiceUri(factory.ServiceNefPfd), subID)
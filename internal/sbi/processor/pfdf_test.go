@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
@@ -17,9 +19,15 @@ func TestGetApplicationsPFD(t *testing.T) {
 	initUDRDrGetPfdDatasStub()
 	defer gock.Off()
 
+	pfdDataForApp1Feat := withCachingTimer(pfdDataForApp1)
+	pfdDataForApp1Feat.SupportedFeatures = "1"
+	pfdDataForApp2Feat := withCachingTimer(pfdDataForApp2)
+	pfdDataForApp2Feat.SupportedFeatures = "1"
+
 	testCases := []struct {
 		description      string
 		appIDs           []string
+		suppFeat         string
 		expectedResponse *HandlerResponse
 	}{
 		{
@@ -27,7 +35,10 @@ func TestGetApplicationsPFD(t *testing.T) {
 			appIDs:      []string{"app1", "app2"},
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusOK,
-				Body:   &[]models.PfdDataForApp{pfdDataForApp1, pfdDataForApp2},
+				Body: &[]models.PfdDataForApp{
+					withCachingTimer(pfdDataForApp1),
+					withCachingTimer(pfdDataForApp2),
+				},
 			},
 		},
 		{
@@ -38,6 +49,15 @@ func TestGetApplicationsPFD(t *testing.T) {
 				Body:   &models.ProblemDetails{Status: http.StatusNotFound},
 			},
 		},
+		{
+			description: "TC3: Supported features requested, should return the negotiated ones",
+			appIDs:      []string{"app1", "app2"},
+			suppFeat:    "3",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]models.PfdDataForApp{pfdDataForApp1Feat, pfdDataForApp2Feat},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -45,7 +65,7 @@ func TestGetApplicationsPFD(t *testing.T) {
 			httpRecorder := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(httpRecorder)
 
-			nefApp.Processor().GetApplicationsPFD(c, tc.appIDs)
+			nefApp.Processor().GetApplicationsPFD(c, tc.appIDs, tc.suppFeat)
 			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
 
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
@@ -57,9 +77,13 @@ func TestGetIndividualApplicationPFD(t *testing.T) {
 	initUDRDrGetPfdDataStub()
 	defer gock.Off()
 
+	pfdDataForApp1Feat := withCachingTimer(pfdDataForApp1)
+	pfdDataForApp1Feat.SupportedFeatures = "1"
+
 	testCases := []struct {
 		description      string
 		appID            string
+		suppFeat         string
 		expectedResponse *HandlerResponse
 	}{
 		{
@@ -67,7 +91,7 @@ func TestGetIndividualApplicationPFD(t *testing.T) {
 			appID:       "app1",
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusOK,
-				Body:   &pfdDataForApp1,
+				Body:   withCachingTimer(pfdDataForApp1),
 			},
 		},
 		{
@@ -78,6 +102,15 @@ func TestGetIndividualApplicationPFD(t *testing.T) {
 				Body:   &models.ProblemDetails{Status: http.StatusNotFound},
 			},
 		},
+		{
+			description: "TC3: Supported features requested, should return the negotiated ones",
+			appID:       "app1",
+			suppFeat:    "1",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &pfdDataForApp1Feat,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -85,7 +118,7 @@ func TestGetIndividualApplicationPFD(t *testing.T) {
 			httpRecorder := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(httpRecorder)
 
-			nefApp.Processor().GetIndividualApplicationPFD(c, tc.appID)
+			nefApp.Processor().GetIndividualApplicationPFD(c, tc.appID, tc.suppFeat)
 			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
 
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
@@ -109,10 +142,7 @@ func TestPostPFDSubscriptions(t *testing.T) {
 			subscription: pfdSubsc,
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusCreated,
-				Headers: map[string][]string{
-					"Location": {nefApp.Processor().genPfdSubscriptionURI("1")},
-				},
-				Body: pfdSubsc,
+				Body:   pfdSubsc,
 			},
 		},
 	}
@@ -125,12 +155,140 @@ func TestPostPFDSubscriptions(t *testing.T) {
 			nefApp.Processor().PostPFDSubscriptions(c, tc.subscription)
 			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
 
+			location := httpRecorder.Header().Get("Location")
+			subsPrefix := nefApp.Processor().genPfdSubscriptionURI("")
+			require.True(t, strings.HasPrefix(location, subsPrefix))
+			require.NoError(t, nefApp.Notifier().PfdChangeNotifier.DeletePfdSub(
+				strings.TrimPrefix(location, subsPrefix)))
+
+			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
+		})
+	}
+}
+
+func TestGetIndividualPFDSubscription(t *testing.T) {
+	pfdSubsc := &models.PfdSubscription{
+		ApplicationIds:    []string{"app1"},
+		NotifyUri:         "http://pfdSub1URI/notify",
+		SupportedFeatures: "1",
+	}
+	subID := nefApp.Notifier().PfdChangeNotifier.AddPfdSub(pfdSubsc)
+	defer func() {
+		_ = nefApp.Notifier().PfdChangeNotifier.DeletePfdSub(subID)
+	}()
+
+	testCases := []struct {
+		description      string
+		subscriptionID   string
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description:    "TC1: Subscription found, should return PfdSubscription",
+			subscriptionID: subID,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   pfdSubsc,
+			},
+		},
+		{
+			description:    "TC2: Subscription not found, should return ProblemDetails",
+			subscriptionID: "unknown",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body:   openapi.ProblemDetailsDataNotFound("subscription not found"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Processor().GetIndividualPFDSubscription(c, tc.subscriptionID)
+			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
+
+			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
+		})
+	}
+}
+
+func TestPatchIndividualPFDSubscription(t *testing.T) {
+	subID := nefApp.Notifier().PfdChangeNotifier.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds:    []string{"app1"},
+		NotifyUri:         "http://pfdSub1URI/notify",
+		SupportedFeatures: "1",
+	})
+	defer func() {
+		_ = nefApp.Notifier().PfdChangeNotifier.DeletePfdSub(subID)
+	}()
+
+	testCases := []struct {
+		description      string
+		subscriptionID   string
+		patch            *models.PfdSubscription
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description:    "TC1: Application list replaced, should return PfdSubscription",
+			subscriptionID: subID,
+			patch: &models.PfdSubscription{
+				ApplicationIds: []string{"app2", "app3"},
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body: &models.PfdSubscription{
+					ApplicationIds:    []string{"app2", "app3"},
+					NotifyUri:         "http://pfdSub1URI/notify",
+					SupportedFeatures: "1",
+				},
+			},
+		},
+		{
+			description:    "TC2: Notify URI replaced, should return PfdSubscription",
+			subscriptionID: subID,
+			patch: &models.PfdSubscription{
+				NotifyUri: "http://pfdSub1URI/moved",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body: &models.PfdSubscription{
+					ApplicationIds:    []string{"app2", "app3"},
+					NotifyUri:         "http://pfdSub1URI/moved",
+					SupportedFeatures: "1",
+				},
+			},
+		},
+		{
+			description:    "TC3: Subscription not found, should return ProblemDetails",
+			subscriptionID: "unknown",
+			patch:          &models.PfdSubscription{},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body:   openapi.ProblemDetailsDataNotFound("subscription not found"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Processor().PatchIndividualPFDSubscription(c, tc.subscriptionID, tc.patch)
+			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
+
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
 		})
 	}
 }
 
 func TestDeleteIndividualPFDSubscription(t *testing.T) {
+	subID := nefApp.Notifier().PfdChangeNotifier.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      "http://pfdSub1URI/notify",
+	})
+
 	testCases := []struct {
 		description      string
 		subscriptionID   string
@@ -138,7 +296,7 @@ func TestDeleteIndividualPFDSubscription(t *testing.T) {
 	}{
 		{
 			description:    "TC1: Successful unsubscription",
-			subscriptionID: "1",
+			subscriptionID: subID,
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusNoContent,
 			},
@@ -172,7 +330,6 @@ var (
 )
 
 func TestPostPfdChangeReports(t *testing.T) {
-	// Note: Because TestPostPFDSubscriptions() already used subscription ID 1, the ID will start from 2 here.
 	initUDRDrPutPfdDataStub(http.StatusOK)
 	initUDRDrDeletePfdDataStub()
 	initNEFNotificationStub("http://pfdSub2URI")
@@ -281,6 +438,11 @@ func TestPostPfdChangeReports(t *testing.T) {
 	}
 }
 
+func withCachingTimer(pfdDataForApp models.PfdDataForApp) models.PfdDataForApp {
+	pfdDataForApp.CachingTimer = factory.NefDefaultPfdCachingTime
+	return pfdDataForApp
+}
+
 func initNEFNotificationStub(notifyURI string) {
 	gock.New(notifyURI).
 		Post("/notify").
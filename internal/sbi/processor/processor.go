@@ -1,9 +1,16 @@
 package processor
 
 import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/logger"
 	"github.com/free5gc/nef/internal/sbi/consumer"
 	"github.com/free5gc/nef/internal/sbi/notifier"
 	"github.com/free5gc/nef/pkg/app"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/bsf/Management"
+	"github.com/free5gc/openapi/models"
 )
 
 type nef interface {
@@ -27,6 +34,13 @@ func NewProcessor(nef nef) (*Processor, error) {
 	handler := &Processor{
 		nef: nef,
 	}
+	if n := nef.Notifier(); n != nil {
+		n.PfdChangeNotifier.SetReportHandler(handler.RelayPfdChangeReports)
+	}
+	if nef.Context() != nil {
+		handler.armRestoredMonSubExpiries()
+		handler.armRestoredNiddDlTransferExpiries()
+	}
 
 	return handler, nil
 }
@@ -39,3 +53,76 @@ func addLocationheader(header map[string][]string, location string) {
 		header["Location"] = append(locations, location)
 	}
 }
+
+// selectPcf looks up the BSF binding of the UE's PDU session and returns the
+// Npcf_PolicyAuthorization URI of the PCF owning it. The URI is empty, making
+// the consumer use the PCF discovered via NRF, only when no BSF is deployed.
+func (p *Processor) selectPcf(ascReqData *models.AppSessionContextReqData) (string, *models.ProblemDetails) {
+	if p.Config().PcfSelection() == factory.PcfSelectionNrf {
+		return "", nil
+	}
+
+	param := &Management.GetPCFBindingsRequest{}
+	switch {
+	case ascReqData.UeIpv4 != "":
+		param.SetIpv4Addr(ascReqData.UeIpv4)
+		if ascReqData.IpDomain != "" {
+			param.SetIpDomain(ascReqData.IpDomain)
+		}
+	case ascReqData.UeIpv6 != "":
+		param.SetIpv6Prefix(ascReqData.UeIpv6)
+	case ascReqData.UeMac != "":
+		param.SetMacAddr48(ascReqData.UeMac)
+	default:
+		return "", openapi.ProblemDetailsMalformedReqSyntax("No UE address to select the PCF")
+	}
+	if ascReqData.Dnn != "" {
+		param.SetDnn(ascReqData.Dnn)
+	}
+	if ascReqData.SliceInfo != nil {
+		param.SetSnssai(*ascReqData.SliceInfo)
+	}
+
+	binding, pd := p.findPcfBinding(param)
+	if pd != nil {
+		return "", pd
+	}
+	return p.pcfOfBinding(binding)
+}
+
+// findPcfBinding queries the BSF for the PCF binding of the PDU session. A
+// failed query is answered with 503 and no binding with 404.
+func (p *Processor) findPcfBinding(
+	param *Management.GetPCFBindingsRequest,
+) (*models.PcfBinding, *models.ProblemDetails) {
+	binding, pd, err := p.Consumer().GetPcfBinding(param)
+	switch {
+	case pd != nil:
+		logger.ProcessorLog.Warnf("PCF binding query failed: %s", pd.Detail)
+		return nil, problemDetailsPcfUnavailable("PCF binding query failed: " + pd.Detail)
+	case err != nil:
+		logger.ProcessorLog.Warnf("PCF binding query failed: %+v", err)
+		return nil, problemDetailsPcfUnavailable("PCF binding query failed")
+	case binding == nil:
+		return nil, openapi.ProblemDetailsDataNotFound("No PCF binding found for the UE")
+	}
+	return binding, nil
+}
+
+// pcfOfBinding returns the Npcf_PolicyAuthorization apiRoot of the PCF bound
+// to the PDU session.
+func (p *Processor) pcfOfBinding(binding *models.PcfBinding) (string, *models.ProblemDetails) {
+	pcfUri := p.Consumer().PcfPaUriFromBinding(binding)
+	if pcfUri == "" {
+		return "", problemDetailsPcfUnavailable("PCF binding holds no PCF address")
+	}
+	return pcfUri, nil
+}
+
+func problemDetailsPcfUnavailable(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Service Unavailable",
+		Status: http.StatusServiceUnavailable,
+		Detail: detail,
+	}
+}
@@ -2,6 +2,8 @@ package processor
 
 import (
 	"net/http"
+	"reflect"
+	"strconv"
 
 	"github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
@@ -13,6 +15,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// The NEF requests all the flows of a subscription in one media component.
+const qosMedCompN = 1
+
 // ListAsSessionQosSubs returns all QoS subscriptions for an SCS/AS.
 func (p *Processor) ListAsSessionQosSubs(c *gin.Context, scsAsID string) {
 	af := p.Context().GetAf(scsAsID)
@@ -26,17 +31,36 @@ func (p *Processor) ListAsSessionQosSubs(c *gin.Context, scsAsID string) {
 	af.Mu.RLock()
 	defer af.Mu.RUnlock()
 
-	var subs []models.AppSessionContext
+	var subs []models.AsSessionWithQoSSubscription
 	for _, sub := range af.QosSubs {
-		if sub.Payload != nil {
-			subs = append(subs, *sub.Payload)
+		if sub.QosSub != nil {
+			subs = append(subs, *sub.QosSub)
 		}
 	}
 	c.JSON(http.StatusOK, subs)
 }
 
 // PostAsSessionQosSub creates a QoS subscription and relays it to PCF.
-func (p *Processor) PostAsSessionQosSub(c *gin.Context, scsAsID string, asc *models.AppSessionContext) {
+func (p *Processor) PostAsSessionQosSub(
+	c *gin.Context,
+	scsAsID string,
+	qosSubReq *models.AsSessionWithQoSSubscription,
+) {
+	if pd := validateAsSessionWithQoSSubscription(qosSubReq); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServiceAsSessionQos, qosSubReq.SupportedFeatures)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	qosSubReq.SupportedFeatures = suppFeat
+	removeUnsupportedQosFeatures(qosSubReq)
+
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -50,8 +74,15 @@ func (p *Processor) PostAsSessionQosSub(c *gin.Context, scsAsID string, asc *mod
 	}
 
 	corrID := uuid.New().String()
+	asc := p.convertAsSessionWithQoSSubToAppSessionContext(qosSubReq, corrID)
+	pcfUri, pd := p.selectPcf(asc.AscReqData)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
 
-	appSessID, pd, err := p.Consumer().PostAppSessions(asc)
+	appSessID, pd, err := p.Consumer().PostAppSessions(pcfUri, asc)
 	switch {
 	case pd != nil:
 		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -68,26 +99,27 @@ func (p *Processor) PostAsSessionQosSub(c *gin.Context, scsAsID string, asc *mod
 	defer af.Mu.Unlock()
 
 	subID := uuid.New().String()
+	qosSubReq.Self = p.genAsSessionQosURI(scsAsID, subID)
 	qosSub := &context.AfQosSubscription{
 		SubscriptionID: subID,
 		AppSessID:      appSessID,
+		PcfUri:         pcfUri,
 		NotifCorrID:    corrID,
-		Payload:        asc,
+		QosSub:         qosSubReq,
 		Log:            af.Log.WithField(logger.FieldSubID, subID),
 	}
 	af.AddQosSubscription(qosSub)
 	nefCtx.AddAf(af)
 
-	self := p.genAsSessionQosURI(scsAsID, subID)
 	headers := map[string][]string{
-		"Location": {self},
+		"Location": {qosSubReq.Self},
 	}
 	for hdrName, hdrValues := range headers {
 		for _, hdrValue := range hdrValues {
 			c.Header(hdrName, hdrValue)
 		}
 	}
-	c.JSON(http.StatusCreated, asc)
+	c.JSON(http.StatusCreated, qosSubReq)
 }
 
 // GetAsSessionQosSub returns a stored QoS subscription representation.
@@ -109,31 +141,79 @@ func (p *Processor) GetAsSessionQosSub(c *gin.Context, scsAsID, subID string) {
 		c.JSON(int(pd.Status), pd)
 		return
 	}
-	c.JSON(http.StatusOK, qosSub.Payload)
+	c.JSON(http.StatusOK, qosSub.QosSub)
 }
 
-// PutAsSessionQosSub updates a QoS subscription (idempotent) and relays to PCF.
+// PutAsSessionQosSub replaces a QoS subscription and relays to PCF. Attributes
+// absent from the request are removed from the subscription.
 func (p *Processor) PutAsSessionQosSub(
 	c *gin.Context,
 	scsAsID, subID string,
-	ascUpdate *models.AppSessionContextUpdateData,
+	qosSubReq *models.AsSessionWithQoSSubscription,
 ) {
-	p.updateAsSessionQosSub(c, scsAsID, subID, ascUpdate)
-}
+	if pd := validateAsSessionWithQoSSubscription(qosSubReq); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
 
-// PatchAsSessionQosSub updates a QoS subscription (partial) and relays to PCF.
-func (p *Processor) PatchAsSessionQosSub(
-	c *gin.Context,
-	scsAsID, subID string,
-	ascUpdate *models.AppSessionContextUpdateData,
-) {
-	p.updateAsSessionQosSub(c, scsAsID, subID, ascUpdate)
+	suppFeat, pd := p.negotiateSuppFeat(factory.ServiceAsSessionQos, qosSubReq.SupportedFeatures)
+	if pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	qosSubReq.SupportedFeatures = suppFeat
+	removeUnsupportedQosFeatures(qosSubReq)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("SCS/AS is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	qosSub, ok := af.GetQosSubscription(subID)
+	if !ok || qosSub.QosSub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("QoS subscription is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	qosSubReq.Self = qosSub.QosSub.Self
+	if appSessionReplaceRequired(qosSub.QosSub, qosSubReq) {
+		pd := p.replaceAppSession(qosSub, qosSubReq)
+		if pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	} else {
+		ascUpdate := p.convertAsSessionWithQoSSubToAppSessionContextUpdateData(
+			qosSub.QosSub, qosSubReq, qosSub.NotifCorrID)
+		if pd := p.patchAppSession(qosSub, ascUpdate); pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+
+	qosSub.QosSub = qosSubReq
+	af.Persist()
+	c.JSON(http.StatusOK, qosSub.QosSub)
 }
 
-func (p *Processor) updateAsSessionQosSub(
+// PatchAsSessionQosSub applies the merge patch to a QoS subscription and relays
+// the resulting changes to PCF.
+func (p *Processor) PatchAsSessionQosSub(
 	c *gin.Context,
 	scsAsID, subID string,
-	ascUpdate *models.AppSessionContextUpdateData,
+	qosSubPatch []byte,
 ) {
 	af := p.Context().GetAf(scsAsID)
 	if af == nil {
@@ -147,33 +227,93 @@ func (p *Processor) updateAsSessionQosSub(
 	defer af.Mu.Unlock()
 
 	qosSub, ok := af.GetQosSubscription(subID)
-	if !ok {
+	if !ok || qosSub.QosSub == nil {
 		pd := openapi.ProblemDetailsDataNotFound("QoS subscription is not found")
 		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
 		c.JSON(int(pd.Status), pd)
 		return
 	}
 
-	respAsc, pd, err := p.Consumer().PatchAppSession(qosSub.AppSessID, ascUpdate)
-	switch {
-	case pd != nil:
+	// The patch is applied locally first, so GET reflects it whatever PCF answers
+	origQosSub := qosSub.QosSub
+	if err := qosSub.PatchQosSubData(qosSubPatch); err != nil {
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
 		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
 		c.JSON(int(pd.Status), pd)
 		return
-	case err != nil:
-		problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-		c.JSON(int(problemDetails.Status), problemDetails)
+	}
+	removeUnsupportedQosFeatures(qosSub.QosSub)
+	if pd := validateAsSessionWithQoSSubscription(qosSub.QosSub); pd != nil {
+		qosSub.QosSub = origQosSub
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
 		return
 	}
 
-	if respAsc != nil {
-		qosSub.Payload = respAsc
-		c.JSON(http.StatusOK, respAsc)
+	var pd *models.ProblemDetails
+	if appSessionReplaceRequired(origQosSub, qosSub.QosSub) {
+		pd = p.replaceAppSession(qosSub, qosSub.QosSub)
+	} else {
+		ascUpdate := p.convertAsSessionWithQoSSubToAppSessionContextUpdateData(
+			origQosSub, qosSub.QosSub, qosSub.NotifCorrID)
+		pd = p.patchAppSession(qosSub, ascUpdate)
+	}
+	if pd != nil {
+		qosSub.QosSub = origQosSub
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	af.Persist()
+	c.JSON(http.StatusOK, qosSub.QosSub)
+}
+
+func (p *Processor) patchAppSession(
+	qosSub *context.AfQosSubscription,
+	ascUpdate *models.AppSessionContextUpdateData,
+) *models.ProblemDetails {
+	_, pd, err := p.Consumer().PatchAppSession(qosSub.PcfUri, qosSub.AppSessID, ascUpdate)
+	switch {
+	case pd != nil:
+		return pd
+	case err != nil:
+		return openapi.ProblemDetailsSystemFailure(err.Error())
+	}
+	return nil
+}
+
+// replaceAppSession moves the subscription to a new app session created with
+// qosSubReq. The new app session is set up before the old one is released.
+func (p *Processor) replaceAppSession(
+	qosSub *context.AfQosSubscription,
+	qosSubReq *models.AsSessionWithQoSSubscription,
+) *models.ProblemDetails {
+	asc := p.convertAsSessionWithQoSSubToAppSessionContext(qosSubReq, qosSub.NotifCorrID)
+	pcfUri, pd := p.selectPcf(asc.AscReqData)
+	if pd != nil {
+		return pd
+	}
+
+	appSessID, pd, err := p.Consumer().PostAppSessions(pcfUri, asc)
+	switch {
+	case pd != nil:
+		return pd
+	case err != nil:
+		return openapi.ProblemDetailsSystemFailure(err.Error())
+	}
+
+	_, pd, err = p.Consumer().DeleteAppSession(qosSub.PcfUri, qosSub.AppSessID)
+	switch {
+	case pd != nil:
+		qosSub.Log.Warnf("Delete replaced app session failed: %s", pd.Detail)
+	case err != nil:
+		qosSub.Log.Warnf("Delete replaced app session failed: %+v", err)
+	}
+
+	qosSub.AppSessID = appSessID
+	qosSub.PcfUri = pcfUri
+	return nil
 }
 
 // DeleteAsSessionQosSub deletes a QoS subscription and relays to PCF.
@@ -197,7 +337,7 @@ func (p *Processor) DeleteAsSessionQosSub(c *gin.Context, scsAsID, subID string)
 		return
 	}
 
-	rspCode, pd, err := p.Consumer().DeleteAppSession(qosSub.AppSessID)
+	rspCode, pd, err := p.Consumer().DeleteAppSession(qosSub.PcfUri, qosSub.AppSessID)
 	switch {
 	case pd != nil:
 		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -219,6 +359,310 @@ func (p *Processor) DeleteAsSessionQosSub(c *gin.Context, scsAsID, subID string)
 	c.Status(rspCode)
 }
 
+func validateAsSessionWithQoSSubscription(
+	qosSub *models.AsSessionWithQoSSubscription,
+) *models.ProblemDetails {
+	if qosSub.NotificationDestination == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing notificationDestination")
+	}
+
+	// TS29.122: One of "ueIpv4Addr", "ueIpv6Addr" or "macAddr" shall be included.
+	ueAddrs := 0
+	for _, addr := range []string{qosSub.UeIpv4Addr, qosSub.UeIpv6Addr, qosSub.MacAddr} {
+		if addr != "" {
+			ueAddrs++
+		}
+	}
+	if ueAddrs != 1 {
+		return openapi.ProblemDetailsMalformedReqSyntax(
+			"Exactly one of ueIpv4Addr, ueIpv6Addr or macAddr shall be included")
+	}
+
+	// TS29.122: "flowInfo" goes with an IP address, "ethFlowInfo" or
+	// "enEthFlowInfo" with a MAC address.
+	if qosSub.MacAddr == "" && len(qosSub.FlowInfo) == 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing flowInfo")
+	}
+	if qosSub.MacAddr != "" && len(qosSub.EthFlowInfo) == 0 && len(qosSub.EnEthFlowInfo) == 0 {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing one of ethFlowInfo or enEthFlowInfo")
+	}
+
+	if qosSub.QosReference == "" && qosSub.TscQosReq == nil {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing one of qosReference or tscQosReq")
+	}
+	if len(qosSub.AltQoSReferences) > 0 && qosSub.QosReference == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("altQoSReferences requires qosReference")
+	}
+	return nil
+}
+
 func (p *Processor) genAsSessionQosURI(scsAsID, subID string) string {
-	return factory.AsSessionQosResUriPrefix + "/" + scsAsID + "/subscriptions/" + subID
+	// E.g. https://localhost:29505/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions/{subId}
+	return p.Config().ServiceUri(factory.ServiceAsSessionQos) + "/" + scsAsID + "/subscriptions/" + subID
+}
+
+func (p *Processor) genQosNotificationUri(notifCorrID string) string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/qos/" + notifCorrID
+}
+
+// convertAsSessionWithQoSSubToAppSessionContext translates the northbound
+// subscription into the Npcf_PolicyAuthorization request, the PCF reports to
+// the NEF callback which relays them to the AF.
+func (p *Processor) convertAsSessionWithQoSSubToAppSessionContext(
+	qosSub *models.AsSessionWithQoSSubscription,
+	notifCorrID string,
+) *models.AppSessionContext {
+	notifUri := p.genQosNotificationUri(notifCorrID)
+	ascReqData := &models.AppSessionContextReqData{
+		AfAppId:   qosSub.ExterAppId,
+		Dnn:       qosSub.Dnn,
+		SliceInfo: qosSub.Snssai,
+		UeIpv4:    qosSub.UeIpv4Addr,
+		UeIpv6:    qosSub.UeIpv6Addr,
+		UeMac:     qosSub.MacAddr,
+		IpDomain:  qosSub.IpDomain,
+		NotifUri:  notifUri,
+		SuppFeat:  qosSub.SupportedFeatures,
+		MedComponents: map[string]models.MediaComponent{
+			strconv.Itoa(qosMedCompN): convertAsSessionWithQoSSubToMediaComponent(qosSub),
+		},
+		EvSubsc: &models.PcfPolicyAuthorizationEventsSubscReqData{
+			Events:         convertUserPlaneEventsToAfEvents(qosSub.Events, qosSub.UsageThreshold != nil),
+			NotifUri:       notifUri,
+			UsgThres:       qosSub.UsageThreshold,
+			DirectNotifInd: qosSub.DirectNotifInd,
+		},
+	}
+	if qosSub.QosMonInfo != nil {
+		ascReqData.EvSubsc.ReqQosMonParams = qosSub.QosMonInfo.ReqQosMonParams
+		ascReqData.EvSubsc.QosMon = &models.PcfPolicyAuthorizationQosMonitoringInformation{
+			RepThreshDl: qosSub.QosMonInfo.RepThreshDl,
+			RepThreshUl: qosSub.QosMonInfo.RepThreshUl,
+			RepThreshRp: qosSub.QosMonInfo.RepThreshRp,
+		}
+	}
+	if qosSub.SponsorInfo != nil {
+		ascReqData.SponId = qosSub.SponsorInfo.SponsorId
+		ascReqData.AspId = qosSub.SponsorInfo.AspId
+		ascReqData.SponStatus = models.SponsoringStatus_ENABLED
+	}
+	return &models.AppSessionContext{AscReqData: ascReqData}
+}
+
+// convertAsSessionWithQoSSubToAppSessionContextUpdateData builds the merge
+// patch bringing the app session from origQosSub to qosSub: the media component
+// and the event subscription are sent in full and flows which are gone are
+// removed with null.
+func (p *Processor) convertAsSessionWithQoSSubToAppSessionContextUpdateData(
+	origQosSub, qosSub *models.AsSessionWithQoSSubscription,
+	notifCorrID string,
+) *models.AppSessionContextUpdateData {
+	asc := p.convertAsSessionWithQoSSubToAppSessionContext(qosSub, notifCorrID)
+	medComp := asc.AscReqData.MedComponents[strconv.Itoa(qosMedCompN)]
+	evSubsc := asc.AscReqData.EvSubsc
+
+	medCompRm := convertMediaComponentToRm(&medComp)
+	origMedSubComps := convertFlowsToMediaSubComponents(
+		origQosSub.FlowInfo, origQosSub.EthFlowInfo, origQosSub.EnEthFlowInfo)
+	for fNum := range origMedSubComps {
+		if _, ok := medComp.MedSubComps[fNum]; !ok {
+			if medCompRm.MedSubComps == nil {
+				medCompRm.MedSubComps = make(map[string]*models.MediaSubComponentRm)
+			}
+			medCompRm.MedSubComps[fNum] = nil
+		}
+	}
+
+	ascUpdate := &models.AppSessionContextUpdateData{
+		AfAppId: qosSub.ExterAppId,
+		MedComponents: map[string]*models.MediaComponentRm{
+			strconv.Itoa(qosMedCompN): medCompRm,
+		},
+		EvSubsc: &models.PcfPolicyAuthorizationEventsSubscReqDataRm{
+			Events:          evSubsc.Events,
+			NotifUri:        evSubsc.NotifUri,
+			ReqQosMonParams: evSubsc.ReqQosMonParams,
+			DirectNotifInd:  evSubsc.DirectNotifInd,
+		},
+	}
+	if evSubsc.UsgThres != nil {
+		ascUpdate.EvSubsc.UsgThres = &models.UsageThresholdRm{
+			Duration:       evSubsc.UsgThres.Duration,
+			TotalVolume:    evSubsc.UsgThres.TotalVolume,
+			DownlinkVolume: evSubsc.UsgThres.DownlinkVolume,
+			UplinkVolume:   evSubsc.UsgThres.UplinkVolume,
+		}
+	}
+	if evSubsc.QosMon != nil {
+		ascUpdate.EvSubsc.QosMon = &models.PcfPolicyAuthorizationQosMonitoringInformationRm{
+			RepThreshDl: evSubsc.QosMon.RepThreshDl,
+			RepThreshUl: evSubsc.QosMon.RepThreshUl,
+			RepThreshRp: evSubsc.QosMon.RepThreshRp,
+		}
+	}
+	return ascUpdate
+}
+
+// appSessionReplaceRequired tells whether the change from origQosSub to qosSub
+// cannot be sent as an app session update: the attributes binding the app
+// session to the PDU session are not modifiable, and the update data has no way
+// to remove some of the others.
+func appSessionReplaceRequired(origQosSub, qosSub *models.AsSessionWithQoSSubscription) bool {
+	if origQosSub.UeIpv4Addr != qosSub.UeIpv4Addr ||
+		origQosSub.UeIpv6Addr != qosSub.UeIpv6Addr ||
+		origQosSub.MacAddr != qosSub.MacAddr ||
+		origQosSub.IpDomain != qosSub.IpDomain ||
+		origQosSub.Dnn != qosSub.Dnn ||
+		origQosSub.SupportedFeatures != qosSub.SupportedFeatures ||
+		!reflect.DeepEqual(origQosSub.Snssai, qosSub.Snssai) ||
+		!reflect.DeepEqual(origQosSub.SponsorInfo, qosSub.SponsorInfo) {
+		return true
+	}
+
+	return (origQosSub.ExterAppId != "" && qosSub.ExterAppId == "") ||
+		(len(origQosSub.AltQoSReferences) > 0 && len(qosSub.AltQoSReferences) == 0) ||
+		(len(origQosSub.AltQosReqs) > 0 && len(qosSub.AltQosReqs) == 0) ||
+		(origQosSub.UsageThreshold != nil && qosSub.UsageThreshold == nil) ||
+		(origQosSub.QosMonInfo != nil && qosSub.QosMonInfo == nil) ||
+		(origQosSub.TscQosReq != nil && qosSub.TscQosReq == nil) ||
+		(origQosSub.DisUeNotif && !qosSub.DisUeNotif) ||
+		(origQosSub.DirectNotifInd && !qosSub.DirectNotifInd)
+}
+
+func convertAsSessionWithQoSSubToMediaComponent(
+	qosSub *models.AsSessionWithQoSSubscription,
+) models.MediaComponent {
+	medComp := models.MediaComponent{
+		MedCompN:       qosMedCompN,
+		QosReference:   qosSub.QosReference,
+		AltSerReqs:     qosSub.AltQoSReferences,
+		AltSerReqsData: qosSub.AltQosReqs,
+		DisUeNotif:     qosSub.DisUeNotif,
+		MedSubComps:    convertFlowsToMediaSubComponents(qosSub.FlowInfo, qosSub.EthFlowInfo, qosSub.EnEthFlowInfo),
+	}
+	if tscQosReq := qosSub.TscQosReq; tscQosReq != nil {
+		medComp.MarBwDl = tscQosReq.ReqMbrDl
+		medComp.MarBwUl = tscQosReq.ReqMbrUl
+		medComp.MirBwDl = tscQosReq.ReqGbrDl
+		medComp.MirBwUl = tscQosReq.ReqGbrUl
+		medComp.TscaiInputDl = tscQosReq.TscaiInputDl
+		medComp.TscaiInputUl = tscQosReq.TscaiInputUl
+		medComp.TscaiTimeDom = tscQosReq.TscaiTimeDom
+	}
+	return medComp
+}
+
+func convertMediaComponentToRm(medComp *models.MediaComponent) *models.MediaComponentRm {
+	medCompRm := &models.MediaComponentRm{
+		MedCompN:       medComp.MedCompN,
+		QosReference:   medComp.QosReference,
+		AltSerReqs:     medComp.AltSerReqs,
+		AltSerReqsData: medComp.AltSerReqsData,
+		DisUeNotif:     medComp.DisUeNotif,
+		MarBwDl:        medComp.MarBwDl,
+		MarBwUl:        medComp.MarBwUl,
+		MirBwDl:        medComp.MirBwDl,
+		MirBwUl:        medComp.MirBwUl,
+		TscaiInputDl:   medComp.TscaiInputDl,
+		TscaiInputUl:   medComp.TscaiInputUl,
+		TscaiTimeDom:   medComp.TscaiTimeDom,
+	}
+	if len(medComp.MedSubComps) > 0 {
+		medCompRm.MedSubComps = make(map[string]*models.MediaSubComponentRm)
+		for fNum, medSubComp := range medComp.MedSubComps {
+			medCompRm.MedSubComps[fNum] = &models.MediaSubComponentRm{
+				FNum:      medSubComp.FNum,
+				FDescs:    medSubComp.FDescs,
+				EthfDescs: medSubComp.EthfDescs,
+			}
+		}
+	}
+	return medCompRm
+}
+
+// convertFlowsToMediaSubComponents keys the media subcomponents by the flow
+// identifier of the AF, so the PCF reports refer to the AF's flow IDs.
+// Ethernet flows without an identifier are numbered in order.
+func convertFlowsToMediaSubComponents(
+	flowInfos []models.FlowInfo,
+	ethFlowDescs []models.EthFlowDescription,
+	enEthFlowInfos []models.EthFlowInfo,
+) map[string]models.MediaSubComponent {
+	if len(flowInfos) == 0 && len(ethFlowDescs) == 0 && len(enEthFlowInfos) == 0 {
+		return nil
+	}
+
+	medSubComps := make(map[string]models.MediaSubComponent)
+	for _, flowInfo := range flowInfos {
+		medSubComps[strconv.Itoa(int(flowInfo.FlowId))] = models.MediaSubComponent{
+			FNum:   flowInfo.FlowId,
+			FDescs: flowInfo.FlowDescriptions,
+		}
+	}
+	for i, ethFlowDesc := range ethFlowDescs {
+		fNum := int32(i + 1)
+		medSubComps[strconv.Itoa(int(fNum))] = models.MediaSubComponent{
+			FNum:      fNum,
+			EthfDescs: []models.EthFlowDescription{ethFlowDesc},
+		}
+	}
+	for _, ethFlowInfo := range enEthFlowInfos {
+		medSubComps[strconv.Itoa(int(ethFlowInfo.FlowId))] = models.MediaSubComponent{
+			FNum:      ethFlowInfo.FlowId,
+			EthfDescs: ethFlowInfo.EthFlowDescriptions,
+		}
+	}
+	return medSubComps
+}
+
+// convertUserPlaneEventsToAfEvents maps the user plane events of TS 29.122
+// clause 5.14.2.1.5 to the Npcf_PolicyAuthorization events. QoS notification
+// control is subscribed by default, SESSION_TERMINATION needs no subscription.
+func convertUserPlaneEventsToAfEvents(
+	events []models.UserPlaneEvent,
+	usageThreshold bool,
+) []models.AfEventSubscription {
+	afEvents := make(map[models.PcfPolicyAuthorizationAfEvent]bool)
+	if len(events) == 0 {
+		afEvents[models.PcfPolicyAuthorizationAfEvent_QOS_NOTIF] = true
+		if usageThreshold {
+			afEvents[models.PcfPolicyAuthorizationAfEvent_USAGE_REPORT] = true
+		}
+	}
+
+	for _, event := range events {
+		switch event {
+		case models.UserPlaneEvent_QOS_GUARANTEED, models.UserPlaneEvent_QOS_NOT_GUARANTEED:
+			afEvents[models.PcfPolicyAuthorizationAfEvent_QOS_NOTIF] = true
+		case models.UserPlaneEvent_USAGE_REPORT:
+			afEvents[models.PcfPolicyAuthorizationAfEvent_USAGE_REPORT] = true
+		case models.UserPlaneEvent_FAILED_RESOURCES_ALLOCATION:
+			afEvents[models.PcfPolicyAuthorizationAfEvent_FAILED_RESOURCES_ALLOCATION] = true
+		case models.UserPlaneEvent_SUCCESSFUL_RESOURCES_ALLOCATION:
+			afEvents[models.PcfPolicyAuthorizationAfEvent_SUCCESSFUL_RESOURCES_ALLOCATION] = true
+		case models.UserPlaneEvent_QOS_MONITORING:
+			afEvents[models.PcfPolicyAuthorizationAfEvent_QOS_MONITORING] = true
+		case models.UserPlaneEvent_ACCESS_TYPE_CHANGE:
+			afEvents[models.PcfPolicyAuthorizationAfEvent_ACCESS_TYPE_CHANGE] = true
+		case models.UserPlaneEvent_PLMN_CHG:
+			afEvents[models.PcfPolicyAuthorizationAfEvent_PLMN_CHG] = true
+		}
+	}
+
+	// Keep a stable order for the PCF
+	var afEventSubs []models.AfEventSubscription
+	for _, afEvent := range []models.PcfPolicyAuthorizationAfEvent{
+		models.PcfPolicyAuthorizationAfEvent_QOS_NOTIF,
+		models.PcfPolicyAuthorizationAfEvent_USAGE_REPORT,
+		models.PcfPolicyAuthorizationAfEvent_FAILED_RESOURCES_ALLOCATION,
+		models.PcfPolicyAuthorizationAfEvent_SUCCESSFUL_RESOURCES_ALLOCATION,
+		models.PcfPolicyAuthorizationAfEvent_QOS_MONITORING,
+		models.PcfPolicyAuthorizationAfEvent_ACCESS_TYPE_CHANGE,
+		models.PcfPolicyAuthorizationAfEvent_PLMN_CHG,
+	} {
+		if afEvents[afEvent] {
+			afEventSubs = append(afEventSubs, models.AfEventSubscription{Event: afEvent})
+		}
+	}
+	return afEventSubs
 }
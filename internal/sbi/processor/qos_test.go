@@ -1,8 +1,349 @@
 package processor
 
-import "testing"
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
 
-// Placeholder test to mark QoS processor coverage; replace with gock-based integration stubs.
-func TestAsSessionQosPlaceholder(t *testing.T) {
-	t.Skip("QoS processor tests not implemented yet")
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var qosSub1ForAf1 = models.AsSessionWithQoSSubscription{
+	ExterAppId:              "App1",
+	Dnn:                     "internet",
+	NotificationDestination: "http://127.0.0.100:8000/qos/notify",
+	UeIpv4Addr:              "10.60.0.1",
+	FlowInfo: []models.FlowInfo{
+		{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from 192.168.0.21 to 10.60.0.1"},
+		},
+	},
+	QosReference: "qos-ref-1",
+	Events:       []models.UserPlaneEvent{models.UserPlaneEvent_QOS_NOT_GUARANTEED},
+}
+
+func TestAsSessionQosNotification(t *testing.T) {
+	initNRFDiscPCFStub()
+	defer gock.Off()
+
+	postMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		BodyString(`"afAppId":"App1".*"evSubsc":\{"events":\[\{"event":"QOS_NOTIF"\}\],`+
+			`"notifUri":"http://127.0.0.5:8000/nnef-callback/v1/notification/qos/.*"\}.*`+
+			`"medComponents":\{"1":\{"qosReference":"qos-ref-1","medCompN":1,`+
+			`"medSubComps":\{"1":\{"fNum":1,"fDescs":\["permit out ip from 192.168.0.21 to 10.60.0.1"\]\}\}\}\}.*`+
+			`"notifUri":"http://127.0.0.5:8000/nnef-callback/v1/notification/qos/.*"ueIpv4":"10.60.0.1"`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/qos1").
+		JSON(models.AppSessionContext{}).Mock
+
+	qosSubInvalid := qosSub1ForAf1
+	qosSubInvalid.FlowInfo = nil
+
+	testCases := []struct {
+		description    string
+		qosSub         *models.AsSessionWithQoSSubscription
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Missing flowInfo for an IP UE address",
+			qosSub:         &qosSubInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "TC2: Valid subscription, relayed to PCF",
+			qosSub:         &qosSub1ForAf1,
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	var httpRecorder *httptest.ResponseRecorder
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder = httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			qosSub := *tc.qosSub
+			nefApp.Processor().PostAsSessionQosSub(c, "af1", &qosSub)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+		})
+	}
+	require.True(t, postMock.Done())
+
+	af := nefApp.Context().GetAf("af1")
+	require.NotNil(t, af)
+	require.Len(t, af.QosSubs, 1)
+	var subID string
+	for id := range af.QosSubs {
+		subID = id
+	}
+	qosSub := af.QosSubs[subID]
+	require.Equal(t, "qos1", qosSub.AppSessID)
+	self := nefApp.Config().ServiceUri(factory.ServiceAsSessionQos) + "/af1/subscriptions/" + subID
+	require.Equal(t, self, httpRecorder.Header().Get("Location"))
+	// The AF gets the northbound representation with its own notification destination
+	expectedSub := qosSub1ForAf1
+	expectedSub.Self = self
+	assertJSONBodyEqual(t, &expectedSub, httpRecorder.Body.Bytes())
+
+	// QoS notification control reports are relayed as user plane events
+	notifyMock := gock.New("http://127.0.0.100:8000").
+		Post("/qos/notify").
+		BodyString(`"transaction":".*/3gpp-as-session-with-qos/v1/af1/subscriptions/` + subID + `".*` +
+			`"event":"QOS_NOT_GUARANTEED","flowIds":\[1,2\]`).
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().AsSessionQosEventNotification(c, qosSub.NotifCorrID,
+		&models.PcfPolicyAuthorizationEventsNotification{
+			EvNotifs: []models.PcfPolicyAuthorizationAfEventNotification{
+				{Event: models.PcfPolicyAuthorizationAfEvent_QOS_NOTIF},
+			},
+			QncReports: []models.PcfPolicyAuthorizationQosNotificationControlInfo{
+				{
+					NotifType: models.QosNotifType_NOT_GUARANTEED,
+					Flows:     []models.Flows{{MedCompN: 1, FNums: []int32{1, 2}}},
+				},
+			},
+		})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, notifyMock.Done())
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().AsSessionQosEventNotification(c, "unknown",
+		&models.PcfPolicyAuthorizationEventsNotification{})
+	require.Equal(t, http.StatusNotFound, httpRecorder.Code)
+
+	// A new notification destination is kept by the NEF, the relay follows it
+	patchMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Patch("/app-sessions/qos1").
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchAsSessionQosSub(c, "af1", subID,
+		[]byte(`{"notificationDestination":"http://127.0.0.100:8000/qos/notify2"}`))
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.True(t, patchMock.Done())
+	expectedSub.NotificationDestination = "http://127.0.0.100:8000/qos/notify2"
+	assertJSONBodyEqual(t, &expectedSub, httpRecorder.Body.Bytes())
+
+	// Session termination is relayed and the app session is released
+	termMock := gock.New("http://127.0.0.100:8000").
+		Post("/qos/notify2").
+		BodyString(`"event":"SESSION_TERMINATION"`).
+		Reply(http.StatusNoContent).Mock
+	deleteMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/qos1/delete").
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().AsSessionQosTermination(c, qosSub.NotifCorrID, &models.TerminationInfo{
+		TermCause: models.PcfPolicyAuthorizationTerminationCause_PDU_SESSION_TERMINATION,
+		ResUri:    "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/qos1",
+	})
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, termMock.Done())
+	require.True(t, deleteMock.Done())
+	require.Empty(t, af.QosSubs)
+
+	nefApp.Context().DeleteAf("af1")
+}
+
+func TestPutPatchAsSessionQosSub(t *testing.T) {
+	initNRFDiscPCFStub()
+	defer gock.Off()
+
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/qos1").
+		JSON(models.AppSessionContext{})
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	qosSubReq := qosSub1ForAf1
+	nefApp.Processor().PostAsSessionQosSub(c, "af1", &qosSubReq)
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+
+	af := nefApp.Context().GetAf("af1")
+	require.NotNil(t, af)
+	var subID string
+	for id := range af.QosSubs {
+		subID = id
+	}
+	qosSub := af.QosSubs[subID]
+
+	// PATCH replaces the flows, the flow gone is removed from PCF with null,
+	// other attributes keep their value even though PCF answers 204
+	patchMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Patch("/app-sessions/qos1").
+		AddMatcher(bodyContains(`"medSubComps":{"1":null,"2":{"fNum":2`)).
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchAsSessionQosSub(c, "af1", subID,
+		[]byte(`{"flowInfo":[{"flowId":2,"flowDescriptions":["permit out ip from 192.168.0.22 to 10.60.0.1"]}]}`))
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.True(t, patchMock.Done())
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().GetAsSessionQosSub(c, "af1", subID)
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	expectedSub := qosSub1ForAf1
+	expectedSub.Self = nefApp.Config().ServiceUri(factory.ServiceAsSessionQos) + "/af1/subscriptions/" + subID
+	expectedSub.FlowInfo = []models.FlowInfo{
+		{
+			FlowId:           2,
+			FlowDescriptions: []string{"permit out ip from 192.168.0.22 to 10.60.0.1"},
+		},
+	}
+	assertJSONBodyEqual(t, &expectedSub, httpRecorder.Body.Bytes())
+
+	// A rejected PATCH leaves the subscription untouched
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Patch("/app-sessions/qos1").
+		Reply(http.StatusBadRequest).
+		JSON(models.ProblemDetails{Status: http.StatusBadRequest, Cause: "INVALID_QOS_REFERENCE"})
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchAsSessionQosSub(c, "af1", subID, []byte(`{"qosReference":"qos-ref-2"}`))
+	require.Equal(t, http.StatusBadRequest, httpRecorder.Code)
+	require.Equal(t, "qos-ref-1", qosSub.QosSub.QosReference)
+
+	// PUT changing the UE address cannot be an update, the app session is replaced
+	postMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		BodyString(`"ueIpv4":"10.60.0.2"`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/qos2").
+		JSON(models.AppSessionContext{}).Mock
+	deleteMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/qos1/delete").
+		Reply(http.StatusNoContent).Mock
+
+	putReq := qosSub1ForAf1
+	putReq.UeIpv4Addr = "10.60.0.2"
+	putReq.Events = nil
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PutAsSessionQosSub(c, "af1", subID, &putReq)
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.True(t, postMock.Done())
+	require.True(t, deleteMock.Done())
+	require.Equal(t, "qos2", qosSub.AppSessID)
+
+	// Attributes absent from the PUT body are gone
+	expectedSub = qosSub1ForAf1
+	expectedSub.Self = putReq.Self
+	expectedSub.UeIpv4Addr = "10.60.0.2"
+	expectedSub.Events = nil
+	assertJSONBodyEqual(t, &expectedSub, httpRecorder.Body.Bytes())
+
+	nefApp.Context().DeleteAf("af1")
+}
+
+func TestPatchAsSessionQosSubMergePatch(t *testing.T) {
+	initNRFDiscPCFStub()
+	defer gock.Off()
+
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/qos1").
+		JSON(models.AppSessionContext{})
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	qosSubReq := qosSub1ForAf1
+	nefApp.Processor().PostAsSessionQosSub(c, "af1", &qosSubReq)
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+
+	af := nefApp.Context().GetAf("af1")
+	require.NotNil(t, af)
+	var subID string
+	for id := range af.QosSubs {
+		subID = id
+	}
+	qosSub := af.QosSubs[subID]
+	expectedSub := qosSub1ForAf1
+	expectedSub.Self = nefApp.Config().ServiceUri(factory.ServiceAsSessionQos) + "/af1/subscriptions/" + subID
+
+	// Attributes outside of the patch schema are not modifiable
+	patchMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Patch("/app-sessions/qos1").
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchAsSessionQosSub(c, "af1", subID,
+		[]byte(`{"altQoSReferences":["qos-ref-2"],"disUeNotif":true,"ueIpv4Addr":"10.60.0.2"}`))
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.True(t, patchMock.Done())
+	expectedSub.AltQoSReferences = []string{"qos-ref-2"}
+	expectedSub.DisUeNotif = true
+	assertJSONBodyEqual(t, &expectedSub, httpRecorder.Body.Bytes())
+
+	// null removes an attribute and false resets a boolean, which the app
+	// session update cannot express: the app session is replaced
+	postMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/qos2").
+		JSON(models.AppSessionContext{}).Mock
+	deleteMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/qos1/delete").
+		Reply(http.StatusNoContent).Mock
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchAsSessionQosSub(c, "af1", subID, []byte(`{"altQoSReferences":null,"disUeNotif":false}`))
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	require.True(t, postMock.Done())
+	require.True(t, deleteMock.Done())
+	require.Equal(t, "qos2", qosSub.AppSessID)
+
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().GetAsSessionQosSub(c, "af1", subID)
+	require.Equal(t, http.StatusOK, httpRecorder.Code)
+	expectedSub.AltQoSReferences = nil
+	expectedSub.DisUeNotif = false
+	assertJSONBodyEqual(t, &expectedSub, httpRecorder.Body.Bytes())
+
+	// A patch which is not a JSON object is malformed
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PatchAsSessionQosSub(c, "af1", subID, []byte(`["qos-ref-3"]`))
+	require.Equal(t, http.StatusBadRequest, httpRecorder.Code)
+
+	nefApp.Context().DeleteAf("af1")
+}
+
+// bodyContains matches requests whose body contains substr, for the content
+// types the body matchers of gock do not handle.
+func bodyContains(substr string) gock.MatchFunc {
+	return func(req *http.Request, _ *gock.Request) (bool, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return false, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return bytes.Contains(body, []byte(substr)), nil
+	}
 }
@@ -0,0 +1,201 @@
+package processor
+
+import (
+	"net/http"
+
+	"github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+)
+
+// PostSmContext Create an SM context for the NIDD PDU session of a UE, the
+// downlink data buffered for the UE is then delivered
+// 3GPP TS 29.541 release 17 version 17.3.0
+// Resource structure: 5.2.3.2
+// Request/Response  : 5.2.3.2.3.1
+func (p *Processor) PostSmContext(c *gin.Context, createData *models.NefSmContextSmContextCreateData) {
+	logger.NiddLog.Infof("PostSmContext - supi[%s], pduSessionId[%d]", createData.Supi, createData.PduSessionId)
+
+	if pd := validateSmContextCreateData(createData); pd != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	afID, gpsi := createData.NiddInfo.AfId, createData.NiddInfo.Gpsi
+
+	nefCtx := p.Context()
+	var conf *context.AfNiddConfiguration
+	var maxPacketSize int32
+	if af := nefCtx.GetAf(afID); af != nil {
+		af.Mu.RLock()
+		if conf = af.FindNiddConf(gpsi); conf != nil && conf.NiddConf != nil {
+			maxPacketSize = conf.NiddConf.MaximumPacketSize
+		}
+		af.Mu.RUnlock()
+	}
+	if conf == nil {
+		pd := openapi.ProblemDetailsForbidden("No NIDD configuration of the UE", "NIDD_CONFIGURATION_NOT_AVAILABLE")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	// A re-established PDU session replaces the SM context of the UE
+	if old := nefCtx.FindSmContext(afID, gpsi); old != nil {
+		nefCtx.DeleteSmContext(old.SmContextID)
+	}
+
+	smCtx := nefCtx.NewSmContext()
+	smCtx.CreateData = createData
+	nefCtx.AddSmContext(smCtx)
+
+	go p.flushNiddDlTransfers(afID, gpsi)
+
+	c.Header("Location", p.genSmContextURI(smCtx.SmContextID))
+	c.JSON(http.StatusCreated, &models.NefSmContextSmContextCreatedData{
+		Supi:          createData.Supi,
+		PduSessionId:  createData.PduSessionId,
+		Dnn:           createData.Dnn,
+		Snssai:        createData.Snssai,
+		NefId:         nefCtx.NfInstID(),
+		MaxPacketSize: maxPacketSize,
+	})
+}
+
+// ReleaseSmContext Release the SM context of a NIDD PDU session
+// 3GPP TS 29.541 release 17 version 17.3.0
+// Resource structure: 5.2.3.3
+// Request/Response  : 5.2.3.3.4.2
+func (p *Processor) ReleaseSmContext(
+	c *gin.Context,
+	smContextID string,
+	releaseData *models.NefSmContextSmContextReleaseData,
+) {
+	logger.NiddLog.Infof("ReleaseSmContext - smContextID[%s], cause[%s]", smContextID, releaseData.Cause)
+
+	nefCtx := p.Context()
+	if nefCtx.GetSmContext(smContextID) == nil {
+		pd := openapi.ProblemDetailsDataNotFound("SM context is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+	nefCtx.DeleteSmContext(smContextID)
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateSmContext Update the SM context of a NIDD PDU session
+// 3GPP TS 29.541 release 17 version 17.3.0
+// Resource structure: 5.2.3.3
+// Request/Response  : 5.2.3.3.4.4
+func (p *Processor) UpdateSmContext(
+	c *gin.Context,
+	smContextID string,
+	updateData *models.NefSmContextSmContextUpdateData,
+) {
+	logger.NiddLog.Infof("UpdateSmContext - smContextID[%s]", smContextID)
+
+	smCtx := p.Context().GetSmContext(smContextID)
+	if smCtx == nil {
+		pd := openapi.ProblemDetailsDataNotFound("SM context is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	smCtx.Mu.Lock()
+	defer smCtx.Mu.Unlock()
+
+	if updateData.DlNiddEndPoint != "" {
+		smCtx.CreateData.DlNiddEndPoint = updateData.DlNiddEndPoint
+	}
+	if updateData.NotificationUri != "" {
+		smCtx.CreateData.NotificationUri = updateData.NotificationUri
+	}
+	if updateData.SmContextConfig != nil {
+		smCtx.CreateData.SmContextConfig = updateData.SmContextConfig
+	}
+	smCtx.Persist()
+	c.Status(http.StatusNoContent)
+}
+
+// DeliverSmContext Relay the MO data of the UE to the AF, the downlink data
+// buffered for the UE is then delivered
+// 3GPP TS 29.541 release 17 version 17.3.0
+// Resource structure: 5.2.3.3
+// Request/Response  : 5.2.3.3.4.3
+func (p *Processor) DeliverSmContext(c *gin.Context, smContextID string, deliverReq *models.DeliverRequest) {
+	logger.NiddLog.Infof("DeliverSmContext - smContextID[%s]", smContextID)
+
+	if len(deliverReq.BinaryMtData) == 0 {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing data")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusBadRequest, pd)
+		return
+	}
+
+	nefCtx := p.Context()
+	smCtx := nefCtx.GetSmContext(smContextID)
+	if smCtx == nil {
+		pd := openapi.ProblemDetailsDataNotFound("SM context is not found")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	smCtx.Mu.RLock()
+	afID, gpsi := smCtx.AfID(), smCtx.Gpsi()
+	smCtx.Mu.RUnlock()
+
+	var notifUri string
+	var notif *nef_models.NiddUplinkDataNotification
+	if af := nefCtx.GetAf(afID); af != nil {
+		af.Mu.RLock()
+		if conf := af.FindNiddConf(gpsi); conf != nil && conf.NiddConf != nil {
+			notifUri = conf.NiddConf.NotificationDestination
+			notif = niddUplinkDataNotification(conf, deliverReq.BinaryMtData)
+		}
+		af.Mu.RUnlock()
+	}
+	if notif == nil {
+		pd := openapi.ProblemDetailsForbidden("No NIDD configuration of the UE", "NIDD_CONFIGURATION_NOT_AVAILABLE")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	if err := p.Notifier().NiddNotifier.NotifyUplinkData(notifUri, notif); err != nil {
+		smCtx.Log.Errorf("Failed to notify AF of the uplink data: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure("Notification to AF failed")
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	// The UE is reachable while it sends data
+	go p.flushNiddDlTransfers(afID, gpsi)
+
+	c.Status(http.StatusNoContent)
+}
+
+func validateSmContextCreateData(createData *models.NefSmContextSmContextCreateData) *models.ProblemDetails {
+	if createData.Supi == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing supi")
+	}
+	if createData.DlNiddEndPoint == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing dlNiddEndPoint")
+	}
+	if createData.NiddInfo == nil || createData.NiddInfo.AfId == "" || createData.NiddInfo.Gpsi == "" {
+		return openapi.ProblemDetailsMalformedReqSyntax("Missing niddInfo afId or gpsi")
+	}
+	return nil
+}
+
+func (p *Processor) genSmContextURI(smContextID string) string {
+	return p.Config().ServiceUri(factory.ServiceNefSmCtx) + "/sm-contexts/" + smContextID
+}
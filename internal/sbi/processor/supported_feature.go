@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+// Optional features of the NEF APIs, numbered as the bits of supportedFeatures
+const (
+	// 3gpp-traffic-influence: afAckInd, addrPreserInd, simConnInd, simConnTerm and maxAllowedUpLat
+	suppFeatTiUrllc = 3
+	// 3gpp-pfd-management: the applications refused are reported in pfdReports
+	// while the others are provisioned, instead of rejecting the whole request
+	suppFeatPfdPartialFailure = 1
+	// 3gpp-as-session-with-qos: qosMonInfo and the QOS_MONITORING event
+	suppFeatQosMonitoring = 3
+)
+
+// nefSuppFeats are the features implemented on each API, supported unless the
+// suppFeat of serviceList narrows them
+var nefSuppFeats = map[string]string{
+	factory.ServiceTraffInflu:   "4",
+	factory.ServicePfdMng:       "1",
+	factory.ServiceAsSessionQos: "4",
+	factory.ServiceNefPfd:       "1", // partial update, applied by the PfdChangeNotifier
+}
+
+func (p *Processor) serviceSuppFeat(serviceName string) string {
+	for _, srv := range p.Config().ServiceList() {
+		if srv.ServiceName == serviceName && srv.SuppFeat != "" {
+			return srv.SuppFeat
+		}
+	}
+	return nefSuppFeats[serviceName]
+}
+
+// negotiateSuppFeat returns the features supported by both the NEF and the
+// consumer on the API, "" when there is none in common
+func (p *Processor) negotiateSuppFeat(serviceName, suppFeat string) (string, *models.ProblemDetails) {
+	if suppFeat == "" {
+		return "", nil
+	}
+	consumerFeats, err := openapi.NewSupportedFeature(suppFeat)
+	if err != nil {
+		return "", openapi.ProblemDetailsMalformedReqSyntax("Invalid supportedFeatures: " + suppFeat)
+	}
+	nefFeats, err := openapi.NewSupportedFeature(p.serviceSuppFeat(serviceName))
+	if err != nil {
+		logger.ProcessorLog.Warnf("Invalid suppFeat of %s: %+v", serviceName, err)
+		return "", nil
+	}
+	return strings.TrimLeft(nefFeats.NegotiateWith(consumerFeats).String(), "0"), nil
+}
+
+// suppFeatEnabled tells whether the feature is in the negotiated features
+func suppFeatEnabled(suppFeat string, feature int) bool {
+	feats, err := openapi.NewSupportedFeature(suppFeat)
+	return err == nil && feats.GetFeature(feature)
+}
+
+// removeUnsupportedTiFeatures drops the attributes of the features not
+// negotiated with the AF. afAckInd and addrPreserInd belong to the URLLC
+// feature of TS 29.522, so an AF sending no suppFeat, or one without bit 3,
+// gets neither the AF acknowledgement nor the UE address preservation.
+func removeUnsupportedTiFeatures(tiSub *models.NefTrafficInfluSub) {
+	if !suppFeatEnabled(tiSub.SuppFeat, suppFeatTiUrllc) {
+		tiSub.AfAckInd = false
+		tiSub.AddrPreserInd = false
+		tiSub.SimConnInd = false
+		tiSub.SimConnTerm = 0
+		tiSub.MaxAllowedUpLat = 0
+	}
+}
+
+func removeUnsupportedTiPatchFeatures(tiSubPatch *models.NefTrafficInfluSubPatch, suppFeat string) {
+	if !suppFeatEnabled(suppFeat, suppFeatTiUrllc) {
+		tiSubPatch.AfAckInd = false
+		tiSubPatch.AddrPreserInd = false
+		tiSubPatch.SimConnInd = false
+		tiSubPatch.SimConnTerm = 0
+		tiSubPatch.MaxAllowedUpLat = 0
+	}
+}
+
+func removeUnsupportedQosFeatures(qosSub *models.AsSessionWithQoSSubscription) {
+	if !suppFeatEnabled(qosSub.SupportedFeatures, suppFeatQosMonitoring) {
+		qosSub.QosMonInfo = nil
+		qosSub.Events = slices.DeleteFunc(qosSub.Events, func(event models.UserPlaneEvent) bool {
+			return event == models.UserPlaneEvent_QOS_MONITORING
+		})
+	}
+}
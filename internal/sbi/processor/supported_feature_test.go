@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateSuppFeat(t *testing.T) {
+	cfg := nefApp.Config().Configuration
+	origServiceList := cfg.ServiceList
+	cfg.ServiceList = []factory.Service{
+		{ServiceName: factory.ServiceNefPfd},
+		{ServiceName: factory.ServiceTraffInflu, SuppFeat: "0"},
+	}
+	defer func() {
+		cfg.ServiceList = origServiceList
+	}()
+
+	testCases := []struct {
+		description      string
+		serviceName      string
+		suppFeat         string
+		expectedSuppFeat string
+		expectedStatus   int32
+	}{
+		{
+			description:      "TC1: No feature advertised by the AF",
+			serviceName:      factory.ServicePfdMng,
+			suppFeat:         "",
+			expectedSuppFeat: "",
+		},
+		{
+			description:      "TC2: PFD partial failure supported by default",
+			serviceName:      factory.ServicePfdMng,
+			suppFeat:         "3",
+			expectedSuppFeat: "1",
+		},
+		{
+			description:      "TC3: Feature disabled by the suppFeat of serviceList",
+			serviceName:      factory.ServiceTraffInflu,
+			suppFeat:         "4",
+			expectedSuppFeat: "",
+		},
+		{
+			description:      "TC4: QoS monitoring not advertised by the AF",
+			serviceName:      factory.ServiceAsSessionQos,
+			suppFeat:         "3",
+			expectedSuppFeat: "",
+		},
+		{
+			description:    "TC5: Invalid supportedFeatures",
+			serviceName:    factory.ServicePfdMng,
+			suppFeat:       "xyz",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			suppFeat, pd := nefApp.Processor().negotiateSuppFeat(tc.serviceName, tc.suppFeat)
+			if tc.expectedStatus != 0 {
+				require.NotNil(t, pd)
+				require.Equal(t, tc.expectedStatus, pd.Status)
+				return
+			}
+			require.Nil(t, pd)
+			require.Equal(t, tc.expectedSuppFeat, suppFeat)
+			require.Equal(t, tc.expectedSuppFeat != "" && tc.serviceName == factory.ServicePfdMng,
+				suppFeatEnabled(suppFeat, suppFeatPfdPartialFailure))
+		})
+	}
+}
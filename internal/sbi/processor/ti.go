@@ -6,6 +6,7 @@ import (
 	"github.com/free5gc/nef/internal/logger"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/bsf/Management"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/metrics/sbi"
 	"github.com/gin-gonic/gin"
@@ -61,6 +62,14 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 		return
 	}
 
+	tiSub.SuppFeat, problemDetails = p.negotiateSuppFeat(factory.ServiceTraffInflu, tiSub.SuppFeat)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+	removeUnsupportedTiFeatures(tiSub)
+
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(afID)
 	if af == nil {
@@ -88,7 +97,14 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 	if len(tiSub.Gpsi) > 0 || len(tiSub.Ipv4Addr) > 0 || len(tiSub.Ipv6Addr) > 0 {
 		// Single UE, sent to PCF
 		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID)
-		appSessId, pd, err := p.Consumer().PostAppSessions(asc)
+		pcfUri, pd := p.bindUe(afID, tiSub, asc)
+		if pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+
+		appSessId, pd, err := p.Consumer().PostAppSessions(pcfUri, asc)
 		switch {
 		case pd != nil:
 			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -104,11 +120,17 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 			return
 		default:
 			afSub.AppSessID = appSessId
+			afSub.PcfUri = pcfUri
 		}
 	} else if len(tiSub.ExternalGroupId) > 0 || tiSub.AnyUeInd {
 		// Group or any UE, sent to UDR
 		afSub.InfluID = uuid.New().String()
-		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID)
+		tiData, pd := p.convertTrafficInfluSubToTrafficInfluData(afID, tiSub, afSub.NotifCorreID)
+		if pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
 
 		_, pd, err := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData)
 		switch {
@@ -203,6 +225,14 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return
 	}
 
+	tiSub.SuppFeat, problemDetails = p.negotiateSuppFeat(factory.ServiceTraffInflu, tiSub.SuppFeat)
+	if problemDetails != nil {
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(int(problemDetails.Status), problemDetails)
+		return
+	}
+	removeUnsupportedTiFeatures(tiSub)
+
 	af := p.Context().GetAf(afID)
 	if af == nil {
 		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
@@ -222,10 +252,17 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return
 	}
 
-	afSub.TiSub = tiSub
+	// The subscription is replaced only once the PCF or UDR accepts it
 	if afSub.AppSessID != "" {
 		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID)
-		appSessId, pd, err := p.Consumer().PostAppSessions(asc)
+		pcfUri, pd := p.bindUe(afID, tiSub, asc)
+		if pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+
+		appSessId, pd, err := p.Consumer().PostAppSessions(pcfUri, asc)
 
 		switch {
 		case pd != nil:
@@ -240,11 +277,23 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 			c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
 			c.JSON(int(problemDetails.Status), problemDetails)
 			return
-		default:
-			afSub.AppSessID = appSessId
 		}
+
+		// The new app session may be on another PCF, the old one is removed
+		if _, pd, err := p.Consumer().DeleteAppSession(afSub.PcfUri, afSub.AppSessID); pd != nil {
+			afSub.Log.Warnf("Delete replaced app session failed: %s", pd.Detail)
+		} else if err != nil {
+			afSub.Log.Warnf("Delete replaced app session failed: %+v", err)
+		}
+		afSub.AppSessID = appSessId
+		afSub.PcfUri = pcfUri
 	} else if afSub.InfluID != "" {
-		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID)
+		tiData, pd := p.convertTrafficInfluSubToTrafficInfluData(afID, tiSub, afSub.NotifCorreID)
+		if pd != nil {
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
 
 		_, pd, err := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData)
 		switch {
@@ -268,6 +317,9 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return
 	}
 
+	afSub.TiSub = tiSub
+	af.Persist()
+
 	c.JSON(http.StatusOK, afSub.TiSub)
 }
 
@@ -302,10 +354,11 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 		return
 	}
 
+	removeUnsupportedTiPatchFeatures(tiSubPatch, afSub.TiSub.SuppFeat)
 	if afSub.AppSessID != "" {
 		ascUpdateData := p.convertTrafficInfluSubPatchToAppSessionContextUpdateData(tiSubPatch)
 
-		_, pd, err := p.Consumer().PatchAppSession(afSub.AppSessID, ascUpdateData)
+		_, pd, err := p.Consumer().PatchAppSession(afSub.PcfUri, afSub.AppSessID, ascUpdateData)
 		switch {
 		case pd != nil:
 			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
@@ -345,6 +398,7 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 	}
 
 	afSub.PatchTiSubData(tiSubPatch)
+	af.Persist()
 	c.JSON(http.StatusOK, afSub.TiSub)
 }
 
@@ -378,7 +432,7 @@ func (p *Processor) DeleteIndividualTrafficInfluenceSubscription(
 	}
 
 	if sub.AppSessID != "" {
-		_, pd, err := p.Consumer().DeleteAppSession(sub.AppSessID)
+		_, pd, err := p.Consumer().DeleteAppSession(sub.PcfUri, sub.AppSessID)
 		switch {
 		case err != nil:
 			problemDetails := &models.ProblemDetails{
@@ -409,7 +463,7 @@ func (p *Processor) DeleteIndividualTrafficInfluenceSubscription(
 			return
 		}
 	}
-	delete(af.Subs, subID)
+	af.DeleteSub(subID)
 	c.Status(http.StatusNoContent)
 }
 
@@ -454,6 +508,10 @@ func (p *Processor) genNotificationUri() string {
 	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/smf"
 }
 
+func (p *Processor) genAfAckUri(notifCorreID string) string {
+	return p.genNotificationUri() + "/" + notifCorreID + "/ack"
+}
+
 func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 	tiSub *models.NefTrafficInfluSub,
 	notifCorreID string,
@@ -469,6 +527,7 @@ func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 			UeIpv4:    tiSub.Ipv4Addr,
 			UeIpv6:    tiSub.Ipv6Addr,
 			UeMac:     tiSub.MacAddr,
+			Gpsi:      tiSub.Gpsi,
 			NotifUri:  tiSub.NotificationDestination,
 			SuppFeat:  tiSub.SuppFeat,
 			Dnn:       tiSub.Dnn,
@@ -482,11 +541,66 @@ func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 			DnaiChgType:     tiSub.DnaiChgType,
 			NotificationUri: p.genNotificationUri(),
 			NotifCorreId:    notifCorreID,
+			AfAckInd:        tiSub.AfAckInd,
 		}
 	}
 	return asc
 }
 
+// bindUe returns the PCF owning the PDU session of a single UE subscription.
+// A GPSI-only AppSessionContext is completed first: the GPSI is translated into
+// the SUPI via UDM, and the BSF binding of the UE's PDU session provides the UE
+// address and the PCF to send the request to. The PCF is selected as by
+// selectPcf, a GPSI cannot be targeted when the PCF is discovered via NRF.
+func (p *Processor) bindUe(
+	afID string,
+	tiSub *models.NefTrafficInfluSub,
+	asc *models.AppSessionContext,
+) (string, *models.ProblemDetails) {
+	if tiSub.Gpsi == "" || tiSub.Ipv4Addr != "" || tiSub.Ipv6Addr != "" {
+		return p.selectPcf(asc.AscReqData)
+	}
+	// Only the BSF binds the GPSI to the address of a PDU session
+	if p.Config().PcfSelection() == factory.PcfSelectionNrf {
+		return "", problemDetailsPcfUnavailable("No BSF to bind the GPSI to a PDU session")
+	}
+
+	supi, pd, err := p.Consumer().GetSupi(afID, tiSub.Gpsi)
+	switch {
+	case pd != nil:
+		return "", pd
+	case err != nil:
+		return "", &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Detail: "Query to UDM failed",
+		}
+	}
+
+	param := &Management.GetPCFBindingsRequest{}
+	param.SetSupi(supi)
+	param.SetGpsi(tiSub.Gpsi)
+	if tiSub.Dnn != "" {
+		param.SetDnn(tiSub.Dnn)
+	}
+	if tiSub.Snssai != nil {
+		param.SetSnssai(*tiSub.Snssai)
+	}
+
+	binding, pd := p.findPcfBinding(param)
+	if pd != nil {
+		return "", pd
+	}
+	if binding.Ipv4Addr == "" && binding.Ipv6Prefix == "" {
+		return "", openapi.ProblemDetailsDataNotFound("No PDU session is bound for the GPSI")
+	}
+
+	asc.AscReqData.Supi = supi
+	asc.AscReqData.UeIpv4 = binding.Ipv4Addr
+	asc.AscReqData.UeIpv6 = binding.Ipv6Prefix
+	asc.AscReqData.IpDomain = binding.IpDomain
+	return p.pcfOfBinding(binding)
+}
+
 func (p *Processor) convertTrafficInfluSubPatchToAppSessionContextUpdateData(
 	tiSubPatch *models.NefTrafficInfluSubPatch,
 ) *models.AppSessionContextUpdateData {
@@ -501,9 +615,10 @@ func (p *Processor) convertTrafficInfluSubPatchToAppSessionContextUpdateData(
 }
 
 func (p *Processor) convertTrafficInfluSubToTrafficInfluData(
+	afID string,
 	tiSub *models.NefTrafficInfluSub,
 	notifCorreID string,
-) *models.TrafficInfluData {
+) (*models.TrafficInfluData, *models.ProblemDetails) {
 	tiData := &models.TrafficInfluData{
 		AfAppId:    tiSub.AfAppId,
 		AppReloInd: tiSub.AppReloInd,
@@ -525,12 +640,39 @@ func (p *Processor) convertTrafficInfluSubToTrafficInfluData(
 		SupportedFeatures: tiSub.SuppFeat,
 	}
 
-	// TODO: handle ExternalGroupId
-	if tiSub.AnyUeInd {
+	if tiSub.ExternalGroupId != "" {
+		interGroupId, pd := p.resolveInterGroupId(afID, tiSub.ExternalGroupId)
+		if pd != nil {
+			return nil, pd
+		}
+		tiData.InterGroupId = interGroupId
+	} else if tiSub.AnyUeInd {
 		tiData.InterGroupId = "AnyUE"
 	}
 
-	return tiData
+	return tiData, nil
+}
+
+// resolveInterGroupId translates an ExternalGroupId into the internal group ID,
+// using the static mapping in the configuration first and Nudm_SDM otherwise.
+func (p *Processor) resolveInterGroupId(afID, extGroupId string) (string, *models.ProblemDetails) {
+	if interGroupId, ok := p.Config().StaticInterGroupId(extGroupId); ok {
+		return interGroupId, nil
+	}
+
+	groupIds, pd, err := p.Consumer().GetGroupIdentifiers(afID, extGroupId)
+	switch {
+	case pd != nil:
+		return "", pd
+	case err != nil:
+		return "", &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Detail: "Query to UDM failed",
+		}
+	case groupIds.IntGroupId == "":
+		return "", openapi.ProblemDetailsDataNotFound("ExternalGroupId is not found")
+	}
+	return groupIds.IntGroupId, nil
 }
 
 func (p *Processor) convertTrafficInfluSubPatchToTrafficInfluDataPatch(
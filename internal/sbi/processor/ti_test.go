@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -130,6 +131,36 @@ var (
 		},
 	}
 
+	tiSub6ForAf1 = models.NefTrafficInfluSub{
+		AfServiceId:     "Service6",
+		AfAppId:         "App6",
+		Dnn:             "internet",
+		ExternalGroupId: "group1@nef.free5gc.org",
+		TrafficFilters: []models.FlowInfo{
+			{
+				FlowId: 1,
+				FlowDescriptions: []string{
+					"permit out ip from 192.168.0.26 to 10.60.0.0/16",
+				},
+			},
+		},
+	}
+
+	tiSub7ForAf1 = models.NefTrafficInfluSub{
+		AfServiceId: "Service7",
+		AfAppId:     "App7",
+		Dnn:         "internet",
+		Gpsi:        "msisdn-0900000000",
+		TrafficFilters: []models.FlowInfo{
+			{
+				FlowId: 1,
+				FlowDescriptions: []string{
+					"permit out ip from 192.168.0.27 to 10.60.0.0/16",
+				},
+			},
+		},
+	}
+
 	tiSubPatch1ForAf1 = models.NefTrafficInfluSubPatch{
 		TrafficFilters: []models.FlowInfo{
 			{
@@ -378,6 +409,374 @@ func TestPostTrafficInfluenceSubscription(t *testing.T) {
 	nefCtx.ResetCorreID()
 }
 
+func TestPostTrafficInfluenceSubscriptionWithExternalGroupId(t *testing.T) {
+	initNRFDiscStub(models.NrfNfManagementNfType_UDM, models.ServiceName_NUDM_SDM, "127.0.0.3", "http://127.0.0.3:8000")
+	initUDMSdmGetGroupIdentifiersStub()
+	defer gock.Off()
+
+	cfg := nefApp.Config()
+	cfg.Configuration.ExtGroupIdMapping = map[string]string{
+		"static@nef.free5gc.org": "0002-02-0002",
+	}
+	defer func() {
+		cfg.Configuration.ExtGroupIdMapping = nil
+	}()
+
+	tiSubStatic := tiSub6ForAf1
+	tiSubStatic.ExternalGroupId = "static@nef.free5gc.org"
+	tiSubUnknown := tiSub6ForAf1
+	tiSubUnknown.ExternalGroupId = "unknown@nef.free5gc.org"
+
+	testCases := []struct {
+		description      string
+		tiSub            *models.NefTrafficInfluSub
+		interGroupId     string
+		expectedStatus   int
+		expectedResponse *models.ProblemDetails
+	}{
+		{
+			description:    "TC1: ExternalGroupId resolved by UDM",
+			tiSub:          &tiSub6ForAf1,
+			interGroupId:   "0001-01-0001",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC2: ExternalGroupId resolved by static mapping",
+			tiSub:          &tiSubStatic,
+			interGroupId:   "0002-02-0002",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC3: Unknown ExternalGroupId",
+			tiSub:          &tiSubUnknown,
+			expectedStatus: http.StatusNotFound,
+			expectedResponse: &models.ProblemDetails{
+				Status: http.StatusNotFound,
+				Cause:  "USER_NOT_FOUND",
+			},
+		},
+	}
+
+	nefCtx := nefApp.Context()
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if tc.interGroupId != "" {
+				gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+					Put("/application-data/influenceData/.*").
+					BodyString(`"interGroupId":"` + tc.interGroupId + `"`).
+					Reply(http.StatusNoContent)
+			}
+
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Processor().PostTrafficInfluenceSubscription(c, "af1", tc.tiSub)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			if tc.expectedResponse != nil {
+				assertJSONBodyEqual(t, tc.expectedResponse, httpRecorder.Body.Bytes())
+			}
+		})
+	}
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
+func TestPostTrafficInfluenceSubscriptionWithGpsi(t *testing.T) {
+	initNRFDiscStub(models.NrfNfManagementNfType_UDM, models.ServiceName_NUDM_SDM, "127.0.0.3", "http://127.0.0.3:8000")
+	initNRFDiscStub(models.NrfNfManagementNfType_BSF, models.ServiceName_NBSF_MANAGEMENT,
+		"127.0.0.15", "http://127.0.0.15:8000")
+	defer gock.Off()
+	nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionBsf
+	defer func() {
+		nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionNrf
+	}()
+
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/msisdn-0900000008/id-translation-result").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(models.IdTranslationResult{Supi: "imsi-208930000000008"})
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/msisdn-0900000000/id-translation-result").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(models.IdTranslationResult{Supi: "imsi-208930000000001"})
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/msisdn-0900000009/id-translation-result").
+		Persist().
+		Reply(http.StatusNotFound).
+		JSON(models.ProblemDetails{Status: http.StatusNotFound, Cause: "USER_NOT_FOUND"})
+
+	gock.New("http://127.0.0.15:8000/nbsf-management/v1").
+		Get("/pcfBindings").
+		MatchParam("supi", "imsi-208930000000001").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(models.PcfBinding{
+			Supi:     "imsi-208930000000001",
+			Ipv4Addr: "10.60.0.20",
+			Dnn:      "internet",
+			PcfIpEndPoints: []models.IpEndPoint{
+				{Ipv4Address: "127.0.0.17", Port: 8000},
+			},
+		})
+
+	gock.New("http://127.0.0.15:8000/nbsf-management/v1").
+		Get("/pcfBindings").
+		MatchParam("supi", "imsi-208930000000008").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(models.PcfBinding{
+			Supi:     "imsi-208930000000008",
+			Ipv4Addr: "10.60.0.28",
+			Dnn:      "internet",
+		})
+
+	// The app session must reach the PCF bound to the UE, with the resolved identities
+	gock.New("http://127.0.0.17:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		BodyString(`"supi":"imsi-208930000000001".*"ueIpv4":"10.60.0.20"`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.17:8000/npcf-policyauthorization/v1/app-sessions/67890").
+		JSON(models.AppSessionContext{})
+
+	tiSubUnknown := tiSub7ForAf1
+	tiSubUnknown.Gpsi = "msisdn-0900000009"
+	tiSubNoPcf := tiSub7ForAf1
+	tiSubNoPcf.Gpsi = "msisdn-0900000008"
+
+	testCases := []struct {
+		description      string
+		tiSub            *models.NefTrafficInfluSub
+		pcfSelection     string
+		expectedStatus   int
+		expectedResponse *models.ProblemDetails
+	}{
+		{
+			description:    "TC1: GPSI resolved by UDM and bound by BSF",
+			tiSub:          &tiSub7ForAf1,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description:    "TC2: Unknown GPSI",
+			tiSub:          &tiSubUnknown,
+			expectedStatus: http.StatusNotFound,
+			expectedResponse: &models.ProblemDetails{
+				Status: http.StatusNotFound,
+				Cause:  "USER_NOT_FOUND",
+			},
+		},
+		{
+			description:    "TC3: Binding without PCF address",
+			tiSub:          &tiSubNoPcf,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedResponse: &models.ProblemDetails{
+				Title:  "Service Unavailable",
+				Status: http.StatusServiceUnavailable,
+				Detail: "PCF binding holds no PCF address",
+			},
+		},
+		{
+			description:    "TC4: PCF discovered via NRF, no BSF to bind the GPSI",
+			tiSub:          &tiSub7ForAf1,
+			pcfSelection:   factory.PcfSelectionNrf,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedResponse: &models.ProblemDetails{
+				Title:  "Service Unavailable",
+				Status: http.StatusServiceUnavailable,
+				Detail: "No BSF to bind the GPSI to a PDU session",
+			},
+		},
+	}
+
+	nefCtx := nefApp.Context()
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionBsf
+			if tc.pcfSelection != "" {
+				nefApp.Config().Configuration.PcfSelection = tc.pcfSelection
+			}
+			nefApp.Processor().PostTrafficInfluenceSubscription(c, "af1", tc.tiSub)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			if tc.expectedResponse != nil {
+				assertJSONBodyEqual(t, tc.expectedResponse, httpRecorder.Body.Bytes())
+			}
+		})
+	}
+
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	for _, sub := range af.Subs {
+		require.Equal(t, "67890", sub.AppSessID)
+	}
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
+func TestPostTrafficInfluenceSubscriptionUrllc(t *testing.T) {
+	initNRFDiscPCFStub()
+	defer gock.Off()
+
+	tiSubUrllc := tiSub3ForAf1
+	tiSubUrllc.DnaiChgType = models.DnaiChangeType_EARLY
+	tiSubUrllc.AfAckInd = true
+	tiSubUrllc.AddrPreserInd = true
+
+	// Without the URLLC feature negotiated the AF acknowledgement is dropped
+	noAckMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/12345").
+		JSON(models.AppSessionContext{})
+
+	tiSub := tiSubUrllc
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub)
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.True(t, noAckMock.Done())
+	rspTiSub := tiSub3ForAf1
+	rspTiSub.DnaiChgType = models.DnaiChangeType_EARLY
+	rspTiSub.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "1")
+	assertJSONBodyEqual(t, &rspTiSub, httpRecorder.Body.Bytes())
+
+	// Once negotiated, the AF acknowledgement is requested from the PCF
+	ackMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		BodyString(`"upPathChgSub":\{.*"afAckInd":true`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/12346").
+		JSON(models.AppSessionContext{})
+
+	tiSub = tiSubUrllc
+	tiSub.SuppFeat = "4"
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub)
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.True(t, ackMock.Done())
+	rspTiSub = tiSubUrllc
+	rspTiSub.SuppFeat = "4"
+	rspTiSub.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "2")
+	assertJSONBodyEqual(t, &rspTiSub, httpRecorder.Body.Bytes())
+
+	nefCtx := nefApp.Context()
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
+func TestTrafficInfluenceSubscriptionPcfSelection(t *testing.T) {
+	initNRFDiscStub(models.NrfNfManagementNfType_BSF, models.ServiceName_NBSF_MANAGEMENT,
+		"127.0.0.15", "http://127.0.0.15:8000")
+	defer gock.Off()
+	nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionBsf
+	defer func() {
+		nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionNrf
+	}()
+
+	gock.New("http://127.0.0.15:8000/nbsf-management/v1").
+		Get("/pcfBindings").
+		MatchParam("ipv4Addr", tiSub3ForAf1.Ipv4Addr).
+		Persist().
+		Reply(http.StatusOK).
+		JSON(models.PcfBinding{
+			Ipv4Addr: tiSub3ForAf1.Ipv4Addr,
+			Dnn:      "internet",
+			PcfIpEndPoints: []models.IpEndPoint{
+				{Ipv4Address: "127.0.0.17", Port: 8000},
+			},
+		})
+	postMock := gock.New("http://127.0.0.17:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.17:8000/npcf-policyauthorization/v1/app-sessions/67890").
+		JSON(models.AppSessionContext{}).Mock
+	deleteMock := gock.New("http://127.0.0.17:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/67890/delete").
+		Reply(http.StatusNoContent).Mock
+
+	nefCtx := nefApp.Context()
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub3ForAf1)
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.True(t, postMock.Done())
+
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	afSub, ok := af.Subs["1"]
+	require.True(t, ok)
+	require.Equal(t, "http://127.0.0.17:8000/npcf-policyauthorization/v1", afSub.PcfUri)
+
+	// Follow-up requests go to the PCF selected at creation
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().DeleteIndividualTrafficInfluenceSubscription(c, "af1", "1")
+	c.Writer.WriteHeaderNow()
+	require.Equal(t, http.StatusNoContent, httpRecorder.Code)
+	require.True(t, deleteMock.Done())
+
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
+func TestTrafficInfluenceSubscriptionPcfSelectionFailure(t *testing.T) {
+	nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionBsf
+	defer func() {
+		nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionNrf
+	}()
+
+	testCases := []struct {
+		description    string
+		bsfStatus      int
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: No PCF binding for the UE",
+			bsfStatus:      http.StatusNoContent,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:    "TC2: BSF failure",
+			bsfStatus:      http.StatusInternalServerError,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			initNRFDiscPCFStub()
+			initNRFDiscStub(models.NrfNfManagementNfType_BSF, models.ServiceName_NBSF_MANAGEMENT,
+				"127.0.0.15", "http://127.0.0.15:8000")
+			defer gock.Off()
+
+			bsfMock := gock.New("http://127.0.0.15:8000/nbsf-management/v1").
+				Get("/pcfBindings").
+				MatchParam("ipv4Addr", tiSub3ForAf1.Ipv4Addr).
+				Reply(tc.bsfStatus)
+			if tc.bsfStatus != http.StatusNoContent {
+				bsfMock.JSON(models.ProblemDetails{Status: int32(tc.bsfStatus)})
+			}
+			// The PCF discovered via NRF shall not be used in place of the bound one
+			postMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+				Post("/app-sessions").
+				Reply(http.StatusCreated).Mock
+
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+			nefApp.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub3ForAf1)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			require.False(t, postMock.Done())
+
+			nefApp.Context().DeleteAf("af1")
+			nefApp.Context().ResetCorreID()
+		})
+	}
+}
+
 func TestDeleteIndividualTrafficInfluenceSubscription(t *testing.T) {
 	initNRFDiscPCFStub()
 	initUDRDrDeleteTiDataStub(http.StatusNoContent)
@@ -541,6 +940,11 @@ func TestPatchIndividualTrafficInfluenceSubscription(t *testing.T) {
 }
 
 func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
+	// The replaced app session is removed from the PCF, matched ahead of the
+	// app session creation
+	deleteMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/11111/delete").
+		Reply(http.StatusNoContent)
 	initNRFDiscPCFStub()
 	initUDRDrPutTiDataStub(http.StatusNoContent)
 	initPCFPaPostAppSessionsStub(http.StatusCreated)
@@ -628,7 +1032,7 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 	correID2 := nefCtx.NewCorreID()
 	afSub2 := af1.NewSub(correID2, &tiSub3ForAf1)
 	af1.Subs[afSub2.SubID] = afSub2
-	afSub2.AppSessID = "12345"
+	afSub2.AppSessID = "11111"
 	nefCtx.AddAf(af1)
 	af1.Mu.Unlock()
 
@@ -644,6 +1048,22 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
 		})
 	}
+	require.True(t, deleteMock.Done())
+	require.Equal(t, "12345", afSub2.AppSessID)
+
+	// A PUT the PCF cannot be selected for keeps the subscription
+	nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionBsf
+	defer func() {
+		nefApp.Config().Configuration.PcfSelection = factory.PcfSelectionNrf
+	}()
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	tiSub := tiSub3ForAf1
+	nefApp.Processor().PutIndividualTrafficInfluenceSubscription(c, "af1", "2", &tiSub)
+	require.Equal(t, http.StatusServiceUnavailable, httpRecorder.Code)
+	require.Equal(t, &tiSub2ForAf1, afSub2.TiSub)
+	require.Equal(t, "12345", afSub2.AppSessID)
+
 	nefCtx.DeleteAf(af1.AfID)
 	nefCtx.ResetCorreID()
 }
@@ -669,6 +1089,28 @@ func initUDRDrDeleteTiDataStub(statusCode int) {
 		Reply(statusCode)
 }
 
+func initUDMSdmGetGroupIdentifiersStub() {
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/group-data/group-identifiers").
+		MatchParam("ext-group-id", "group1@nef.free5gc.org").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(models.UdmSdmGroupIdentifiers{
+			ExtGroupId: "group1@nef.free5gc.org",
+			IntGroupId: "0001-01-0001",
+		})
+
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/group-data/group-identifiers").
+		MatchParam("ext-group-id", "unknown@nef.free5gc.org").
+		Persist().
+		Reply(http.StatusNotFound).
+		JSON(models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "USER_NOT_FOUND",
+		})
+}
+
 func initPCFPaPostAppSessionsStub(statusCode int) {
 	asc3ForAf1 := &models.AppSessionContext{
 		AscReqData: &models.AppSessionContextReqData{
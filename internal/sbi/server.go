@@ -45,33 +45,90 @@ func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
 
 	s.router.Use(metrics.InboundMetrics())
 
-	endpoints := s.getTrafficInfluenceRoutes()
-	group := s.router.Group(factory.TraffInfluResUriPrefix)
-	applyRoutes(group, endpoints)
+	sbiVerifier := s.Config().SbiTokenVerifier()
+	sbiTokenKeys, err := loadTokenKeys(sbiVerifier.KeyPems)
+	if err != nil {
+		if sbiVerifier.Enable {
+			return nil, err
+		}
+		// Only needed once the NRF requires OAuth2
+		logger.InitLog.Warnf("Load SBI token keys failed: %+v", err)
+	}
+
+	nbVerifier := s.Config().NorthboundTokenVerifier()
+	nbTokenKeys, err := loadTokenKeys(nbVerifier.KeyPems)
+	if err != nil {
+		return nil, err
+	}
+	// The northbound APIs of the AFs are mounted behind the token validation, the AF
+	// authorization and the CAPIF invocation logging
+	applyNorthboundRoutes := func(serviceName, prefix, afIDParam string, endpoints []Route) {
+		if !s.Config().ServiceEnabled(serviceName) {
+			return
+		}
+		group := s.router.Group(prefix)
+		group.Use(validateToken(serviceName, nbVerifier, nbTokenKeys, afIDParam, func() bool {
+			return nbVerifier.Enable
+		}))
+		group.Use(s.authorizeAf(serviceName, afIDParam))
+		if s.Config().CapifEnabled() {
+			group.Use(s.logApiInvocation(serviceName, afIDParam))
+		}
+		applyRoutes(group, endpoints)
+	}
 
-	endpoints = s.getPFDManagementRoutes()
-	group = s.router.Group(factory.PfdMngResUriPrefix)
-	applyRoutes(group, endpoints)
+	applyNorthboundRoutes(factory.ServiceTraffInflu, factory.TraffInfluResUriPrefix, "afID",
+		s.getTrafficInfluenceRoutes())
+	applyNorthboundRoutes(factory.ServicePfdMng, factory.PfdMngResUriPrefix, "scsAsID",
+		s.getPFDManagementRoutes())
+	applyNorthboundRoutes(factory.ServiceAsSessionQos, factory.AsSessionQosResUriPrefix, "scsAsId",
+		s.getAsSessionQosRoutes())
+	applyNorthboundRoutes(factory.ServiceMonEvt, factory.MonEvtResUriPrefix, "scsAsID",
+		s.getMonitoringEventRoutes())
+	applyNorthboundRoutes(factory.ServiceAnaExpo, factory.AnaExpoResUriPrefix, "afID",
+		s.getAnalyticsExposureRoutes())
+	applyNorthboundRoutes(factory.ServiceDevTrig, factory.DevTrigResUriPrefix, "scsAsID",
+		s.getDeviceTriggeringRoutes())
+	applyNorthboundRoutes(factory.ServiceNidd, factory.NiddResUriPrefix, "scsAsID",
+		s.getNiddRoutes())
+
+	if s.Config().ServiceEnabled(factory.ServiceNefPfd) {
+		group := s.router.Group(factory.NefPfdMngResUriPrefix)
+		group.Use(validateToken(factory.ServiceNefPfd, sbiVerifier, sbiTokenKeys, "", func() bool {
+			return sbiVerifier.Enable || s.Context().OAuth2Required
+		}))
+		applyRoutes(group, s.getPFDFRoutes())
+	}
 
-	endpoints = s.getPFDFRoutes()
-	group = s.router.Group(factory.NefPfdMngResUriPrefix)
-	applyRoutes(group, endpoints)
+	if s.Config().ServiceEnabled(factory.ServiceNefEvtExpo) {
+		group := s.router.Group(factory.NefEvtExpoResUriPrefix)
+		group.Use(validateToken(factory.ServiceNefEvtExpo, sbiVerifier, sbiTokenKeys, "", func() bool {
+			return sbiVerifier.Enable || s.Context().OAuth2Required
+		}))
+		applyRoutes(group, s.getEventExposureRoutes())
+	}
 
-	endpoints = s.getOamRoutes()
-	group = s.router.Group(factory.NefOamResUriPrefix)
-	applyRoutes(group, endpoints)
+	if s.Config().ServiceEnabled(factory.ServiceNefSmCtx) {
+		group := s.router.Group(factory.NefSmCtxResUriPrefix)
+		group.Use(validateToken(factory.ServiceNefSmCtx, sbiVerifier, sbiTokenKeys, "", func() bool {
+			return sbiVerifier.Enable || s.Context().OAuth2Required
+		}))
+		applyRoutes(group, s.getSmContextRoutes())
+	}
 
-	endpoints = s.getCallbackRoutes()
-	group = s.router.Group(factory.NefCallbackResUriPrefix)
-	applyRoutes(group, endpoints)
+	if s.Config().ServiceEnabled(factory.ServiceNefOam) {
+		group := s.router.Group(factory.NefOamResUriPrefix)
+		applyRoutes(group, s.getOamRoutes())
+	}
 
-<<<<<<< Updated upstream
-=======
-	endpoints = s.getAsSessionQosRoutes()
-	group = s.router.Group(factory.AsSessionQosResUriPrefix)
-	applyRoutes(group, endpoints)
+	// The notifications from the consumed NFs are always accepted, with a valid
+	// token when the SBI services require one
+	group := s.router.Group(factory.NefCallbackResUriPrefix)
+	group.Use(validateToken(factory.ServiceNefCallback, sbiVerifier, sbiTokenKeys, "", func() bool {
+		return sbiVerifier.Enable || s.Context().OAuth2Required
+	}))
+	applyRoutes(group, s.getCallbackRoutes())
 
->>>>>>> Stashed changes
 	s.router.Use(cors.New(cors.Config{
 		AllowMethods: []string{"GET", "POST", "OPTIONS", "PUT", "PATCH", "DELETE"},
 		AllowHeaders: []string{
@@ -86,7 +143,6 @@ func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
 
 	bindAddr := s.Config().SbiBindingAddr()
 	logger.SBILog.Infof("Binding addr: [%s]", bindAddr)
-	var err error
 	if s.httpServer, err = httpwrapper.NewHttp2Server(bindAddr, tlsKeyLogPath, s.router); err != nil {
 		logger.InitLog.Errorf("Initialize HTTP server failed: %+v", err)
 		return nil, err
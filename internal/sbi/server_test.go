@@ -0,0 +1,104 @@
+package sbi
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServerServiceList(t *testing.T) {
+	disabled := false
+	cfg := &factory.Config{
+		Info: &factory.Info{
+			Version: "1.0.1",
+		},
+		Configuration: &factory.Configuration{
+			Sbi: &factory.Sbi{
+				Scheme:       "http",
+				RegisterIPv4: "127.0.0.5",
+				BindingIPv4:  "127.0.0.5",
+				Port:         8000,
+			},
+			ServiceList: []factory.Service{
+				{ServiceName: factory.ServiceTraffInflu},
+				{ServiceName: factory.ServicePfdMng, Enable: &disabled},
+				{ServiceName: factory.ServiceNefPfd},
+			},
+		},
+	}
+	s, err := NewServer(&nefTestApp{cfg: cfg}, "")
+	require.NoError(t, err)
+
+	mounted := func(prefix string) bool {
+		for _, route := range s.router.Routes() {
+			if strings.HasPrefix(route.Path, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, mounted(factory.TraffInfluResUriPrefix))
+	require.True(t, mounted(factory.NefPfdMngResUriPrefix))
+	require.False(t, mounted(factory.PfdMngResUriPrefix))
+	require.False(t, mounted(factory.AsSessionQosResUriPrefix))
+
+	// The APIs of the disabled or absent services are not served
+	for _, uri := range []string{
+		factory.PfdMngResUriPrefix + "/af1/transactions",
+		factory.AsSessionQosResUriPrefix + "/af1/subscriptions",
+	} {
+		req := httptest.NewRequest(http.MethodGet, uri, nil)
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, req)
+		require.Equal(t, http.StatusNotFound, rsp.Code, uri)
+	}
+}
+
+func TestNewServerCallbackToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	cfg := &factory.Config{
+		Info: &factory.Info{
+			Version: "1.0.1",
+		},
+		Configuration: &factory.Configuration{
+			Sbi: &factory.Sbi{
+				Scheme:       "http",
+				RegisterIPv4: "127.0.0.5",
+				BindingIPv4:  "127.0.0.5",
+				Port:         8000,
+			},
+			TokenValidation: &factory.TokenValidation{
+				Sbi: &factory.TokenVerifier{
+					Enable:  true,
+					KeyPems: []string{writePublicKeyPem(t, "nrf.pem", &rsaKey.PublicKey)},
+				},
+			},
+		},
+	}
+	s, err := NewServer(&nefTestApp{cfg: cfg}, "")
+	require.NoError(t, err)
+
+	// The notifications are rejected without a token of the callback service
+	uri := factory.NefCallbackResUriPrefix + "/notification/smf"
+	req := httptest.NewRequest(http.MethodPost, uri, strings.NewReader("{}"))
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, req)
+	require.Equal(t, http.StatusUnauthorized, rsp.Code)
+
+	token := signToken(t, jwt.SigningMethodRS256, rsaKey,
+		newTokenClaims(factory.ServiceNefPfd, time.Now().Add(time.Hour)))
+	req = httptest.NewRequest(http.MethodPost, uri, strings.NewReader("{}"))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rsp = httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, req)
+	require.Equal(t, http.StatusForbidden, rsp.Code)
+}
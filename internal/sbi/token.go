@@ -0,0 +1,147 @@
+package sbi
+
+import (
+	"crypto"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var tokenSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+type accessTokenClaims struct {
+	Scope    string `json:"scope"`
+	ClientId string `json:"client_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// afId returns the AF the token is issued to, the subject or else the client
+func (c *accessTokenClaims) afId() string {
+	if c.Subject != "" {
+		return c.Subject
+	}
+	return c.ClientId
+}
+
+// loadTokenKeys reads the RSA or EC public keys, or the certificates holding
+// them, trusted to sign the access tokens
+func loadTokenKeys(keyPems []string) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for _, keyPem := range keyPems {
+		b, err := os.ReadFile(keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("read token key: %w", err)
+		}
+		if block, _ := pem.Decode(b); block == nil {
+			return nil, fmt.Errorf("token key %s is not PEM encoded", keyPem)
+		}
+
+		if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(b); err == nil {
+			keys = append(keys, rsaKey)
+		} else if ecKey, err := jwt.ParseECPublicKeyFromPEM(b); err == nil {
+			keys = append(keys, ecKey)
+		} else {
+			return nil, fmt.Errorf("token key %s is neither RSA nor EC: %w", keyPem, err)
+		}
+	}
+	return keys, nil
+}
+
+// validateToken rejects the requests without a valid bearer token signed by
+// one of the keys or whose scope does not cover the service, when required
+// tells the validation applies. On the northbound APIs, afIDParam names the
+// path parameter of the AF the token shall be issued to.
+func validateToken(
+	serviceName string,
+	verifier *factory.TokenVerifier,
+	keys []crypto.PublicKey,
+	afIDParam string,
+	required func() bool,
+) gin.HandlerFunc {
+	keySet := jwt.VerificationKeySet{}
+	for _, key := range keys {
+		keySet.Keys = append(keySet.Keys, key)
+	}
+
+	return func(gc *gin.Context) {
+		if !required() {
+			gc.Next()
+			return
+		}
+
+		var pd *models.ProblemDetails
+		authorization := strings.Fields(gc.GetHeader("Authorization"))
+		if len(authorization) != 2 || !strings.EqualFold(authorization[0], "Bearer") {
+			gc.Header("WWW-Authenticate", "Bearer")
+			pd = problemDetailsUnauthorized("Missing bearer token")
+		} else if claims, err := parseAccessToken(authorization[1], keySet, verifier); err != nil {
+			gc.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			pd = problemDetailsUnauthorized(err.Error())
+		} else if !slices.Contains(strings.Fields(claims.Scope), serviceName) {
+			gc.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+serviceName+`"`)
+			pd = openapi.ProblemDetailsForbidden(
+				fmt.Sprintf("Token scope does not cover %s", serviceName), causeRequestNotAuthorized)
+		} else if afID := gc.Param(afIDParam); afIDParam != "" && claims.afId() != afID {
+			pd = openapi.ProblemDetailsForbidden(
+				fmt.Sprintf("Token is not issued to AF[%s]", afID), causeRequestNotAuthorized)
+		}
+
+		if pd != nil {
+			logger.SBILog.Warnf("Reject request to %s: %s", serviceName, pd.Detail)
+			gc.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			gc.AbortWithStatusJSON(int(pd.Status), pd)
+			return
+		}
+		gc.Next()
+	}
+}
+
+func parseAccessToken(
+	accessToken string,
+	keySet jwt.VerificationKeySet,
+	verifier *factory.TokenVerifier,
+) (*accessTokenClaims, error) {
+	if len(keySet.Keys) == 0 {
+		return nil, fmt.Errorf("no key to verify the token")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(tokenSigningMethods),
+		jwt.WithExpirationRequired(),
+	}
+	if verifier.Audience != "" {
+		opts = append(opts, jwt.WithAudience(verifier.Audience))
+	}
+	if verifier.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(verifier.Issuer))
+	}
+
+	claims := &accessTokenClaims{}
+	_, err := jwt.ParseWithClaims(accessToken, claims,
+		func(*jwt.Token) (interface{}, error) {
+			return keySet, nil
+		}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return claims, nil
+}
+
+func problemDetailsUnauthorized(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Unauthorized",
+		Status: http.StatusUnauthorized,
+		Detail: detail,
+	}
+}
@@ -0,0 +1,163 @@
+package sbi
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func writePublicKeyPem(t *testing.T, name string, pubKey crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pubKey)
+	require.NoError(t, err)
+	keyPem := filepath.Join(t.TempDir(), name)
+	err = os.WriteFile(keyPem, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+	require.NoError(t, err)
+	return keyPem
+}
+
+func newTokenClaims(scope string, exp time.Time) accessTokenClaims {
+	return accessTokenClaims{
+		Scope: scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://auth.example.com",
+			Subject:   "af1",
+			Audience:  jwt.ClaimStrings{"nef"},
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key crypto.PrivateKey, claims accessTokenClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestValidateToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys, err := loadTokenKeys([]string{
+		writePublicKeyPem(t, "rsa.pem", &rsaKey.PublicKey),
+		writePublicKeyPem(t, "ec.pem", &ecKey.PublicKey),
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	verifier := &factory.TokenVerifier{
+		Enable:   true,
+		Issuer:   "https://auth.example.com",
+		Audience: "nef",
+	}
+	router.Use(validateToken(factory.ServicePfdMng, verifier, keys, "scsAsID", func() bool { return true }))
+	router.GET("/:scsAsID/resource", func(gc *gin.Context) {
+		gc.Status(http.StatusNoContent)
+	})
+
+	expiry := time.Now().Add(time.Hour)
+	claims := newTokenClaims("3gpp-pfd-management", expiry)
+	expiredClaims := newTokenClaims("3gpp-pfd-management", time.Now().Add(-time.Minute))
+	otherAfClaims := newTokenClaims("3gpp-pfd-management", expiry)
+	otherAfClaims.Subject = "af2"
+	clientClaims := newTokenClaims("3gpp-pfd-management", expiry)
+	clientClaims.Subject = ""
+	clientClaims.ClientId = "af1"
+	otherAudClaims := newTokenClaims("3gpp-pfd-management", expiry)
+	otherAudClaims.Audience = jwt.ClaimStrings{"smf"}
+	otherIssClaims := newTokenClaims("3gpp-pfd-management", expiry)
+	otherIssClaims.Issuer = "https://other.example.com"
+
+	testCases := []struct {
+		description    string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Missing token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description: "TC2: Token signed with the RSA key",
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodRS512, rsaKey,
+				newTokenClaims("nnef-pfdmanagement 3gpp-pfd-management", expiry)),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description:    "TC3: Token signed with the EC key",
+			authorization:  "Bearer " + signToken(t, jwt.SigningMethodES256, ecKey, claims),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description:    "TC4: Token signed with an unknown key",
+			authorization:  "Bearer " + signToken(t, jwt.SigningMethodRS256, otherKey, claims),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "TC5: Expired token",
+			authorization:  "Bearer " + signToken(t, jwt.SigningMethodRS256, rsaKey, expiredClaims),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description: "TC6: Scope without the service",
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodRS256, rsaKey,
+				newTokenClaims("3gpp-traffic-influence", expiry)),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC7: Token issued to another AF",
+			authorization:  "Bearer " + signToken(t, jwt.SigningMethodRS256, rsaKey, otherAfClaims),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC8: Token issued to the AF as client",
+			authorization:  "Bearer " + signToken(t, jwt.SigningMethodRS256, rsaKey, clientClaims),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description:    "TC9: Token for another audience",
+			authorization:  "Bearer " + signToken(t, jwt.SigningMethodRS256, rsaKey, otherAudClaims),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "TC10: Token from another issuer",
+			authorization:  "Bearer " + signToken(t, jwt.SigningMethodRS256, rsaKey, otherIssClaims),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/af1/resource", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rsp := httptest.NewRecorder()
+			router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedStatus, rsp.Code)
+			if rsp.Code == http.StatusUnauthorized {
+				require.NotEmpty(t, rsp.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const fileSuffix = ".json"
+
+// FileStore keeps one file per record under <root>/<bucket>/<key>.json.
+// Writes go to a temporary file first and are renamed into place, so a crash
+// never leaves a half-written record behind.
+type FileStore struct {
+	mu   sync.RWMutex
+	root string
+}
+
+var _ Store = &FileStore{}
+
+func NewFileStore(root string) (*FileStore, error) {
+	if root == "" {
+		return nil, errors.New("file store requires a path")
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("create store dir [%s]: %w", root, err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+func (s *FileStore) bucketDir(bucket string) string {
+	return filepath.Join(s.root, url.PathEscape(bucket))
+}
+
+func (s *FileStore) recordPath(bucket, key string) string {
+	return filepath.Join(s.bucketDir(bucket), url.PathEscape(key)+fileSuffix)
+}
+
+func (s *FileStore) Put(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.bucketDir(bucket)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create bucket [%s]: %w", bucket, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp record: %w", err)
+	}
+	if _, err = tmp.Write(value); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write record [%s/%s]: %w", bucket, key, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("sync record [%s/%s]: %w", bucket, key, err)
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("close record [%s/%s]: %w", bucket, key, err)
+	}
+	if err = os.Rename(tmp.Name(), s.recordPath(bucket, key)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("commit record [%s/%s]: %w", bucket, key, err)
+	}
+	return nil
+}
+
+func (s *FileStore) Get(bucket, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, err := os.ReadFile(s.recordPath(bucket, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *FileStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.recordPath(bucket, key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete record [%s/%s]: %w", bucket, key, err)
+	}
+	return nil
+}
+
+func (s *FileStore) List(bucket string) (map[string][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make(map[string][]byte)
+	entries, err := os.ReadDir(s.bucketDir(bucket))
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("list bucket [%s]: %w", bucket, err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != fileSuffix {
+			continue
+		}
+		key, err := url.PathUnescape(name[:len(name)-len(fileSuffix)])
+		if err != nil {
+			continue
+		}
+		value, err := os.ReadFile(filepath.Join(s.bucketDir(bucket), name))
+		if err != nil {
+			return nil, fmt.Errorf("read record [%s/%s]: %w", bucket, key, err)
+		}
+		records[key] = value
+	}
+	return records, nil
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
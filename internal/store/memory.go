@@ -0,0 +1,65 @@
+package store
+
+import (
+	"sync"
+)
+
+type MemoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+var _ Store = &MemoryStore{}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) Put(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		b = make(map[string][]byte)
+		s.buckets[bucket] = b
+	}
+	b[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *MemoryStore) Get(bucket, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *MemoryStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+func (s *MemoryStore) List(bucket string) (map[string][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make(map[string][]byte, len(s.buckets[bucket]))
+	for key, value := range s.buckets[bucket] {
+		records[key] = append([]byte(nil), value...)
+	}
+	return records, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	BackendMemory string = "memory"
+	BackendFile   string = "file"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+// Store is the persistence layer behind NefContext. Records are opaque byte
+// slices grouped in buckets, so every backend only has to provide a flat
+// key-value space per bucket.
+type Store interface {
+	Put(bucket, key string, value []byte) error
+	Get(bucket, key string) ([]byte, error)
+	Delete(bucket, key string) error
+	List(bucket string) (map[string][]byte, error)
+	Close() error
+}
+
+func New(backend, path string) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendFile:
+		return NewFileStore(path)
+	default:
+		return nil, fmt.Errorf("unsupported store backend [%s]", backend)
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreBackends(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		description string
+		store       Store
+	}{
+		{
+			description: "Memory backend",
+			store:       NewMemoryStore(),
+		},
+		{
+			description: "File backend",
+			store:       fileStore,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := tc.store.Get("afs", "af1")
+			require.ErrorIs(t, err, ErrNotFound)
+
+			require.NoError(t, tc.store.Put("afs", "af1", []byte(`{"afId":"af1"}`)))
+			require.NoError(t, tc.store.Put("afs", "af/2", []byte(`{"afId":"af/2"}`)))
+			require.NoError(t, tc.store.Put("afs", "af1", []byte(`{"afId":"af1","numSubscId":1}`)))
+
+			value, err := tc.store.Get("afs", "af1")
+			require.NoError(t, err)
+			require.Equal(t, `{"afId":"af1","numSubscId":1}`, string(value))
+
+			records, err := tc.store.List("afs")
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+			require.Equal(t, `{"afId":"af/2"}`, string(records["af/2"]))
+
+			require.NoError(t, tc.store.Delete("afs", "af1"))
+			require.NoError(t, tc.store.Delete("afs", "af1"))
+			records, err = tc.store.List("afs")
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+
+			records, err = tc.store.List("empty")
+			require.NoError(t, err)
+			require.Empty(t, records)
+			require.NoError(t, tc.store.Close())
+		})
+	}
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s1, err := NewFileStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, s1.Put("meta", "numCorreID", []byte("7")))
+	require.NoError(t, s1.Close())
+
+	s2, err := NewFileStore(dir)
+	require.NoError(t, err)
+	value, err := s2.Get("meta", "numCorreID")
+	require.NoError(t, err)
+	require.Equal(t, "7", string(value))
+}
@@ -7,7 +7,10 @@ package factory
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,20 +22,25 @@ import (
 )
 
 const (
-<<<<<<< Updated upstream
-	ServiceTraffInflu  string = "3gpp-traffic-influence"
-	ServicePfdMng      string = "3gpp-pfd-management"
-	ServiceNefPfd      string = string(models.ServiceName_NNEF_PFDMANAGEMENT)
-	ServiceNefOam      string = "nnef-oam"
-	ServiceNefCallback string = "nnef-callback"
-=======
 	ServiceTraffInflu   string = "3gpp-traffic-influence"
 	ServicePfdMng       string = "3gpp-pfd-management"
 	ServiceNefPfd       string = string(models.ServiceName_NNEF_PFDMANAGEMENT)
 	ServiceNefOam       string = "nnef-oam"
+	ServiceNefEvtExpo   string = string(models.ServiceName_NNEF_EVENTEXPOSURE)
+	ServiceNefSmCtx     string = string(models.ServiceName_NNEF_SMCONTEXT)
 	ServiceAsSessionQos string = "3gpp-as-session-with-qos"
+	ServiceMonEvt       string = "3gpp-monitoring-event"
+	ServiceAnaExpo      string = "3gpp-analyticsexposure"
+	ServiceDevTrig      string = "3gpp-device-triggering"
+	ServiceNidd         string = "3gpp-nidd"
 	ServiceNefCallback  string = "nnef-callback"
->>>>>>> Stashed changes
+)
+
+const (
+	// The PCF owning the PDU session of the UE is looked up in the BSF bindings
+	PcfSelectionBsf string = "bsf"
+	// No BSF is deployed, the single PCF discovered via NRF serves every UE
+	PcfSelectionNrf string = "nrf"
 )
 
 const (
@@ -49,14 +57,21 @@ const (
 	NefMetricsDefaultScheme    = "https"
 	NefMetricsDefaultNamespace = "free5gc"
 	NefDefaultNrfUri           = "https://127.0.0.10:8000"
+	NefDefaultStoreBackend     = "memory"
+	NefDefaultStorePath        = "./nefstate"
+	NefDefaultPfdCachingTime   = 3600
+	NefDefaultPcfSelection     = PcfSelectionBsf
 	TraffInfluResUriPrefix     = "/" + ServiceTraffInflu + "/v1"
 	PfdMngResUriPrefix         = "/" + ServicePfdMng + "/v1"
 	NefPfdMngResUriPrefix      = "/" + ServiceNefPfd + "/v1"
 	NefOamResUriPrefix         = "/" + ServiceNefOam + "/v1"
-<<<<<<< Updated upstream
-=======
+	NefEvtExpoResUriPrefix     = "/" + ServiceNefEvtExpo + "/v1"
+	NefSmCtxResUriPrefix       = "/" + ServiceNefSmCtx + "/v1"
 	AsSessionQosResUriPrefix   = "/" + ServiceAsSessionQos + "/v1"
->>>>>>> Stashed changes
+	MonEvtResUriPrefix         = "/" + ServiceMonEvt + "/v1"
+	AnaExpoResUriPrefix        = "/" + ServiceAnaExpo + "/v1"
+	DevTrigResUriPrefix        = "/" + ServiceDevTrig + "/v1"
+	NiddResUriPrefix           = "/" + ServiceNidd + "/v1"
 	NefCallbackResUriPrefix    = "/" + ServiceNefCallback + "/v1"
 )
 
@@ -100,6 +115,23 @@ type Configuration struct {
 	NrfUri      string    `yaml:"nrfUri,omitempty" valid:"required"`
 	NrfCertPem  string    `yaml:"nrfCertPem,omitempty" valid:"optional"`
 	ServiceList []Service `yaml:"serviceList,omitempty" valid:"required"`
+	Store       *Store    `yaml:"store,omitempty" valid:"optional"`
+	// Static ExternalGroupId to internal group ID mapping, consulted before UDM
+	ExtGroupIdMapping map[string]string `yaml:"extGroupIdMapping,omitempty" valid:"optional"`
+	// How the PCF of the AF sessions is selected, bsf or nrf
+	PcfSelection string `yaml:"pcfSelection,omitempty" valid:"in(bsf|nrf),optional"`
+	// AFs allowed on the northbound APIs, no restriction applies when absent
+	AfAuthorization []AfAuthorization `yaml:"afAuthorization,omitempty" valid:"optional"`
+	// Bearer token validation of the inbound requests
+	TokenValidation *TokenValidation `yaml:"tokenValidation,omitempty" valid:"optional"`
+	// CAPIF core function the northbound APIs are published to
+	Capif *Capif `yaml:"capif,omitempty" valid:"optional"`
+	// Caching time and allowed delay of the PFDs provisioned by the AFs
+	Pfd *Pfd `yaml:"pfd,omitempty" valid:"optional"`
+	// AFs the events of Nnef_EventExposure are collected from
+	EventExposure *EventExposure `yaml:"eventExposure,omitempty" valid:"optional"`
+	// SMS-SF the device triggers are delivered through
+	DeviceTriggering *DeviceTriggering `yaml:"deviceTriggering,omitempty" valid:"optional"`
 }
 
 type Logger struct {
@@ -129,24 +161,73 @@ func (c *Configuration) validate() (bool, error) {
 		}
 	}
 
+	if pfd := c.Pfd; pfd != nil && (pfd.CachingTime < 0 || pfd.MinAllowedDelay < 0) {
+		return false, appendInvalid(errors.New("pfd: cachingTime and minAllowedDelay shall not be negative"))
+	}
+
+	serviceNames := make(map[string]bool)
 	for i, s := range c.ServiceList {
 		switch s.ServiceName {
+		case ServiceTraffInflu:
+		case ServicePfdMng:
 		case ServiceNefPfd:
 		case ServiceNefOam:
-<<<<<<< Updated upstream
-		default:
-			err := errors.New("invalid serviceList[" + strconv.Itoa(i) + "]: " +
-				s.ServiceName + ", should be " + ServiceNefPfd + " or " + ServiceNefOam)
-=======
+		case ServiceNefEvtExpo:
+		case ServiceNefSmCtx:
 		case ServiceAsSessionQos:
+		case ServiceMonEvt:
+		case ServiceAnaExpo:
+		case ServiceDevTrig:
+		case ServiceNidd:
 		default:
 			err := errors.New("invalid serviceList[" + strconv.Itoa(i) + "]: " +
-				s.ServiceName + ", should be " + ServiceNefPfd + ", " + ServiceNefOam +
-				" or " + ServiceAsSessionQos)
->>>>>>> Stashed changes
+				s.ServiceName + ", should be " + ServiceTraffInflu + ", " + ServicePfdMng + ", " +
+				ServiceNefPfd + ", " + ServiceNefOam + ", " + ServiceNefEvtExpo + ", " + ServiceNefSmCtx + ", " +
+				ServiceAsSessionQos + ", " + ServiceMonEvt + ", " + ServiceAnaExpo + ", " + ServiceDevTrig +
+				" or " + ServiceNidd)
+			return false, appendInvalid(err)
+		}
+		if serviceNames[s.ServiceName] {
+			err := errors.New("invalid serviceList[" + strconv.Itoa(i) + "]: duplicated " + s.ServiceName)
+			return false, appendInvalid(err)
+		}
+		serviceNames[s.ServiceName] = true
+		if s.ApiPrefix != "" && !govalidator.IsURL(s.ApiPrefix) {
+			err := errors.New("invalid serviceList[" + strconv.Itoa(i) + "].apiPrefix: " + s.ApiPrefix)
+			return false, appendInvalid(err)
+		}
+		if s.SuppFeat != "" && !govalidator.IsHexadecimal(s.SuppFeat) {
+			err := errors.New("invalid serviceList[" + strconv.Itoa(i) + "].suppFeat: " + s.SuppFeat)
 			return false, appendInvalid(err)
 		}
 	}
+
+	for i := range c.AfAuthorization {
+		if result, err := c.AfAuthorization[i].validate(i); err != nil {
+			return result, err
+		}
+	}
+
+	if ee := c.EventExposure; ee != nil {
+		for i := range ee.Afs {
+			if result, err := ee.Afs[i].validate(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if tv := c.TokenValidation; tv != nil {
+		if tv.Sbi != nil && tv.Sbi.Enable && len(tv.Sbi.KeyPems) == 0 && c.NrfCertPem == "" {
+			return false, appendInvalid(errors.New("tokenValidation.sbi is enabled without keyPems nor nrfCertPem"))
+		}
+		if tv.Northbound != nil && tv.Northbound.Enable && len(tv.Northbound.KeyPems) == 0 {
+			return false, appendInvalid(errors.New("tokenValidation.northbound is enabled without keyPems"))
+		}
+		if tv.Northbound != nil && tv.Northbound.Enable && (tv.Northbound.Issuer == "" || tv.Northbound.Audience == "") {
+			return false, appendInvalid(errors.New("tokenValidation.northbound is enabled without issuer or audience"))
+		}
+	}
+
 	result, err := govalidator.ValidateStruct(c)
 	return result, appendInvalid(err)
 }
@@ -174,6 +255,216 @@ func (s *Sbi) validate() (bool, error) {
 type Service struct {
 	ServiceName string `yaml:"serviceName"`
 	SuppFeat    string `yaml:"suppFeat,omitempty"`
+	// URI prefix advertised for the service, e.g. behind a gateway, the SBI URI when absent
+	ApiPrefix string `yaml:"apiPrefix,omitempty"`
+	// The service is served unless explicitly disabled
+	Enable *bool `yaml:"enable,omitempty"`
+}
+
+func (s *Service) Enabled() bool {
+	return s.Enable == nil || *s.Enable
+}
+
+// IsSbiService tells whether the service is an SBI service of the NEF rather
+// than a northbound API
+func (s *Service) IsSbiService() bool {
+	return strings.HasPrefix(s.ServiceName, "nnef-")
+}
+
+// AfAuthorization is the policy of an AF on the northbound APIs. An empty list
+// does not restrict the corresponding request parameter, except that the UEs
+// are restricted by both gpsis and externalGroupIds once either is configured.
+type AfAuthorization struct {
+	AfId           string          `yaml:"afId" valid:"type(string),minstringlength(1),required"`
+	Services       []string        `yaml:"services,omitempty" valid:"optional"`
+	ExternalAppIds []string        `yaml:"externalAppIds,omitempty" valid:"optional"`
+	Dnns           []string        `yaml:"dnns,omitempty" valid:"optional"`
+	Snssais        []models.Snssai `yaml:"snssais,omitempty" valid:"optional"`
+	UeIpv4Ranges   []string        `yaml:"ueIpv4Ranges,omitempty" valid:"optional"`
+	UeIpv6Ranges   []string        `yaml:"ueIpv6Ranges,omitempty" valid:"optional"`
+	// Patterns (path.Match) of the GPSIs, e.g. "extid-*@af1.example.org" or "msisdn-88690*"
+	Gpsis []string `yaml:"gpsis,omitempty" valid:"optional"`
+	// Patterns (path.Match) of the external group IDs
+	ExternalGroupIds []string `yaml:"externalGroupIds,omitempty" valid:"optional"`
+}
+
+func (a *AfAuthorization) validate(idx int) (bool, error) {
+	for _, srv := range a.Services {
+		switch srv {
+		case ServiceTraffInflu, ServicePfdMng, ServiceAsSessionQos, ServiceMonEvt, ServiceAnaExpo, ServiceDevTrig,
+			ServiceNidd:
+		default:
+			err := fmt.Errorf("invalid afAuthorization[%d].services: %s, should be %s, %s, %s, %s, %s, %s or %s",
+				idx, srv, ServiceTraffInflu, ServicePfdMng, ServiceAsSessionQos, ServiceMonEvt, ServiceAnaExpo,
+				ServiceDevTrig, ServiceNidd)
+			return false, appendInvalid(err)
+		}
+	}
+
+	for _, ipRange := range append(append([]string{}, a.UeIpv4Ranges...), a.UeIpv6Ranges...) {
+		if _, _, err := net.ParseCIDR(ipRange); err != nil {
+			return false, appendInvalid(fmt.Errorf("afAuthorization[%d]: %w", idx, err))
+		}
+	}
+
+	for _, pattern := range append(append([]string{}, a.Gpsis...), a.ExternalGroupIds...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return false, appendInvalid(fmt.Errorf("invalid afAuthorization[%d] UE pattern %s: %w", idx, pattern, err))
+		}
+	}
+
+	result, err := govalidator.ValidateStruct(a)
+	return result, appendInvalid(err)
+}
+
+// AllowsService tells whether the AF may use the northbound service.
+func (a *AfAuthorization) AllowsService(srv string) bool {
+	return len(a.Services) == 0 || slices.Contains(a.Services, srv)
+}
+
+func (a *AfAuthorization) AllowsExternalAppId(appId string) bool {
+	return len(a.ExternalAppIds) == 0 || slices.Contains(a.ExternalAppIds, appId)
+}
+
+func (a *AfAuthorization) AllowsDnn(dnn string) bool {
+	return len(a.Dnns) == 0 || slices.Contains(a.Dnns, dnn)
+}
+
+func (a *AfAuthorization) AllowsSnssai(snssai *models.Snssai) bool {
+	if len(a.Snssais) == 0 {
+		return true
+	}
+	for _, allowed := range a.Snssais {
+		if allowed.Sst == snssai.Sst && strings.EqualFold(allowed.Sd, snssai.Sd) {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictsUes tells whether the AF is restricted to the UEs of its gpsis and
+// externalGroupIds, any UE identifier the policy does not list being denied.
+func (a *AfAuthorization) RestrictsUes() bool {
+	return len(a.Gpsis) > 0 || len(a.ExternalGroupIds) > 0
+}
+
+func (a *AfAuthorization) AllowsGpsi(gpsi string) bool {
+	return !a.RestrictsUes() || matchesAny(a.Gpsis, gpsi)
+}
+
+func (a *AfAuthorization) AllowsExternalGroupId(groupId string) bool {
+	return !a.RestrictsUes() || matchesAny(a.ExternalGroupIds, groupId)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsUeAddr tells whether the UE IPv4 address or IPv6 address/prefix is in
+// the ranges of the AF.
+func (a *AfAuthorization) AllowsUeAddr(addr string) bool {
+	ranges := a.UeIpv4Ranges
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		// IPv6 prefix of the UE
+		var err error
+		if ip, _, err = net.ParseCIDR(addr); err != nil {
+			return false
+		}
+	}
+	if ip.To4() == nil {
+		ranges = a.UeIpv6Ranges
+	}
+	if len(ranges) == 0 {
+		return true
+	}
+
+	for _, ipRange := range ranges {
+		if _, ipNet, err := net.ParseCIDR(ipRange); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type TokenValidation struct {
+	// NRF-issued access tokens on the SBI services
+	Sbi *TokenVerifier `yaml:"sbi,omitempty" valid:"optional"`
+	// AF access tokens on the northbound APIs
+	Northbound *TokenVerifier `yaml:"northbound,omitempty" valid:"optional"`
+}
+
+// TokenVerifier lists the PEM files of the public keys or certificates trusted
+// to sign the access tokens, and the issuer and audience the tokens shall hold.
+type TokenVerifier struct {
+	Enable   bool     `yaml:"enable,omitempty" valid:"optional"`
+	KeyPems  []string `yaml:"keyPems,omitempty" valid:"optional"`
+	Issuer   string   `yaml:"issuer,omitempty" valid:"optional"`
+	Audience string   `yaml:"audience,omitempty" valid:"optional"`
+}
+
+// Capif configures the CAPIF API exposing function of the NEF
+type Capif struct {
+	Enable bool   `yaml:"enable" valid:"optional"`
+	Uri    string `yaml:"uri" valid:"url,required"` // apiRoot of the CAPIF core function
+	ApfId  string `yaml:"apfId" valid:"type(string),minstringlength(1),required"`
+	AefId  string `yaml:"aefId" valid:"type(string),minstringlength(1),required"`
+}
+
+// Pfd configures the provisioning of PFDs, durations are in seconds
+type Pfd struct {
+	// How long the PFDF consumers may cache the PFDs of an application
+	CachingTime int32 `yaml:"cachingTime,omitempty" valid:"optional"`
+	// Shortest allowedDelay the NEF accepts, the PFDs with a shorter one are
+	// reported with SHORT_DELAY
+	MinAllowedDelay int32 `yaml:"minAllowedDelay,omitempty" valid:"optional"`
+}
+
+// EventExposure configures the collection of the AF events of Nnef_EventExposure
+type EventExposure struct {
+	Afs []EventExposureAf `yaml:"afs,omitempty" valid:"optional"`
+}
+
+// EventExposureAf is an AF offering the Naf_EventExposure API to the NEF
+type EventExposureAf struct {
+	AfId string `yaml:"afId" valid:"type(string),minstringlength(1),required"`
+	Uri  string `yaml:"uri" valid:"url,required"` // apiRoot of the Naf_EventExposure API
+	// Applications the AF reports events of, any when absent
+	AppIds []string `yaml:"appIds,omitempty" valid:"optional"`
+}
+
+func (a *EventExposureAf) validate() (bool, error) {
+	result, err := govalidator.ValidateStruct(a)
+	return result, appendInvalid(err)
+}
+
+// ServesAnyApp tells whether the AF reports events of one of the applications,
+// an empty list standing for any application.
+func (a *EventExposureAf) ServesAnyApp(appIds []string) bool {
+	if len(a.AppIds) == 0 || len(appIds) == 0 {
+		return true
+	}
+	for _, appId := range appIds {
+		if slices.Contains(a.AppIds, appId) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceTriggering configures the delivery of the device triggers of the AFs
+type DeviceTriggering struct {
+	SmsSfUri string `yaml:"smsSfUri" valid:"url,required"` // apiRoot of the SMS-SF device trigger API
+}
+
+type Store struct {
+	Backend string `yaml:"backend,omitempty" valid:"in(memory|file),optional"`
+	Path    string `yaml:"path,omitempty" valid:"type(string),optional"`
 }
 
 type Tls struct {
@@ -486,14 +777,212 @@ func (c *Config) NrfCertPem() string {
 	return "" // havn't setup in config
 }
 
+// SbiTokenVerifier returns the verifier of the NRF-issued tokens, the keys
+// default to the NRF certificate.
+func (c *Config) SbiTokenVerifier() *TokenVerifier {
+	c.RLock()
+	defer c.RUnlock()
+
+	verifier := &TokenVerifier{}
+	if tv := c.Configuration.TokenValidation; tv != nil && tv.Sbi != nil {
+		verifier.Enable = tv.Sbi.Enable
+		verifier.KeyPems = tv.Sbi.KeyPems
+		verifier.Issuer = tv.Sbi.Issuer
+		verifier.Audience = tv.Sbi.Audience
+	}
+	if len(verifier.KeyPems) == 0 && c.Configuration.NrfCertPem != "" {
+		verifier.KeyPems = []string{c.Configuration.NrfCertPem}
+	}
+	return verifier
+}
+
+func (c *Config) NorthboundTokenVerifier() *TokenVerifier {
+	c.RLock()
+	defer c.RUnlock()
+
+	if tv := c.Configuration.TokenValidation; tv != nil && tv.Northbound != nil {
+		return tv.Northbound
+	}
+	return &TokenVerifier{}
+}
+
+func (c *Config) StoreBackend() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Store != nil && c.Configuration.Store.Backend != "" {
+		return c.Configuration.Store.Backend
+	}
+	return NefDefaultStoreBackend
+}
+
+func (c *Config) StorePath() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Store != nil && c.Configuration.Store.Path != "" {
+		return c.Configuration.Store.Path
+	}
+	return NefDefaultStorePath
+}
+
+func (c *Config) PcfSelection() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.PcfSelection != "" {
+		return c.Configuration.PcfSelection
+	}
+	return NefDefaultPcfSelection
+}
+
+func (c *Config) PfdCachingTime() int32 {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Pfd != nil && c.Configuration.Pfd.CachingTime > 0 {
+		return c.Configuration.Pfd.CachingTime
+	}
+	return NefDefaultPfdCachingTime
+}
+
+func (c *Config) PfdMinAllowedDelay() int32 {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Pfd != nil {
+		return c.Configuration.Pfd.MinAllowedDelay
+	}
+	return 0
+}
+
+func (c *Config) CapifEnabled() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Capif != nil && c.Configuration.Capif.Enable
+}
+
+func (c *Config) CapifUri() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Capif != nil {
+		return strings.TrimSuffix(c.Configuration.Capif.Uri, "/")
+	}
+	return ""
+}
+
+func (c *Config) CapifApfId() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Capif != nil {
+		return c.Configuration.Capif.ApfId
+	}
+	return ""
+}
+
+func (c *Config) CapifAefId() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Capif != nil {
+		return c.Configuration.Capif.AefId
+	}
+	return ""
+}
+
+func (c *Config) DevTrigSmsSfUri() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.DeviceTriggering != nil {
+		return strings.TrimSuffix(c.Configuration.DeviceTriggering.SmsSfUri, "/")
+	}
+	return ""
+}
+
+func (c *Config) StaticInterGroupId(extGroupId string) (string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	interGroupId, ok := c.Configuration.ExtGroupIdMapping[extGroupId]
+	return interGroupId, ok
+}
+
+// EventExposureAfs returns the AFs reporting events of one of the
+// applications, all the AFs when appIds is empty.
+func (c *Config) EventExposureAfs(appIds []string) []EventExposureAf {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.EventExposure == nil {
+		return nil
+	}
+	var afs []EventExposureAf
+	for _, af := range c.Configuration.EventExposure.Afs {
+		if af.ServesAnyApp(appIds) {
+			afs = append(afs, af)
+		}
+	}
+	return afs
+}
+
+// AfAuthorization returns the policy of the AF, nil when the AF is not allowed.
+// The second return is false when no AF authorization is configured.
+func (c *Config) AfAuthorization(afId string) (*AfAuthorization, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	if len(c.Configuration.AfAuthorization) == 0 {
+		return nil, false
+	}
+	for i := range c.Configuration.AfAuthorization {
+		if c.Configuration.AfAuthorization[i].AfId == afId {
+			return &c.Configuration.AfAuthorization[i], true
+		}
+	}
+	return nil, true
+}
+
+// ServiceList returns the enabled services
 func (c *Config) ServiceList() []Service {
 	c.RLock()
 	defer c.RUnlock()
 
-	if len(c.Configuration.ServiceList) > 0 {
-		return c.Configuration.ServiceList
+	var services []Service
+	for _, s := range c.Configuration.ServiceList {
+		if s.Enabled() {
+			services = append(services, s)
+		}
 	}
-	return nil
+	return services
+}
+
+func (c *Config) ServiceEnabled(name string) bool {
+	for _, s := range c.ServiceList() {
+		if s.ServiceName == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) serviceApiPrefix(name string) string {
+	c.RLock()
+	var apiPrefix string
+	for _, s := range c.Configuration.ServiceList {
+		if s.ServiceName == name {
+			apiPrefix = strings.TrimSuffix(s.ApiPrefix, "/")
+		}
+	}
+	c.RUnlock()
+
+	if apiPrefix != "" {
+		return apiPrefix
+	}
+	return c.SbiUri()
 }
 
 func (c *Config) GetCertPemPath() string {
@@ -516,11 +1005,16 @@ func (c *Config) GetCertKeyPath() string {
 	return NefDefaultPrivateKeyPath
 }
 
+// NFServices returns the enabled SBI services advertised in the NF profile,
+// the northbound APIs of the AFs are not NF services.
 func (c *Config) NFServices() []models.NrfNfManagementNfService {
 	versions := strings.Split(c.Version(), ".")
 	majorVersionUri := "v" + versions[0]
 	var nfServices []models.NrfNfManagementNfService
 	for i, s := range c.ServiceList() {
+		if !s.IsSbiService() {
+			continue
+		}
 		nfService := models.NrfNfManagementNfService{
 			ServiceInstanceId: strconv.Itoa(i),
 			ServiceName:       models.ServiceName(s.ServiceName),
@@ -532,7 +1026,7 @@ func (c *Config) NFServices() []models.NrfNfManagementNfService {
 			},
 			Scheme:          models.UriScheme(c.SbiScheme()),
 			NfServiceStatus: models.NfServiceStatus_REGISTERED,
-			ApiPrefix:       c.SbiUri(),
+			ApiPrefix:       c.serviceApiPrefix(s.ServiceName),
 			IpEndPoints: []models.IpEndPoint{
 				{
 					Ipv4Address: c.SbiRegisterIP(),
@@ -548,19 +1042,32 @@ func (c *Config) NFServices() []models.NrfNfManagementNfService {
 }
 
 func (c *Config) ServiceUri(name string) string {
+	apiPrefix := c.serviceApiPrefix(name)
 	switch name {
 	case ServiceTraffInflu:
-		return c.SbiUri() + TraffInfluResUriPrefix
+		return apiPrefix + TraffInfluResUriPrefix
 	case ServicePfdMng:
-		return c.SbiUri() + PfdMngResUriPrefix
+		return apiPrefix + PfdMngResUriPrefix
 	case ServiceNefPfd:
-		return c.SbiUri() + NefPfdMngResUriPrefix
+		return apiPrefix + NefPfdMngResUriPrefix
 	case ServiceNefOam:
-		return c.SbiUri() + NefOamResUriPrefix
+		return apiPrefix + NefOamResUriPrefix
+	case ServiceNefEvtExpo:
+		return apiPrefix + NefEvtExpoResUriPrefix
+	case ServiceNefSmCtx:
+		return apiPrefix + NefSmCtxResUriPrefix
 	case ServiceNefCallback:
-		return c.SbiUri() + NefCallbackResUriPrefix
+		return apiPrefix + NefCallbackResUriPrefix
 	case ServiceAsSessionQos:
-		return c.SbiUri() + AsSessionQosResUriPrefix
+		return apiPrefix + AsSessionQosResUriPrefix
+	case ServiceMonEvt:
+		return apiPrefix + MonEvtResUriPrefix
+	case ServiceAnaExpo:
+		return apiPrefix + AnaExpoResUriPrefix
+	case ServiceDevTrig:
+		return apiPrefix + DevTrigResUriPrefix
+	case ServiceNidd:
+		return apiPrefix + NiddResUriPrefix
 	default:
 		return ""
 	}
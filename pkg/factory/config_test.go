@@ -0,0 +1,42 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNFServices(t *testing.T) {
+	disabled := false
+	cfg := &Config{
+		Info: &Info{
+			Version: "1.0.1",
+		},
+		Configuration: &Configuration{
+			Sbi: &Sbi{
+				Scheme:       "http",
+				RegisterIPv4: "127.0.0.5",
+				Port:         8000,
+			},
+			ServiceList: []Service{
+				{ServiceName: ServiceTraffInflu},
+				{ServiceName: ServicePfdMng},
+				{ServiceName: ServiceNefPfd, SuppFeat: "1"},
+				{ServiceName: ServiceNefEvtExpo, ApiPrefix: "https://nef.example.com"},
+				{ServiceName: ServiceNefSmCtx, Enable: &disabled},
+				{ServiceName: ServiceAsSessionQos},
+				{ServiceName: ServiceNidd},
+			},
+		},
+	}
+
+	// Only the enabled SBI services are advertised, not the northbound APIs
+	nfServices := cfg.NFServices()
+	require.Len(t, nfServices, 2)
+	require.Equal(t, models.ServiceName_NNEF_PFDMANAGEMENT, nfServices[0].ServiceName)
+	require.Equal(t, "1", nfServices[0].SupportedFeatures)
+	require.Equal(t, "http://127.0.0.5:8000", nfServices[0].ApiPrefix)
+	require.Equal(t, models.ServiceName_NNEF_EVENTEXPOSURE, nfServices[1].ServiceName)
+	require.Equal(t, "https://nef.example.com", nfServices[1].ApiPrefix)
+}
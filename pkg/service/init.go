@@ -7,9 +7,11 @@ import (
 	"os"
 	"runtime/debug"
 	"sync"
+	"time"
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics/business"
 	"github.com/free5gc/nef/internal/sbi"
 	"github.com/free5gc/nef/internal/sbi/consumer"
 	"github.com/free5gc/nef/internal/sbi/notifier"
@@ -22,6 +24,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const capifPublishRetryDelay = 2 * time.Second
+
 var NEF *NefApp
 
 var _ app.App = &NefApp{}
@@ -59,10 +63,16 @@ func NewApp(
 	if nef.nefCtx, err = nef_context.NewContext(nef); err != nil {
 		return nil, err
 	}
+	if err = nef.nefCtx.LoadFromStore(); err != nil {
+		return nil, err
+	}
 	if nef.consumer, err = consumer.NewConsumer(nef); err != nil {
 		return nil, err
 	}
-	if nef.notifier, err = notifier.NewNotifier(); err != nil {
+	if nef.notifier, err = notifier.NewNotifier(nef.nefCtx.Store()); err != nil {
+		return nil, err
+	}
+	if err = nef.notifier.LoadFromStore(); err != nil {
 		return nil, err
 	}
 	if nef.proc, err = processor.NewProcessor(nef); err != nil {
@@ -77,6 +87,8 @@ func NewApp(
 	features := map[utils.MetricTypeEnabled]bool{utils.SBI: true}
 	customMetrics := make(map[utils.MetricTypeEnabled][]prometheus.Collector)
 	if cfg.AreMetricsEnabled() {
+		customMetrics[utils.MetricTypeEnabled(business.SUBSYSTEM_NAME)] = business.GetNotificationMetrics(
+			cfg.GetMetricsNamespace())
 		if nef.metricsServer, err = metrics.NewServer(
 			getInitMetrics(cfg, features, customMetrics), tlsKeyLogPath, logger.InitLog); err != nil {
 			return nil, err
@@ -214,6 +226,11 @@ func (a *NefApp) Start() error {
 		logger.MainLog.Infoln("register to NRF successfully")
 	}
 
+	if a.cfg.CapifEnabled() {
+		a.wg.Add(1)
+		go a.runCapifPublication()
+	}
+
 	a.WaitRoutineStopped()
 	return nil
 }
@@ -232,6 +249,21 @@ func (a *NefApp) listenShutdownEvent() {
 	a.terminateProcedure()
 }
 
+// runCapifPublication publishes the northbound APIs to CAPIF, retrying until
+// it succeeds, and withdraws them on shutdown
+func (a *NefApp) runCapifPublication() {
+	defer a.wg.Done()
+
+	if err := a.proc.PublishServiceApisWithRetry(a.ctx, capifPublishRetryDelay); err != nil {
+		logger.MainLog.Errorf("publish service APIs to CAPIF failed: %+v", err)
+	} else {
+		logger.MainLog.Infoln("publish service APIs to CAPIF successfully")
+	}
+
+	<-a.ctx.Done()
+	a.proc.UnpublishServiceApis()
+}
+
 func (a *NefApp) CallServersStop() {
 	if a.sbiServer != nil {
 		a.sbiServer.Terminate()
@@ -246,6 +278,7 @@ func (a *NefApp) terminateProcedure() {
 	logger.MainLog.Infof("Terminating NEF...")
 
 	a.CallServersStop()
+	a.notifier.Stop()
 
 	// deregister with NRF
 	if _, err := a.consumer.DeregisterNFInstance(); err != nil {
@@ -253,6 +286,8 @@ func (a *NefApp) terminateProcedure() {
 	} else {
 		logger.MainLog.Infof("Deregister from NRF successfully")
 	}
+
+	a.nefCtx.CloseStore()
 }
 
 func (a *NefApp) WaitRoutineStopped() {